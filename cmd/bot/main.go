@@ -2,31 +2,57 @@ package main
 
 import (
 	"log"
+	"os"
 	"time"
 
 	"github.com/SemenovDmitry/manga-crawler-backend/db"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/fetcher"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/maintenance"
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/parsers"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/telegram"
 )
 
 func main() {
-	// Инициализируем подключение к БД
-	_ = db.GetDB()
-	defer db.CloseDB()
+	// Управление миграциями: manga-crawler-backend migrate <команда>
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
+	// Разовое обслуживание БД: manga-crawler-backend maintenance [--dry-run]
+	if len(os.Args) > 1 && os.Args[1] == "maintenance" {
+		os.Exit(runMaintenance(os.Args[2:]))
+	}
+
+	// Инициализируем подключение к БД (драйвер из DB_DRIVER)
+	st, err := db.Open()
+	if err != nil {
+		log.Fatalf("Ошибка подключения к БД: %v", err)
+	}
+	defer st.Close()
 
 	// Запускаем миграции
-	if err := db.RunMigrations(); err != nil {
+	if err := st.Migrate(); err != nil {
 		log.Fatalf("Ошибка миграций: %v", err)
 	}
 
+	// Cookie источников сохраняются в БД между перезапусками
+	fetcher.Default().SetSessionStore(st)
+
 	// Инициализируем Telegram бота
 	tgbot := telegram.InitTelegramBot()
 
 	// Запускаем polling для получения команд от пользователей
-	go telegram.StartPolling(tgbot)
+	go telegram.StartPolling(tgbot, st)
+
+	// Доставляем уведомления о новых главах из очереди
+	go telegram.StartDispatcher(tgbot, st)
+
+	// Чистим старые данные по политике хранения
+	go maintenance.Start(st)
 
 	// Запускаем первую проверку обновлений
-	checkMangaUpdates(tgbot)
+	checkMangaUpdates(tgbot, st)
 
 	// Запускаем периодическую проверку каждые 20 минут
 	ticker := time.NewTicker(20 * time.Minute)
@@ -35,12 +61,12 @@ func main() {
 	log.Println("Manga tracker запущен. Проверка каждые 20 минут...")
 
 	for range ticker.C {
-		checkMangaUpdates(tgbot)
+		checkMangaUpdates(tgbot, st)
 	}
 }
 
-func checkMangaUpdates(telegramBot *telegram.TelegramBot) {
-	sources, err := db.GetActiveSources()
+func checkMangaUpdates(telegramBot *telegram.TelegramBot, st store.Store) {
+	sources, err := st.GetActiveSources()
 	if err != nil {
 		log.Printf("Ошибка получения источников: %v", err)
 		return
@@ -54,7 +80,7 @@ func checkMangaUpdates(telegramBot *telegram.TelegramBot) {
 	log.Printf("Найдено %d активных источников", len(sources))
 
 	for _, source := range sources {
-		mangaList, err := db.GetMangaBySourceID(source.ID)
+		mangaList, err := st.GetMangaBySourceID(source.ID)
 		if err != nil {
 			log.Printf("Ошибка получения манги для %s: %v", source.ParserName, err)
 			continue
@@ -65,8 +91,10 @@ func checkMangaUpdates(telegramBot *telegram.TelegramBot) {
 			continue
 		}
 
-		if err := parsers.RunParser(telegramBot, source, mangaList); err != nil {
+		if err := parsers.RunParser(telegramBot, st, source, mangaList); err != nil {
 			log.Printf("Ошибка парсинга %s: %v", source.ParserName, err)
 		}
 	}
+
+	log.Printf("Статистика запросов:\n%s", fetcher.Default().StatsReport())
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/db"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/maintenance"
+)
+
+const maintenanceUsage = `Использование: manga-crawler-backend maintenance [--dry-run]
+
+Выполняет одно обслуживание БД по политике хранения из переменных RETENTION_*
+и печатает отчёт. С --dry-run только показывает, что было бы удалено или заморожено.
+`
+
+// runMaintenance выполняет подкоманду maintenance и возвращает код выхода
+func runMaintenance(args []string) int {
+	dryRun := false
+	for _, arg := range args {
+		switch arg {
+		case "--dry-run", "-n":
+			dryRun = true
+		default:
+			fmt.Print(maintenanceUsage)
+			return 2
+		}
+	}
+
+	st, err := db.Open()
+	if err != nil {
+		log.Printf("Ошибка подключения к БД: %v", err)
+		return 1
+	}
+	defer st.Close()
+
+	if err := st.Migrate(); err != nil {
+		log.Printf("Ошибка миграций: %v", err)
+		return 1
+	}
+
+	report, err := st.RunMaintenance(maintenance.LoadPolicy(), time.Now().UTC(), dryRun)
+	if err != nil {
+		log.Printf("Ошибка обслуживания БД: %v", err)
+		return 1
+	}
+
+	fmt.Println(maintenance.FormatReport(report))
+	return 0
+}
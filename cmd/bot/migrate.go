@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/db"
+)
+
+// dbDir каталог пакета db с миграциями и файлами схемы (команды запускаются из корня репозитория)
+const dbDir = "db"
+
+const migrateUsage = `Использование: manga-crawler-backend migrate <команда> [аргументы]
+
+Команды:
+  up                 применить все миграции
+  up-by-one          применить одну следующую миграцию
+  up-to VERSION      применить миграции до версии VERSION
+  down               откатить последнюю миграцию
+  down-to VERSION    откатить миграции до версии VERSION
+  redo               откатить и заново применить последнюю миграцию
+  status             показать состояние миграций
+  version            показать текущую версию схемы
+  create NAME        создать пустую миграцию NAME для каждого диалекта (db/migrations, db/migrations_sqlite)
+  schema             сгенерировать файл схемы диалекта (db/schema.sql, db/schema_sqlite.sql)
+  check              сравнить схему БД и файл схемы со схемой после миграций
+
+Драйвер и подключение берутся из тех же переменных, что и у бота (DB_DRIVER, DATABASE_URL, ...).
+`
+
+// runMigrate выполняет подкоманду migrate и возвращает код выхода
+func runMigrate(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Print(migrateUsage)
+		return 2
+	}
+
+	command, args := args[0], args[1:]
+
+	// Создание файлов миграций не требует подключения к БД
+	if command == "create" {
+		if len(args) == 0 {
+			fmt.Print(migrateUsage)
+			return 2
+		}
+		for _, dialect := range db.Dialects {
+			path, err := db.CreateMigration(filepath.Join(dbDir, dialect.MigrationsDir()), strings.Join(args, "_"))
+			if err != nil {
+				log.Printf("Ошибка создания миграции: %v", err)
+				return 1
+			}
+			fmt.Printf("Создана миграция %s\n", path)
+		}
+		return 0
+	}
+
+	st, err := db.Open()
+	if err != nil {
+		log.Printf("Ошибка подключения к БД: %v", err)
+		return 1
+	}
+	defer st.Close()
+
+	switch command {
+	case "schema":
+		return writeSchema(st)
+	case "check":
+		return checkSchema(st)
+	}
+
+	if err := st.RunMigrationCommand(command, args...); err != nil {
+		log.Printf("Ошибка миграций: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// writeSchema генерирует файл схемы из миграций
+func writeSchema(st *db.Store) int {
+	migrated, err := st.MigratedSchema()
+	if err != nil {
+		log.Printf("Ошибка получения схемы: %v", err)
+		return 1
+	}
+
+	path := filepath.Join(dbDir, st.Dialect().SchemaFile())
+	if err := os.WriteFile(path, []byte(db.SchemaHeader(st.Dialect())+migrated), 0o644); err != nil {
+		log.Printf("Ошибка записи схемы: %v", err)
+		return 1
+	}
+
+	fmt.Printf("Схема записана в %s\n", path)
+	return 0
+}
+
+// checkSchema сравнивает схему БД и файл схемы со схемой, которую дают миграции
+func checkSchema(st *db.Store) int {
+	migrated, err := st.MigratedSchema()
+	if err != nil {
+		log.Printf("Ошибка получения схемы: %v", err)
+		return 1
+	}
+
+	live, err := st.DumpSchema()
+	if err != nil {
+		log.Printf("Ошибка получения схемы БД: %v", err)
+		return 1
+	}
+
+	code := 0
+
+	if diff := db.DiffSchema(migrated, live); len(diff) > 0 {
+		fmt.Println("Схема БД отличается от схемы после миграций (не применены миграции или изменения внесены вручную):")
+		fmt.Println(strings.Join(diff, "\n"))
+		code = 1
+	} else {
+		fmt.Println("Схема БД совпадает с миграциями")
+	}
+
+	path := filepath.Join(dbDir, st.Dialect().SchemaFile())
+	file, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Ошибка чтения %s: %v", path, err)
+		return 1
+	}
+
+	if diff := db.DiffSchema(db.SchemaHeader(st.Dialect())+migrated, string(file)); len(diff) > 0 {
+		fmt.Printf("%s устарел, обновите его командой migrate schema:\n", path)
+		fmt.Println(strings.Join(diff, "\n"))
+		code = 1
+	} else {
+		fmt.Printf("%s актуален\n", path)
+	}
+
+	return code
+}
@@ -1,17 +1,21 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
 )
 
 // GetChaptersByMangaID возвращает все главы манги
-func GetChaptersByMangaID(mangaID int) ([]types.Chapter, error) {
-	database := GetDB()
+func (d *Store) GetChaptersByMangaID(mangaID int) ([]types.Chapter, error) {
+	database := d.db
 
 	query := `
-		SELECT id, manga_id, url, title, discovered_at
+		SELECT id, manga_id, url, title, number, translator, discovered_at
 		FROM chapters
 		WHERE manga_id = $1
 		ORDER BY discovered_at DESC
@@ -28,59 +32,207 @@ func GetChaptersByMangaID(mangaID int) ([]types.Chapter, error) {
 	var chapters []types.Chapter
 	for rows.Next() {
 		var c types.Chapter
-		err := rows.Scan(&c.ID, &c.MangaID, &c.URL, &c.Title, &c.DiscoveredAt)
+		var number, translator sql.NullString
+		err := rows.Scan(&c.ID, &c.MangaID, &c.URL, &c.Title, &number, &translator, &c.DiscoveredAt)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка сканирования главы: %w", err)
 		}
+		if number.Valid {
+			c.Number = number.String
+		}
+		if translator.Valid {
+			c.Translator = translator.String
+		}
 		chapters = append(chapters, c)
 	}
 
 	return chapters, nil
 }
 
-// CreateChapter создаёт новую главу
-func CreateChapter(mangaID int, url, title string) (*types.Chapter, error) {
-	database := GetDB()
+// CreateChapter создаёт новую главу.
+// Возвращает nil без ошибки, если глава с таким URL у манги уже есть.
+func (d *Store) CreateChapter(mangaID int, url, title, number, translator string) (*types.Chapter, error) {
+	database := d.db
 
 	query := `
-		INSERT INTO chapters (manga_id, url, title)
-		VALUES ($1, $2, $3)
+		INSERT INTO chapters (manga_id, url, title, number, translator, discovered_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (manga_id, url) DO NOTHING
 		RETURNING id, manga_id, url, title, discovered_at
 	`
 
-	var c types.Chapter
+	c := types.Chapter{Number: number, Translator: translator}
 
-	err := database.QueryRow(query, mangaID, url, title).Scan(&c.ID, &c.MangaID, &c.URL, &c.Title, &c.DiscoveredAt)
+	err := database.QueryRow(query, mangaID, url, title, nullString(number), nullString(translator), d.now().UTC()).Scan(&c.ID, &c.MangaID, &c.URL, &c.Title, &c.DiscoveredAt)
 
-	if err != nil {
-		// Если ON CONFLICT сработал, глава уже существует — не ошибка
+	// ON CONFLICT сработал — глава уже существует, это не ошибка
+	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания главы: %w", err)
+	}
 
 	return &c, nil
 }
 
-// CreateChapters создаёт несколько глав и возвращает только новые
-func CreateChapters(mangaID int, chapters []types.Chapter) ([]types.Chapter, error) {
-	var newChapters []types.Chapter
+// CreateChapters создаёт несколько глав одним запросом и возвращает только новые
+func (d *Store) CreateChapters(mangaID int, chapters []types.Chapter) ([]types.Chapter, error) {
+	return insertChapters(d.db, mangaID, chapters, d.now().UTC())
+}
 
-	for _, ch := range chapters {
-		created, err := CreateChapter(mangaID, ch.URL, ch.Title)
-		if err != nil {
+// IngestChapters сохраняет главы из проверки и обновляет мангу в одной транзакции:
+// новые главы, последняя глава (если новые есть) и время проверки фиксируются вместе.
+// Если задан workID, там же новые главы анонсируются в рамках произведения и для каждого
+// подписчика создаётся уведомление — падение после фиксации не теряет уведомления.
+// Возвращает только новые главы в порядке входного списка.
+func (d *Store) IngestChapters(mangaID, workID int, chapters []types.Chapter) ([]types.Chapter, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := d.now()
+
+	newChapters, err := insertChapters(tx, mangaID, chapters, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(newChapters) > 0 {
+		lastChapter := newChapters[0]
+		_, err = tx.Exec(`
+			UPDATE manga
+			SET last_chapter_url = $1, last_chapter_title = $2, last_check_at = $3, updated_at = $3
+			WHERE id = $4
+		`, lastChapter.URL, lastChapter.Title, now, mangaID)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE manga
+			SET last_check_at = $1, updated_at = $1
+			WHERE id = $2
+		`, now, mangaID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обновления манги: %w", err)
+	}
+
+	if workID != 0 && len(newChapters) > 0 {
+		if err := enqueueChapterNotifications(tx, workID, newChapters); err != nil {
 			return nil, err
 		}
-		if created != nil {
-			newChapters = append(newChapters, *created)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return newChapters, nil
+}
+
+// queryer общий интерфейс подключения и транзакции
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertChapters вставляет главы одним многострочным INSERT ... ON CONFLICT DO NOTHING
+// и возвращает вставленные строки в порядке входного списка.
+// Главы без URL и повторы URL в списке пропускаются; у всех вставленных одно время обнаружения.
+func insertChapters(q queryer, mangaID int, chapters []types.Chapter, discoveredAt time.Time) ([]types.Chapter, error) {
+	var values []string
+	var args []any
+	order := make(map[string]int)
+
+	for _, ch := range chapters {
+		if ch.URL == "" {
+			continue
+		}
+		if _, dup := order[ch.URL]; dup {
+			continue
 		}
+		order[ch.URL] = len(order)
+
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6))
+		args = append(args, mangaID, ch.URL, ch.Title, nullString(ch.Number), nullString(ch.Translator), discoveredAt)
+	}
+
+	if len(values) == 0 {
+		return nil, nil
 	}
 
+	rows, err := q.Query(`
+		INSERT INTO chapters (manga_id, url, title, number, translator, discovered_at)
+		VALUES `+strings.Join(values, ", ")+`
+		ON CONFLICT (manga_id, url) DO NOTHING
+		RETURNING id, manga_id, url, title, number, translator, discovered_at
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения глав: %w", err)
+	}
+	defer rows.Close()
+
+	var newChapters []types.Chapter
+	for rows.Next() {
+		var c types.Chapter
+		var number, translator sql.NullString
+		if err := rows.Scan(&c.ID, &c.MangaID, &c.URL, &c.Title, &number, &translator, &c.DiscoveredAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования главы: %w", err)
+		}
+		c.Number = number.String
+		c.Translator = translator.String
+		newChapters = append(newChapters, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения глав: %w", err)
+	}
+
+	// Порядок RETURNING не гарантирован, а первой должна идти самая свежая глава фида
+	sort.Slice(newChapters, func(i, j int) bool {
+		return order[newChapters[i].URL] < order[newChapters[j].URL]
+	})
+
 	return newChapters, nil
 }
 
+// GetChapterByURL возвращает главу по её URL
+func (d *Store) GetChapterByURL(url string) (*types.Chapter, error) {
+	database := d.db
+
+	var c types.Chapter
+	var number, translator sql.NullString
+
+	err := database.QueryRow(`
+		SELECT id, manga_id, url, title, number, translator, discovered_at
+		FROM chapters
+		WHERE url = $1
+		ORDER BY discovered_at
+		LIMIT 1
+	`, url).Scan(&c.ID, &c.MangaID, &c.URL, &c.Title, &number, &translator, &c.DiscoveredAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса главы: %w", err)
+	}
+
+	if number.Valid {
+		c.Number = number.String
+	}
+	if translator.Valid {
+		c.Translator = translator.String
+	}
+
+	return &c, nil
+}
+
 // ChapterExists проверяет существование главы
-func ChapterExists(mangaID int, url string) (bool, error) {
-	database := GetDB()
+func (d *Store) ChapterExists(mangaID int, url string) (bool, error) {
+	database := d.db
 
 	var exists bool
 	err := database.QueryRow(`
@@ -93,3 +245,45 @@ func ChapterExists(mangaID int, url string) (bool, error) {
 
 	return exists, nil
 }
+
+// SetChapterNumber сохраняет номер главы (для глав, добавленных до появления номеров)
+func (d *Store) SetChapterNumber(chapterID int, number string) error {
+	database := d.db
+
+	_, err := database.Exec(`
+		UPDATE chapters SET number = $1 WHERE id = $2
+	`, nullString(number), chapterID)
+
+	if err != nil {
+		return fmt.Errorf("ошибка обновления номера главы: %w", err)
+	}
+
+	return nil
+}
+
+// GetMangaTranslators возвращает команды переводчиков, выпускавшие главы манги
+func (d *Store) GetMangaTranslators(mangaID int) ([]string, error) {
+	database := d.db
+
+	rows, err := database.Query(`
+		SELECT DISTINCT translator
+		FROM chapters
+		WHERE manga_id = $1 AND translator IS NOT NULL AND translator <> ''
+		ORDER BY translator
+	`, mangaID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса команд переводчиков: %w", err)
+	}
+	defer rows.Close()
+
+	var translators []string
+	for rows.Next() {
+		var translator string
+		if err := rows.Scan(&translator); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования команды переводчиков: %w", err)
+		}
+		translators = append(translators, translator)
+	}
+
+	return translators, nil
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// RecordCrawl сохраняет результат проверки манги в историю
+func (d *Store) RecordCrawl(record types.CrawlRecord) error {
+	database := d.db
+
+	var mangaID sql.NullInt64
+	if record.MangaID != 0 {
+		mangaID = sql.NullInt64{Int64: int64(record.MangaID), Valid: true}
+	}
+
+	var httpStatus sql.NullInt64
+	if record.HTTPStatus != 0 {
+		httpStatus = sql.NullInt64{Int64: int64(record.HTTPStatus), Valid: true}
+	}
+
+	_, err := database.Exec(`
+		INSERT INTO crawl_history (source_id, manga_id, status, error_kind, error, http_status, new_chapters)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, record.SourceID, mangaID, record.Status, nullString(record.ErrorKind), nullString(record.Error), httpStatus, record.NewChapters)
+
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения истории проверки: %w", err)
+	}
+
+	return nil
+}
+
+// GetMangaCrawlHistory возвращает последние проверки манги
+func (d *Store) GetMangaCrawlHistory(mangaID int, limit int) ([]types.CrawlRecord, error) {
+	database := d.db
+
+	rows, err := database.Query(`
+		SELECT id, source_id, manga_id, status, error_kind, error, http_status, new_chapters, created_at
+		FROM crawl_history
+		WHERE manga_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, mangaID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса истории проверок: %w", err)
+	}
+	defer rows.Close()
+
+	var history []types.CrawlRecord
+	for rows.Next() {
+		var r types.CrawlRecord
+		var mangaIDNull, httpStatus sql.NullInt64
+		var errorKind, errorText sql.NullString
+
+		err := rows.Scan(&r.ID, &r.SourceID, &mangaIDNull, &r.Status, &errorKind, &errorText, &httpStatus, &r.NewChapters, &r.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования истории проверок: %w", err)
+		}
+
+		r.MangaID = int(mangaIDNull.Int64)
+		r.ErrorKind = errorKind.String
+		r.Error = errorText.String
+		r.HTTPStatus = int(httpStatus.Int64)
+
+		history = append(history, r)
+	}
+
+	return history, nil
+}
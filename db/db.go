@@ -4,72 +4,94 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
-	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
-var (
-	conn *sql.DB
-	once sync.Once
-)
-
-// GetDB возвращает singleton подключение к базе данных
-func GetDB() *sql.DB {
-	once.Do(func() {
-		var err error
-		conn, err = initDB()
-		if err != nil {
-			log.Fatalf("Ошибка подключения к БД: %v", err)
-		}
-	})
-	return conn
-}
-
-// initDB инициализирует подключение к PostgreSQL
-func initDB() (*sql.DB, error) {
+// Open открывает хранилище с драйвером из DB_DRIVER: postgres (по умолчанию) или sqlite
+func Open() (*Store, error) {
 	// Загружаем .env если есть
 	_ = godotenv.Load()
 
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "mangauser")
-	password := getEnv("DB_PASSWORD", "mangapass")
-	dbname := getEnv("DB_NAME", "mangadb")
+	dialect, err := parseDialect(getEnv("DB_DRIVER", string(DialectPostgres)))
+	if err != nil {
+		return nil, err
+	}
+
+	var database *sql.DB
+	switch dialect {
+	case DialectSQLite:
+		database, err = connectSQLite()
+	default:
+		database, err = connectPostgres()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Подключение к базе данных успешно установлено (%s)", dialect)
+	return &Store{db: &conn{DB: database, dialect: dialect}, now: time.Now}, nil
+}
+
+// connectPostgres открывает подключение к PostgreSQL с настройками пула из LoadConfig.
+// Пока сервер недоступен (например, ещё стартует в docker-compose), повторяет попытки с нарастающей паузой.
+func connectPostgres() (*sql.DB, error) {
+	cfg := LoadConfig()
 
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname,
-	)
+	connStr, err := cfg.DSN()
+	if err != nil {
+		return nil, err
+	}
 
 	database, err := sql.Open("postgres", connStr)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка открытия соединения: %w", err)
 	}
 
+	database.SetMaxOpenConns(cfg.MaxOpenConns)
+	database.SetMaxIdleConns(cfg.MaxIdleConns)
+	database.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	database.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
 	// Проверяем соединение
-	if err := database.Ping(); err != nil {
-		return nil, fmt.Errorf("ошибка пинга БД: %w", err)
-	}
+	for attempt := 1; ; attempt++ {
+		err = database.Ping()
+		if err == nil {
+			return database, nil
+		}
 
-	log.Println("Подключение к базе данных успешно установлено")
-	return database, nil
-}
+		if attempt >= cfg.ConnectAttempts {
+			database.Close()
+			return nil, fmt.Errorf("ошибка пинга БД после %d попыток: %w", attempt, err)
+		}
 
-// CloseDB закрывает соединение с БД
-func CloseDB() error {
-	if conn != nil {
-		return conn.Close()
+		delay := cfg.backoff(attempt)
+		log.Printf("БД недоступна (попытка %d/%d): %v. Повтор через %v", attempt, cfg.ConnectAttempts, err, delay)
+		time.Sleep(delay)
 	}
-	return nil
 }
 
-// getEnv получает переменную окружения или возвращает значение по умолчанию
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// connectSQLite открывает файл SQLite из SQLITE_PATH.
+// Внешние ключи включаются явно (в SQLite они выключены по умолчанию), журнал — WAL.
+func connectSQLite() (*sql.DB, error) {
+	path := getEnv("SQLITE_PATH", "manga.db")
+
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+
+	database, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия файла SQLite: %w", err)
+	}
+
+	// SQLite допускает одного писателя: одно соединение исключает ошибки SQLITE_BUSY
+	database.SetMaxOpenConns(1)
+
+	if err := database.Ping(); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("ошибка открытия файла SQLite: %w", err)
 	}
-	return defaultValue
+
+	return database, nil
 }
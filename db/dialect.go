@@ -0,0 +1,118 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+)
+
+// Dialect SQL-диалект хранилища
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres" // PostgreSQL (основной)
+	DialectSQLite   Dialect = "sqlite"   // SQLite (однопользовательские установки)
+)
+
+// parseDialect разбирает значение DB_DRIVER
+func parseDialect(value string) (Dialect, error) {
+	switch Dialect(value) {
+	case DialectPostgres, "postgresql", "pg":
+		return DialectPostgres, nil
+	case DialectSQLite, "sqlite3":
+		return DialectSQLite, nil
+	}
+	return "", fmt.Errorf("неизвестный DB_DRIVER %q (ожидается postgres или sqlite)", value)
+}
+
+// gooseDialect название диалекта для goose
+func (dialect Dialect) gooseDialect() string {
+	if dialect == DialectSQLite {
+		return "sqlite3"
+	}
+	return "postgres"
+}
+
+// Dialects все поддерживаемые диалекты
+var Dialects = []Dialect{DialectPostgres, DialectSQLite}
+
+// MigrationsDir каталог миграций диалекта (относительно db/)
+func (dialect Dialect) MigrationsDir() string {
+	if dialect == DialectSQLite {
+		return "migrations_sqlite"
+	}
+	return "migrations"
+}
+
+// SchemaFile файл со сгенерированной схемой диалекта (относительно db/)
+func (dialect Dialect) SchemaFile() string {
+	if dialect == DialectSQLite {
+		return "schema_sqlite.sql"
+	}
+	return "schema.sql"
+}
+
+// placeholderPattern позиционные параметры PostgreSQL: $1, $2, ...
+var placeholderPattern = regexp.MustCompile(`\$(\d+)`)
+
+// rebind переводит запрос из синтаксиса PostgreSQL в синтаксис диалекта.
+// В SQLite $N заменяется на ?N: номер сохраняется, поэтому повторное
+// использование параметра ($3 дважды) работает так же.
+// RETURNING и ON CONFLICT SQLite поддерживает начиная с 3.35 и переводить их не нужно.
+func (dialect Dialect) rebind(query string) string {
+	if dialect != DialectSQLite {
+		return query
+	}
+	return placeholderPattern.ReplaceAllString(query, "?$1")
+}
+
+// conn подключение к БД, переводящее запросы под свой диалект
+type conn struct {
+	*sql.DB
+	dialect Dialect
+}
+
+// Query выполняет запрос, возвращающий строки
+func (c *conn) Query(query string, args ...any) (*sql.Rows, error) {
+	return c.DB.Query(c.dialect.rebind(query), args...)
+}
+
+// QueryRow выполняет запрос, возвращающий одну строку
+func (c *conn) QueryRow(query string, args ...any) *sql.Row {
+	return c.DB.QueryRow(c.dialect.rebind(query), args...)
+}
+
+// Exec выполняет запрос без результата
+func (c *conn) Exec(query string, args ...any) (sql.Result, error) {
+	return c.DB.Exec(c.dialect.rebind(query), args...)
+}
+
+// Begin начинает транзакцию в том же диалекте
+func (c *conn) Begin() (*tx, error) {
+	sqlTx, err := c.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &tx{Tx: sqlTx, dialect: c.dialect}, nil
+}
+
+// tx транзакция, переводящая запросы под свой диалект
+type tx struct {
+	*sql.Tx
+	dialect Dialect
+}
+
+// Query выполняет запрос, возвращающий строки
+func (t *tx) Query(query string, args ...any) (*sql.Rows, error) {
+	return t.Tx.Query(t.dialect.rebind(query), args...)
+}
+
+// QueryRow выполняет запрос, возвращающий одну строку
+func (t *tx) QueryRow(query string, args ...any) *sql.Row {
+	return t.Tx.QueryRow(t.dialect.rebind(query), args...)
+}
+
+// Exec выполняет запрос без результата
+func (t *tx) Exec(query string, args ...any) (sql.Result, error) {
+	return t.Tx.Exec(t.dialect.rebind(query), args...)
+}
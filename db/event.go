@@ -0,0 +1,139 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// defaultEventsLimit сколько событий возвращать, если в фильтре не указан лимит
+const defaultEventsLimit = 50
+
+// RecordEvent добавляет событие в журнал
+func (d *Store) RecordEvent(event types.Event) error {
+	database := d.db
+
+	payload := []byte("{}")
+	if len(event.Payload) > 0 {
+		var err error
+		payload, err = json.Marshal(event.Payload)
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации события: %w", err)
+		}
+	}
+
+	createdAt := event.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	_, err := database.Exec(`
+		INSERT INTO events (type, actor_id, user_id, manga_id, source_id, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, event.Type, event.ActorID, nullInt64(event.UserID), nullInt64(int64(event.MangaID)), nullInt64(int64(event.SourceID)),
+		string(payload), createdAt.UTC())
+
+	if err != nil {
+		return fmt.Errorf("ошибка записи события: %w", err)
+	}
+
+	return nil
+}
+
+// GetEvents возвращает события по фильтру, новые первыми
+func (d *Store) GetEvents(filter types.EventFilter) ([]types.Event, error) {
+	database := d.db
+
+	var conditions []string
+	var args []any
+
+	// where добавляет условие; "?" в нём заменяется номером параметра
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(condition, "?", fmt.Sprintf("$%d", len(args)), 1))
+	}
+
+	if filter.Type != "" {
+		where("type = ?", filter.Type)
+	}
+	if filter.ActorID != 0 {
+		where("actor_id = ?", filter.ActorID)
+	}
+	if filter.UserID != 0 {
+		where("user_id = ?", filter.UserID)
+	}
+	if filter.MangaID != 0 {
+		where("manga_id = ?", filter.MangaID)
+	}
+	if filter.SourceID != 0 {
+		where("source_id = ?", filter.SourceID)
+	}
+	if !filter.Since.IsZero() {
+		where("created_at >= ?", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		where("created_at < ?", filter.Until.UTC())
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultEventsLimit
+	}
+	args = append(args, limit)
+
+	query := `
+		SELECT id, type, actor_id, user_id, manga_id, source_id, payload, created_at
+		FROM events`
+	if len(conditions) > 0 {
+		query += `
+		WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d`, len(args))
+
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса событий: %w", err)
+	}
+	defer rows.Close()
+
+	var events []types.Event
+	for rows.Next() {
+		var e types.Event
+		var userID, mangaID, sourceID sql.NullInt64
+		var payload string
+
+		err := rows.Scan(&e.ID, &e.Type, &e.ActorID, &userID, &mangaID, &sourceID, &payload, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования события: %w", err)
+		}
+
+		e.UserID = userID.Int64
+		e.MangaID = int(mangaID.Int64)
+		e.SourceID = int(sourceID.Int64)
+		if err := json.Unmarshal([]byte(payload), &e.Payload); err != nil {
+			return nil, fmt.Errorf("ошибка разбора события %d: %w", e.ID, err)
+		}
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения событий: %w", err)
+	}
+
+	return events, nil
+}
+
+// nullInt64 преобразует число в sql.NullInt64 (0 — NULL)
+func nullInt64(n int64) sql.NullInt64 {
+	if n == 0 {
+		return sql.NullInt64{Valid: false}
+	}
+	return sql.NullInt64{Int64: n, Valid: true}
+}
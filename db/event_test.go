@@ -0,0 +1,27 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+func TestEventsAppendOnly(t *testing.T) {
+	s := openSQLite(t)
+
+	if err := s.RecordEvent(types.Event{Type: types.EventSubscribe, ActorID: 1001, UserID: 1001}); err != nil {
+		t.Fatalf("RecordEvent: %v", err)
+	}
+
+	if _, err := s.db.Exec(`UPDATE events SET type = $1`, types.EventUnsubscribe); err == nil {
+		t.Error("запись журнала изменена")
+	}
+
+	events, err := s.GetEvents(types.EventFilter{})
+	if err != nil {
+		t.Fatalf("GetEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != types.EventSubscribe {
+		t.Errorf("журнал после попытки изменения: %+v", events)
+	}
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// prunedChapters главы сверх лимита на мангу (самые старые).
+// Главы одной проверки найдены в одно время и сохранены в порядке фида, свежие первыми,
+// поэтому при равном времени старше та, что сохранена позже.
+const prunedChapters = `
+	SELECT id FROM (
+		SELECT id, ROW_NUMBER() OVER (PARTITION BY manga_id ORDER BY discovered_at DESC, id ASC) AS rn
+		FROM chapters
+	) ranked
+	WHERE rn > $1
+`
+
+// RunMaintenance применяет политику хранения одной транзакцией.
+// При dryRun все изменения откатываются — отчёт содержит точные числа, но данные не меняются.
+func (d *Store) RunMaintenance(policy types.RetentionPolicy, now time.Time, dryRun bool) (types.MaintenanceReport, error) {
+	report := types.MaintenanceReport{DryRun: dryRun}
+	now = now.UTC()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return report, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	// exec выполняет шаг обслуживания и возвращает число затронутых строк
+	exec := func(step string, query string, args ...any) (int, error) {
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка обслуживания (%s): %w", step, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("ошибка обслуживания (%s): %w", step, err)
+		}
+		return int(affected), nil
+	}
+
+	if policy.ChaptersPerManga > 0 {
+		if policy.ArchiveChapters {
+			report.ChaptersArchived, err = exec("архив глав", `
+				INSERT INTO chapters_archive (id, manga_id, url, title, number, translator, discovered_at, archived_at)
+				SELECT id, manga_id, url, title, number, translator, discovered_at, $2
+				FROM chapters
+				WHERE id IN (`+prunedChapters+`)
+				ON CONFLICT (id) DO NOTHING
+			`, policy.ChaptersPerManga, now)
+			if err != nil {
+				return report, err
+			}
+		}
+
+		report.ChaptersDeleted, err = exec("удаление глав", `
+			DELETE FROM chapters WHERE id IN (`+prunedChapters+`)
+		`, policy.ChaptersPerManga)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if policy.OrphanGrace > 0 {
+		// Отсрочка отсчитывается с первого обслуживания, заметившего мангу без подписчиков
+		report.MangaOrphaned, err = exec("манга без подписчиков", `
+			UPDATE manga SET orphaned_since = $1
+			WHERE orphaned_since IS NULL
+			  AND NOT EXISTS (SELECT 1 FROM user_subscriptions us WHERE us.manga_id = manga.id)
+		`, now)
+		if err != nil {
+			return report, err
+		}
+
+		_, err = exec("манга с подписчиками", `
+			UPDATE manga SET orphaned_since = NULL, frozen_at = NULL
+			WHERE orphaned_since IS NOT NULL
+			  AND EXISTS (SELECT 1 FROM user_subscriptions us WHERE us.manga_id = manga.id)
+		`)
+		if err != nil {
+			return report, err
+		}
+
+		cutoff := now.Add(-policy.OrphanGrace)
+
+		if policy.OrphanAction == types.OrphanDelete {
+			report.MangaDeleted, err = exec("удаление манги", `
+				DELETE FROM manga WHERE orphaned_since <= $1
+			`, cutoff)
+		} else {
+			report.MangaFrozen, err = exec("заморозка манги", `
+				UPDATE manga SET frozen_at = $1
+				WHERE frozen_at IS NULL AND orphaned_since <= $2
+			`, now, cutoff)
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if policy.InactiveUserGrace > 0 {
+		report.UsersDeleted, err = exec("неактивные пользователи", `
+			DELETE FROM telegram_users
+			WHERE is_active = false AND inactive_since <= $1
+		`, now.Add(-policy.InactiveUserGrace))
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if policy.NotificationsTTL > 0 {
+		report.NotificationsDeleted, err = exec("уведомления", `
+			DELETE FROM notifications
+			WHERE status <> $1 AND created_at <= $2
+		`, types.NotificationPending, now.Add(-policy.NotificationsTTL))
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if policy.CrawlHistoryTTL > 0 {
+		report.CrawlHistoryDeleted, err = exec("история проверок", `
+			DELETE FROM crawl_history WHERE created_at <= $1
+		`, now.Add(-policy.CrawlHistoryTTL))
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if policy.EventsTTL > 0 {
+		report.EventsDeleted, err = exec("журнал событий", `
+			DELETE FROM events WHERE created_at <= $1
+		`, now.Add(-policy.EventsTTL))
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return report, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return report, nil
+}
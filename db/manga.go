@@ -8,14 +8,14 @@ import (
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
 )
 
-// GetMangaBySourceID возвращает все манги для источника
-func GetMangaBySourceID(sourceID int) ([]types.Manga, error) {
-	database := GetDB()
+// GetMangaBySourceID возвращает мангу источника для проверки (замороженная пропускается)
+func (d *Store) GetMangaBySourceID(sourceID int) ([]types.Manga, error) {
+	database := d.db
 
 	rows, err := database.Query(`
-		SELECT id, source_id, url, title, last_chapter_url, last_chapter_title, last_check_at, created_at, updated_at
+		SELECT id, source_id, url, title, last_chapter_url, last_chapter_title, last_check_at, work_id, created_at, updated_at
 		FROM manga
-		WHERE source_id = $1
+		WHERE source_id = $1 AND frozen_at IS NULL
 	`, sourceID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка запроса манги: %w", err)
@@ -27,8 +27,9 @@ func GetMangaBySourceID(sourceID int) ([]types.Manga, error) {
 		var m types.Manga
 		var lastChapterURL, lastChapterTitle sql.NullString
 		var lastCheckAt sql.NullTime
+		var workID sql.NullInt64
 
-		err := rows.Scan(&m.ID, &m.SourceID, &m.URL, &m.Title, &lastChapterURL, &lastChapterTitle, &lastCheckAt, &m.CreatedAt, &m.UpdatedAt)
+		err := rows.Scan(&m.ID, &m.SourceID, &m.URL, &m.Title, &lastChapterURL, &lastChapterTitle, &lastCheckAt, &workID, &m.CreatedAt, &m.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка сканирования манги: %w", err)
 		}
@@ -42,6 +43,9 @@ func GetMangaBySourceID(sourceID int) ([]types.Manga, error) {
 		if lastCheckAt.Valid {
 			m.LastCheckAt = &lastCheckAt.Time
 		}
+		if workID.Valid {
+			m.WorkID = int(workID.Int64)
+		}
 
 		mangaList = append(mangaList, m)
 	}
@@ -50,18 +54,19 @@ func GetMangaBySourceID(sourceID int) ([]types.Manga, error) {
 }
 
 // GetMangaByID возвращает мангу по ID
-func GetMangaByID(id int) (*types.Manga, error) {
-	database := GetDB()
+func (d *Store) GetMangaByID(id int) (*types.Manga, error) {
+	database := d.db
 
 	var m types.Manga
 	var lastChapterURL, lastChapterTitle sql.NullString
 	var lastCheckAt sql.NullTime
+	var workID sql.NullInt64
 
 	err := database.QueryRow(`
-		SELECT id, source_id, url, title, last_chapter_url, last_chapter_title, last_check_at, created_at, updated_at
+		SELECT id, source_id, url, title, last_chapter_url, last_chapter_title, last_check_at, work_id, created_at, updated_at
 		FROM manga
 		WHERE id = $1
-	`, id).Scan(&m.ID, &m.SourceID, &m.URL, &m.Title, &lastChapterURL, &lastChapterTitle, &lastCheckAt, &m.CreatedAt, &m.UpdatedAt)
+	`, id).Scan(&m.ID, &m.SourceID, &m.URL, &m.Title, &lastChapterURL, &lastChapterTitle, &lastCheckAt, &workID, &m.CreatedAt, &m.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -79,23 +84,27 @@ func GetMangaByID(id int) (*types.Manga, error) {
 	if lastCheckAt.Valid {
 		m.LastCheckAt = &lastCheckAt.Time
 	}
+	if workID.Valid {
+		m.WorkID = int(workID.Int64)
+	}
 
 	return &m, nil
 }
 
 // GetMangaBySourceAndURL возвращает мангу по source_id и url
-func GetMangaBySourceAndURL(sourceID int, url string) (*types.Manga, error) {
-	database := GetDB()
+func (d *Store) GetMangaBySourceAndURL(sourceID int, url string) (*types.Manga, error) {
+	database := d.db
 
 	var m types.Manga
 	var lastChapterURL, lastChapterTitle sql.NullString
 	var lastCheckAt sql.NullTime
+	var workID sql.NullInt64
 
 	err := database.QueryRow(`
-		SELECT id, source_id, url, title, last_chapter_url, last_chapter_title, last_check_at, created_at, updated_at
+		SELECT id, source_id, url, title, last_chapter_url, last_chapter_title, last_check_at, work_id, created_at, updated_at
 		FROM manga
 		WHERE source_id = $1 AND url = $2
-	`, sourceID, url).Scan(&m.ID, &m.SourceID, &m.URL, &m.Title, &lastChapterURL, &lastChapterTitle, &lastCheckAt, &m.CreatedAt, &m.UpdatedAt)
+	`, sourceID, url).Scan(&m.ID, &m.SourceID, &m.URL, &m.Title, &lastChapterURL, &lastChapterTitle, &lastCheckAt, &workID, &m.CreatedAt, &m.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -113,13 +122,16 @@ func GetMangaBySourceAndURL(sourceID int, url string) (*types.Manga, error) {
 	if lastCheckAt.Valid {
 		m.LastCheckAt = &lastCheckAt.Time
 	}
+	if workID.Valid {
+		m.WorkID = int(workID.Int64)
+	}
 
 	return &m, nil
 }
 
 // UpdateMangaLastChapter обновляет информацию о последней главе
-func UpdateMangaLastChapter(mangaID int, chapterURL, chapterTitle string) error {
-	database := GetDB()
+func (d *Store) UpdateMangaLastChapter(mangaID int, chapterURL, chapterTitle string) error {
+	database := d.db
 
 	_, err := database.Exec(`
 		UPDATE manga
@@ -135,8 +147,8 @@ func UpdateMangaLastChapter(mangaID int, chapterURL, chapterTitle string) error
 }
 
 // UpdateMangaLastCheck обновляет время последней проверки
-func UpdateMangaLastCheck(mangaID int) error {
-	database := GetDB()
+func (d *Store) UpdateMangaLastCheck(mangaID int) error {
+	database := d.db
 
 	now := time.Now()
 	_, err := database.Exec(`
@@ -153,8 +165,8 @@ func UpdateMangaLastCheck(mangaID int) error {
 }
 
 // CreateManga создаёт новую мангу
-func CreateManga(sourceID int, url, title string) (*types.Manga, error) {
-	database := GetDB()
+func (d *Store) CreateManga(sourceID int, url, title string) (*types.Manga, error) {
+	database := d.db
 
 	var m types.Manga
 	err := database.QueryRow(`
@@ -171,13 +183,13 @@ func CreateManga(sourceID int, url, title string) (*types.Manga, error) {
 }
 
 // GetMangaWithSubscribers возвращает мангу с подписчиками
-func GetMangaWithSubscribers(mangaID int) (*types.Manga, error) {
-	manga, err := GetMangaByID(mangaID)
+func (d *Store) GetMangaWithSubscribers(mangaID int) (*types.Manga, error) {
+	manga, err := d.GetMangaByID(mangaID)
 	if err != nil || manga == nil {
 		return manga, err
 	}
 
-	subscribers, err := GetMangaSubscribers(mangaID)
+	subscribers, err := d.GetMangaSubscribers(mangaID)
 	if err != nil {
 		return nil, err
 	}
@@ -187,8 +199,8 @@ func GetMangaWithSubscribers(mangaID int) (*types.Manga, error) {
 }
 
 // GetMangaSubscribers возвращает подписчиков манги
-func GetMangaSubscribers(mangaID int) ([]types.TelegramUser, error) {
-	database := GetDB()
+func (d *Store) GetMangaSubscribers(mangaID int) ([]types.TelegramUser, error) {
+	database := d.db
 
 	rows, err := database.Query(`
 		SELECT tu.id, tu.username, tu.first_name, tu.last_name, tu.is_active, tu.created_at, tu.updated_at
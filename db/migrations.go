@@ -1,29 +1,103 @@
 package db
 
 import (
+	"context"
+	"database/sql"
 	"embed"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/pressly/goose/v3"
 )
 
-//go:embed migrations/*.sql
+// Миграции ведутся отдельно для каждого диалекта: migrations (PostgreSQL)
+// и migrations_sqlite (SQLite). Изменения схемы добавляются в оба набора.
+//
+//go:embed migrations/*.sql migrations_sqlite/*.sql
 var embedMigrations embed.FS
 
-// RunMigrations запускает миграции базы данных
-func RunMigrations() error {
-	database := GetDB()
+// MigrationCommands команды goose, доступные через RunMigrationCommand
+var MigrationCommands = []string{"up", "up-by-one", "up-to", "down", "down-to", "redo", "status", "version"}
 
+// Migrate применяет миграции диалекта хранилища
+func (d *Store) Migrate() error {
+	if err := migrate(d.db.DB, d.db.dialect, "up"); err != nil {
+		return err
+	}
+
+	log.Println("Миграции успешно применены")
+	return nil
+}
+
+// RunMigrationCommand выполняет команду goose (up, down, status, redo, ...) над встроенными миграциями диалекта
+func (d *Store) RunMigrationCommand(command string, args ...string) error {
+	known := false
+	for _, c := range MigrationCommands {
+		if c == command {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("неизвестная команда миграций %q (доступны: %s)", command, strings.Join(MigrationCommands, ", "))
+	}
+
+	return migrate(d.db.DB, d.db.dialect, command, args...)
+}
+
+// migrate выполняет команду goose над встроенными миграциями диалекта
+func migrate(database *sql.DB, dialect Dialect, command string, args ...string) error {
 	goose.SetBaseFS(embedMigrations)
 
-	if err := goose.SetDialect("postgres"); err != nil {
+	if err := goose.SetDialect(dialect.gooseDialect()); err != nil {
 		return err
 	}
 
-	if err := goose.Up(database, "migrations"); err != nil {
-		return err
+	return goose.RunContext(context.Background(), command, database, dialect.MigrationsDir(), args...)
+}
+
+// migrationFilePattern файл миграции: 007_notifications.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_.+\.sql$`)
+
+// CreateMigration создаёт пустую миграцию name со следующим номером в каталоге dir
+// (нумерация как у существующих файлов: 001, 002, ...). Возвращает путь к файлу.
+func CreateMigration(dir, name string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения каталога миграций: %w", err)
 	}
 
-	log.Println("Миграции успешно применены")
-	return nil
+	last := 0
+	for _, entry := range entries {
+		matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if len(matches) < 2 {
+			continue
+		}
+		if version, err := strconv.Atoi(matches[1]); err == nil && version > last {
+			last = version
+		}
+	}
+
+	name = strings.ToLower(strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_'
+	}), "_"))
+	if name == "" {
+		return "", fmt.Errorf("не задано имя миграции")
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%03d_%s.sql", last+1, name))
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("файл миграции %s уже существует", path)
+	}
+
+	if err := os.WriteFile(path, []byte("-- +goose Up\n\n-- +goose Down\n"), 0o644); err != nil {
+		return "", fmt.Errorf("ошибка создания миграции: %w", err)
+	}
+
+	return path, nil
 }
@@ -0,0 +1,44 @@
+-- +goose Up
+
+-- Произведения: группируют мангу с разных источников (readmanga, mintmanga, зеркала)
+CREATE TABLE IF NOT EXISTS works (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,                            -- Основное название
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Названия произведений (основное и альтернативные) для автоматического сопоставления
+CREATE TABLE IF NOT EXISTS work_titles (
+    id SERIAL PRIMARY KEY,
+    work_id INT NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,                            -- Название как есть
+    normalized_title TEXT NOT NULL,                 -- Нормализованное название (нижний регистр, без пунктуации)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(normalized_title)
+);
+
+-- Привязка манги к произведению
+ALTER TABLE manga ADD COLUMN IF NOT EXISTS work_id INT REFERENCES works(id) ON DELETE SET NULL;
+
+-- Нормализованный номер главы (например, 15 или 15.5)
+ALTER TABLE chapters ADD COLUMN IF NOT EXISTS number TEXT;
+
+-- Анонсированные главы: каждый номер главы объявляется один раз на произведение
+CREATE TABLE IF NOT EXISTS work_chapter_announcements (
+    work_id INT NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+    number TEXT NOT NULL,                           -- Номер главы
+    chapter_id INT NOT NULL REFERENCES chapters(id) ON DELETE CASCADE, -- Глава, с которой номер был объявлен впервые
+    announced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (work_id, number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_manga_work_id ON manga(work_id);
+CREATE INDEX IF NOT EXISTS idx_work_titles_work_id ON work_titles(work_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS work_chapter_announcements;
+ALTER TABLE chapters DROP COLUMN IF EXISTS number;
+ALTER TABLE manga DROP COLUMN IF EXISTS work_id;
+DROP TABLE IF EXISTS work_titles;
+DROP TABLE IF EXISTS works;
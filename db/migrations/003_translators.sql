@@ -0,0 +1,25 @@
+-- +goose Up
+
+-- Команда переводчиков (ветка перевода) главы, если источник её сообщает
+ALTER TABLE chapters ADD COLUMN IF NOT EXISTS translator TEXT;
+
+-- Фильтр по командам перевода в подписке:
+-- all — все релизы, team — только preferred_team, first — только первый релиз каждого номера главы
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS team_mode TEXT NOT NULL DEFAULT 'all';
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS preferred_team TEXT;
+
+-- Анонсы теперь уникальны по номеру главы и команде: релиз другой команды — отдельный анонс
+ALTER TABLE work_chapter_announcements ADD COLUMN IF NOT EXISTS translator TEXT NOT NULL DEFAULT '';
+ALTER TABLE work_chapter_announcements DROP CONSTRAINT IF EXISTS work_chapter_announcements_pkey;
+ALTER TABLE work_chapter_announcements ADD PRIMARY KEY (work_id, number, translator);
+
+-- +goose Down
+ALTER TABLE work_chapter_announcements DROP CONSTRAINT IF EXISTS work_chapter_announcements_pkey;
+DELETE FROM work_chapter_announcements a
+USING work_chapter_announcements b
+WHERE a.work_id = b.work_id AND a.number = b.number AND a.announced_at > b.announced_at;
+ALTER TABLE work_chapter_announcements DROP COLUMN IF EXISTS translator;
+ALTER TABLE work_chapter_announcements ADD PRIMARY KEY (work_id, number);
+ALTER TABLE user_subscriptions DROP COLUMN IF EXISTS preferred_team;
+ALTER TABLE user_subscriptions DROP COLUMN IF EXISTS team_mode;
+ALTER TABLE chapters DROP COLUMN IF EXISTS translator;
@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- Сессии источников: cookie, зашифрованные AES-GCM ключом из SESSION_SECRET
+CREATE TABLE IF NOT EXISTS source_sessions (
+    source_id INT PRIMARY KEY REFERENCES sources(id) ON DELETE CASCADE,
+    cookies BYTEA NOT NULL,                         -- Зашифрованный JSON со списком cookie
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS source_sessions;
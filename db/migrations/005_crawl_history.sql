@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- История проверок манги: результат каждой проверки с типом ошибки
+CREATE TABLE IF NOT EXISTS crawl_history (
+    id SERIAL PRIMARY KEY,
+    source_id INT NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    manga_id INT REFERENCES manga(id) ON DELETE CASCADE,
+    status TEXT NOT NULL,                           -- ok, error
+    error_kind TEXT,                                -- blocked, challenge, not_found, server_error, network, parse_error, other
+    error TEXT,                                     -- Текст ошибки
+    http_status INT,                                -- HTTP статус ответа, если был
+    new_chapters INT NOT NULL DEFAULT 0,            -- Сколько новых глав найдено
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_crawl_history_manga_id ON crawl_history(manga_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_crawl_history_source_id ON crawl_history(source_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS crawl_history;
@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- Соблюдать ли robots.txt источника (Disallow и Crawl-delay)
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS respect_robots BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE sources DROP COLUMN IF EXISTS respect_robots;
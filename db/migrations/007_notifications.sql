@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- Исходящие уведомления (outbox): пишутся в одной транзакции с новыми главами,
+-- доставляются отдельным диспетчером с повторами
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES telegram_users(id) ON DELETE CASCADE,
+    manga_id INT NOT NULL REFERENCES manga(id) ON DELETE CASCADE,
+    chapters TEXT NOT NULL,                         -- JSON: главы, о которых уведомляем
+    status TEXT NOT NULL DEFAULT 'pending',         -- pending, sent, failed
+    attempts INT NOT NULL DEFAULT 0,                -- Сколько раз пытались отправить
+    last_error TEXT,                                -- Ошибка последней попытки
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_pending ON notifications(status, next_attempt_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS notifications;
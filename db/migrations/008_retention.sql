@@ -0,0 +1,41 @@
+-- +goose Up
+
+-- Обслуживание (internal/maintenance): очистка старых глав, манги без подписчиков и неактивных пользователей
+
+-- Манга без подписчиков: с какого момента (отмечает обслуживание) и когда заморожена (не проверяется)
+ALTER TABLE manga ADD COLUMN IF NOT EXISTS orphaned_since TIMESTAMP;
+ALTER TABLE manga ADD COLUMN IF NOT EXISTS frozen_at TIMESTAMP;
+
+-- Когда пользователь стал неактивным (например, заблокировал бота)
+ALTER TABLE telegram_users ADD COLUMN IF NOT EXISTS inactive_since TIMESTAMP;
+
+-- Архив глав, вытесненных политикой хранения
+CREATE TABLE IF NOT EXISTS chapters_archive (
+    id INT PRIMARY KEY,                             -- ID главы из chapters
+    manga_id INT NOT NULL REFERENCES manga(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT NOT NULL,
+    number TEXT,
+    translator TEXT,
+    discovered_at TIMESTAMP,
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_chapters_archive_manga_id ON chapters_archive(manga_id);
+
+-- Анонс переживает удаление главы: иначе номер объявился бы повторно
+ALTER TABLE work_chapter_announcements ALTER COLUMN chapter_id DROP NOT NULL;
+ALTER TABLE work_chapter_announcements DROP CONSTRAINT IF EXISTS work_chapter_announcements_chapter_id_fkey;
+ALTER TABLE work_chapter_announcements ADD CONSTRAINT work_chapter_announcements_chapter_id_fkey
+    FOREIGN KEY (chapter_id) REFERENCES chapters(id) ON DELETE SET NULL;
+
+-- +goose Down
+DELETE FROM work_chapter_announcements WHERE chapter_id IS NULL;
+ALTER TABLE work_chapter_announcements DROP CONSTRAINT IF EXISTS work_chapter_announcements_chapter_id_fkey;
+ALTER TABLE work_chapter_announcements ADD CONSTRAINT work_chapter_announcements_chapter_id_fkey
+    FOREIGN KEY (chapter_id) REFERENCES chapters(id) ON DELETE CASCADE;
+ALTER TABLE work_chapter_announcements ALTER COLUMN chapter_id SET NOT NULL;
+DROP TABLE IF EXISTS chapters_archive;
+ALTER TABLE telegram_users DROP COLUMN IF EXISTS inactive_since;
+ALTER TABLE manga DROP COLUMN IF EXISTS frozen_at;
+ALTER TABLE manga DROP COLUMN IF EXISTS orphaned_since;
@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- Нечёткий поиск по названиям манги и альтернативным названиям произведений (/find)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Ключ поиска: нижний регистр, кириллица латиницей ("Берсерк" -> "berserk").
+-- Должна совпадать с utils.SearchKey.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION search_key(title TEXT) RETURNS TEXT AS $$
+    SELECT translate(
+        replace(replace(replace(replace(replace(replace(replace(replace(lower(title),
+            'ж', 'zh'), 'х', 'kh'), 'ц', 'ts'), 'ч', 'ch'), 'ш', 'sh'), 'щ', 'shch'), 'ю', 'yu'), 'я', 'ya'),
+        'абвгдеёзийклмнопрстуфыэъь',
+        'abvgdeeziiklmnoprstufye'
+    )
+$$ LANGUAGE SQL IMMUTABLE PARALLEL SAFE;
+-- +goose StatementEnd
+
+CREATE INDEX IF NOT EXISTS idx_manga_search_key ON manga USING gin (search_key(title) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_work_titles_search_key ON work_titles USING gin (search_key(title) gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_work_titles_search_key;
+DROP INDEX IF EXISTS idx_manga_search_key;
+DROP FUNCTION IF EXISTS search_key(TEXT);
+-- Расширение pg_trgm не удаляем: им могут пользоваться другие объекты базы
@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- Приглушение подписки (/mute): notify = false. Манга продолжает проверяться, главы сохраняются,
+-- а при возобновлении пользователь получает пропущенные главы, найденные после muted_at.
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS muted_at TIMESTAMP;
+-- До какого момента приглушена подписка (NULL — бессрочно); по истечении уведомления возобновляются сами
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS muted_until TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_muted_until ON user_subscriptions(muted_until) WHERE muted_until IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_subscriptions_muted_until;
+ALTER TABLE user_subscriptions DROP COLUMN IF EXISTS muted_until;
+ALTER TABLE user_subscriptions DROP COLUMN IF EXISTS muted_at;
@@ -0,0 +1,139 @@
+-- +goose Up
+
+-- Схема SQLite для личных установок (DB_DRIVER=sqlite).
+-- Соответствует миграциям Postgres 001–006; дальнейшие изменения схемы добавляются в оба набора.
+
+-- Пользователи Telegram
+CREATE TABLE IF NOT EXISTS telegram_users (
+    id INTEGER PRIMARY KEY,                         -- Telegram user ID (используется как chat_id)
+    username TEXT,                                  -- @username
+    first_name TEXT,                                -- Имя
+    last_name TEXT,                                 -- Фамилия
+    is_active BOOLEAN DEFAULT TRUE,                 -- Активен ли пользователь
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Источники (сайты для парсинга)
+CREATE TABLE IF NOT EXISTS sources (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    parser_name TEXT NOT NULL UNIQUE,               -- readmanga, mintmanga
+    base_url TEXT NOT NULL,                         -- https://a.zazaza.me
+    is_active BOOLEAN DEFAULT TRUE,
+    respect_robots BOOLEAN NOT NULL DEFAULT FALSE,  -- Соблюдать robots.txt (Disallow, Crawl-delay)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Произведения: группируют мангу с разных источников
+CREATE TABLE IF NOT EXISTS works (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,                            -- Основное название
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Названия произведений (основное и альтернативные)
+CREATE TABLE IF NOT EXISTS work_titles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    work_id INTEGER NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,                            -- Название как есть
+    normalized_title TEXT NOT NULL UNIQUE,          -- Нормализованное название
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Манга
+CREATE TABLE IF NOT EXISTS manga (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id INTEGER NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,                              -- URL-часть манги (например, asura_2024)
+    title TEXT NOT NULL,                            -- Название манги
+    last_chapter_url TEXT,                          -- URL последней известной главы
+    last_chapter_title TEXT,                        -- Название последней главы
+    last_check_at TIMESTAMP,                        -- Время последней проверки
+    work_id INTEGER REFERENCES works(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(source_id, url)
+);
+
+-- Подписки пользователей на мангу
+CREATE TABLE IF NOT EXISTS user_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES telegram_users(id) ON DELETE CASCADE,
+    manga_id INTEGER NOT NULL REFERENCES manga(id) ON DELETE CASCADE,
+    notify BOOLEAN DEFAULT TRUE,                    -- Отправлять уведомления
+    team_mode TEXT NOT NULL DEFAULT 'all',          -- all, team, first
+    preferred_team TEXT,                            -- Выбранная команда перевода
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, manga_id)
+);
+
+-- Главы манги
+CREATE TABLE IF NOT EXISTS chapters (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    manga_id INTEGER NOT NULL REFERENCES manga(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT NOT NULL,
+    number TEXT,                                    -- Нормализованный номер главы
+    translator TEXT,                                -- Команда переводчиков
+    discovered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(manga_id, url)
+);
+
+-- Анонсированные главы: номер главы объявляется один раз на произведение и команду
+CREATE TABLE IF NOT EXISTS work_chapter_announcements (
+    work_id INTEGER NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+    number TEXT NOT NULL,
+    translator TEXT NOT NULL DEFAULT '',
+    chapter_id INTEGER NOT NULL REFERENCES chapters(id) ON DELETE CASCADE,
+    announced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (work_id, number, translator)
+);
+
+-- Сессии источников: cookie, зашифрованные AES-GCM ключом из SESSION_SECRET
+CREATE TABLE IF NOT EXISTS source_sessions (
+    source_id INTEGER PRIMARY KEY REFERENCES sources(id) ON DELETE CASCADE,
+    cookies BLOB NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- История проверок манги
+CREATE TABLE IF NOT EXISTS crawl_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id INTEGER NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    manga_id INTEGER REFERENCES manga(id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    error_kind TEXT,
+    error TEXT,
+    http_status INTEGER,
+    new_chapters INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_manga_source_id ON manga(source_id);
+CREATE INDEX IF NOT EXISTS idx_manga_work_id ON manga(work_id);
+CREATE INDEX IF NOT EXISTS idx_work_titles_work_id ON work_titles(work_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON user_subscriptions(user_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_manga_id ON user_subscriptions(manga_id);
+CREATE INDEX IF NOT EXISTS idx_chapters_manga_id ON chapters(manga_id);
+CREATE INDEX IF NOT EXISTS idx_crawl_history_manga_id ON crawl_history(manga_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_crawl_history_source_id ON crawl_history(source_id, created_at DESC);
+
+-- Начальные данные источников
+INSERT INTO sources (parser_name, base_url) VALUES
+    ('readmanga', 'https://a.zazaza.me'),
+    ('mintmanga', 'https://1.seimanga.me')
+ON CONFLICT (parser_name) DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS crawl_history;
+DROP TABLE IF EXISTS source_sessions;
+DROP TABLE IF EXISTS work_chapter_announcements;
+DROP TABLE IF EXISTS chapters;
+DROP TABLE IF EXISTS user_subscriptions;
+DROP TABLE IF EXISTS manga;
+DROP TABLE IF EXISTS work_titles;
+DROP TABLE IF EXISTS works;
+DROP TABLE IF EXISTS sources;
+DROP TABLE IF EXISTS telegram_users;
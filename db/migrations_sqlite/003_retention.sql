@@ -0,0 +1,57 @@
+-- +goose Up
+
+-- Обслуживание (internal/maintenance): очистка старых глав, манги без подписчиков и неактивных пользователей
+
+-- Манга без подписчиков: с какого момента (отмечает обслуживание) и когда заморожена (не проверяется)
+ALTER TABLE manga ADD COLUMN orphaned_since TIMESTAMP;
+ALTER TABLE manga ADD COLUMN frozen_at TIMESTAMP;
+
+-- Когда пользователь стал неактивным (например, заблокировал бота)
+ALTER TABLE telegram_users ADD COLUMN inactive_since TIMESTAMP;
+
+-- Архив глав, вытесненных политикой хранения
+CREATE TABLE IF NOT EXISTS chapters_archive (
+    id INTEGER PRIMARY KEY,                         -- ID главы из chapters
+    manga_id INTEGER NOT NULL REFERENCES manga(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT NOT NULL,
+    number TEXT,
+    translator TEXT,
+    discovered_at TIMESTAMP,
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_chapters_archive_manga_id ON chapters_archive(manga_id);
+
+-- Анонс переживает удаление главы: иначе номер объявился бы повторно.
+-- SQLite не меняет внешние ключи через ALTER TABLE, поэтому таблица пересоздаётся.
+CREATE TABLE work_chapter_announcements_new (
+    work_id INTEGER NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+    number TEXT NOT NULL,
+    translator TEXT NOT NULL DEFAULT '',
+    chapter_id INTEGER REFERENCES chapters(id) ON DELETE SET NULL,
+    announced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (work_id, number, translator)
+);
+INSERT INTO work_chapter_announcements_new (work_id, number, translator, chapter_id, announced_at)
+SELECT work_id, number, translator, chapter_id, announced_at FROM work_chapter_announcements;
+DROP TABLE work_chapter_announcements;
+ALTER TABLE work_chapter_announcements_new RENAME TO work_chapter_announcements;
+
+-- +goose Down
+CREATE TABLE work_chapter_announcements_old (
+    work_id INTEGER NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+    number TEXT NOT NULL,
+    translator TEXT NOT NULL DEFAULT '',
+    chapter_id INTEGER NOT NULL REFERENCES chapters(id) ON DELETE CASCADE,
+    announced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (work_id, number, translator)
+);
+INSERT INTO work_chapter_announcements_old (work_id, number, translator, chapter_id, announced_at)
+SELECT work_id, number, translator, chapter_id, announced_at FROM work_chapter_announcements WHERE chapter_id IS NOT NULL;
+DROP TABLE work_chapter_announcements;
+ALTER TABLE work_chapter_announcements_old RENAME TO work_chapter_announcements;
+DROP TABLE IF EXISTS chapters_archive;
+ALTER TABLE telegram_users DROP COLUMN inactive_since;
+ALTER TABLE manga DROP COLUMN frozen_at;
+ALTER TABLE manga DROP COLUMN orphaned_since;
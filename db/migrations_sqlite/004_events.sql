@@ -0,0 +1,31 @@
+-- +goose Up
+
+-- Журнал действий пользователей и администраторов: подписки, настройки, источники, объединения манги.
+-- Только добавление: изменять записи запрещено, старые удаляет обслуживание (RETENTION_EVENTS).
+-- Внешних ключей нет — запись остаётся после удаления пользователя, манги или источника.
+CREATE TABLE IF NOT EXISTS events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type TEXT NOT NULL,
+    actor_id INTEGER NOT NULL DEFAULT 0,          -- Кто выполнил действие (Telegram user ID, 0 — система)
+    user_id INTEGER,                              -- Пользователь, которого касается событие
+    manga_id INTEGER,
+    source_id INTEGER,
+    payload TEXT NOT NULL DEFAULT '{}',           -- Подробности в JSON
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_created_at ON events(created_at);
+CREATE INDEX IF NOT EXISTS idx_events_actor_id ON events(actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_events_user_id ON events(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_events_manga_id ON events(manga_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_events_source_id ON events(source_id, created_at DESC);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS events_append_only BEFORE UPDATE ON events
+BEGIN
+    SELECT RAISE(ABORT, 'журнал событий только пополняется');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS events;
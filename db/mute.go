@@ -0,0 +1,209 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// MuteSubscription приглушает уведомления по подписке до until (nil — бессрочно).
+// Повторный вызов меняет только срок: пропущенные главы по-прежнему считаются от первого приглушения.
+func (d *Store) MuteSubscription(userID int64, mangaID int, until *time.Time) error {
+	database := d.db
+
+	var mutedUntil sql.NullTime
+	if until != nil {
+		mutedUntil = sql.NullTime{Time: until.UTC(), Valid: true}
+	}
+
+	_, err := database.Exec(`
+		UPDATE user_subscriptions
+		SET notify = false, muted_at = COALESCE(muted_at, $1), muted_until = $2
+		WHERE user_id = $3 AND manga_id = $4
+	`, d.now().UTC(), mutedUntil, userID, mangaID)
+
+	if err != nil {
+		return fmt.Errorf("ошибка приглушения подписки: %w", err)
+	}
+
+	return nil
+}
+
+// UnmuteSubscription возобновляет уведомления по подписке и возвращает главы,
+// найденные, пока она была приглушена (свежие первыми). Уведомление о них ставится в очередь
+// в той же транзакции, как в ResumeExpiredMutes. Для неприглушённой подписки возвращает nil.
+func (d *Store) UnmuteSubscription(userID int64, mangaID int) ([]types.Chapter, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	sub, err := getSubscription(tx, userID, mangaID)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil || sub.Notify {
+		return nil, nil
+	}
+
+	missed, err := missedChapters(tx, *sub)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(missed) > 0 {
+		var isActive bool
+		err := tx.QueryRow(`SELECT is_active FROM telegram_users WHERE id = $1`, userID).Scan(&isActive)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка запроса пользователя: %w", err)
+		}
+
+		if isActive {
+			if err := insertNotification(tx, userID, mangaID, missed, time.Now().UTC()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := unmuteSubscription(tx, sub.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return missed, nil
+}
+
+// ResumeExpiredMutes возобновляет подписки, срок приглушения которых истёк к now,
+// и ставит в очередь уведомления о пропущенных главах (только активным пользователям).
+// Возвращает возобновлённые подписки в том виде, в каком они были приглушены.
+func (d *Store) ResumeExpiredMutes(now time.Time) ([]types.UserSubscription, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT us.id, us.user_id, us.manga_id, us.notify, us.team_mode, us.preferred_team, us.created_at, us.muted_at, us.muted_until,
+		       tu.is_active
+		FROM user_subscriptions us
+		JOIN telegram_users tu ON tu.id = us.user_id
+		WHERE us.notify = false AND us.muted_until IS NOT NULL AND us.muted_until <= $1
+		ORDER BY us.id
+	`, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса приглушённых подписок: %w", err)
+	}
+
+	var subs []types.UserSubscription
+	var active []bool
+	for rows.Next() {
+		var sub types.UserSubscription
+		var preferredTeam sql.NullString
+		var mutedAt, mutedUntil sql.NullTime
+		var isActive bool
+
+		err := rows.Scan(&sub.ID, &sub.TelegramUserID, &sub.MangaID, &sub.Notify, &sub.TeamMode, &preferredTeam, &sub.CreatedAt, &mutedAt, &mutedUntil,
+			&isActive)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ошибка сканирования подписки: %w", err)
+		}
+
+		sub.PreferredTeam = preferredTeam.String
+		if mutedAt.Valid {
+			sub.MutedAt = &mutedAt.Time
+		}
+		if mutedUntil.Valid {
+			sub.MutedUntil = &mutedUntil.Time
+		}
+
+		subs = append(subs, sub)
+		active = append(active, isActive)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения приглушённых подписок: %w", err)
+	}
+
+	for i, sub := range subs {
+		missed, err := missedChapters(tx, sub)
+		if err != nil {
+			return nil, err
+		}
+
+		if active[i] && len(missed) > 0 {
+			if err := insertNotification(tx, sub.TelegramUserID, sub.MangaID, missed, now.UTC()); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := unmuteSubscription(tx, sub.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return subs, nil
+}
+
+// missedChapters возвращает главы всех манги произведения подписки, найденные после приглушения
+// и прошедшие её фильтр команд. Граница берётся из той же строки подписки, чтобы сравнивать время в одном формате.
+// Главы одной проверки сохранены в порядке фида, поэтому при равном времени свежее та, что сохранена раньше.
+func missedChapters(database queryer, sub types.UserSubscription) ([]types.Chapter, error) {
+	rows, err := database.Query(`
+		SELECT c.id, c.manga_id, c.url, c.title, c.number, c.translator, c.discovered_at
+		FROM user_subscriptions us
+		JOIN manga sm ON sm.id = us.manga_id
+		JOIN manga m ON m.id = sm.id OR m.work_id = sm.work_id
+		JOIN chapters c ON c.manga_id = m.id
+		WHERE us.id = $1 AND us.muted_at IS NOT NULL AND c.discovered_at >= us.muted_at
+		ORDER BY c.discovered_at DESC, c.id ASC
+	`, sub.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса пропущенных глав: %w", err)
+	}
+	defer rows.Close()
+
+	var chapters []types.Chapter
+	for rows.Next() {
+		var c types.Chapter
+		var number, translator sql.NullString
+		err := rows.Scan(&c.ID, &c.MangaID, &c.URL, &c.Title, &number, &translator, &c.DiscoveredAt)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования главы: %w", err)
+		}
+		c.Number = number.String
+		c.Translator = translator.String
+		chapters = append(chapters, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения пропущенных глав: %w", err)
+	}
+
+	return sub.MissedChapters(chapters), nil
+}
+
+// unmuteSubscription включает уведомления и снимает отметки приглушения
+func unmuteSubscription(tx queryer, subscriptionID int) error {
+	_, err := tx.Exec(`
+		UPDATE user_subscriptions
+		SET notify = true, muted_at = NULL, muted_until = NULL
+		WHERE id = $1
+	`, subscriptionID)
+
+	if err != nil {
+		return fmt.Errorf("ошибка возобновления подписки: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// enqueueChapterNotifications анонсирует новые главы в рамках произведения и создаёт
+// по одному уведомлению на подписчика с главами, прошедшими его фильтр по командам.
+// Уведомление привязано к манге подписки, а не к источнику главы: кнопки под ним управляют подпиской.
+// Вызывается внутри транзакции IngestChapters.
+func enqueueChapterNotifications(tx queryer, workID int, newChapters []types.Chapter) error {
+	// Оставляем только главы, номер которых ещё не объявлялся с другого источника
+	announced, err := claimChapterAnnouncements(tx, workID, newChapters)
+	if err != nil {
+		return err
+	}
+	if len(announced) == 0 {
+		return nil
+	}
+
+	subscribers, err := workSubscribers(tx, workID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	for _, subscriber := range subscribers {
+		wanted := subscriber.Subscription.WantedChapters(announced)
+		if len(wanted) == 0 {
+			continue
+		}
+
+		if err := insertNotification(tx, subscriber.ID, subscriber.Subscription.MangaID, wanted, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertNotification ставит в очередь уведомление пользователю о главах манги
+func insertNotification(tx queryer, userID int64, mangaID int, chapters []types.Chapter, now time.Time) error {
+	payload, err := json.Marshal(chapters)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации глав уведомления: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO notifications (user_id, manga_id, chapters, status, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+	`, userID, mangaID, string(payload), types.NotificationPending, now)
+	if err != nil {
+		return fmt.Errorf("ошибка создания уведомления: %w", err)
+	}
+
+	return nil
+}
+
+// GetDueNotifications возвращает ожидающие уведомления, срок попытки которых наступил
+func (d *Store) GetDueNotifications(now time.Time, limit int) ([]types.Notification, error) {
+	database := d.db
+
+	rows, err := database.Query(`
+		SELECT id, user_id, manga_id, chapters, status, attempts, last_error, next_attempt_at, created_at, sent_at
+		FROM notifications
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY id
+		LIMIT $3
+	`, types.NotificationPending, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса уведомлений: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []types.Notification
+	for rows.Next() {
+		var n types.Notification
+		var payload string
+		var lastError sql.NullString
+		var sentAt sql.NullTime
+
+		err := rows.Scan(&n.ID, &n.UserID, &n.MangaID, &payload, &n.Status, &n.Attempts, &lastError, &n.NextAttemptAt, &n.CreatedAt, &sentAt)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования уведомления: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(payload), &n.Chapters); err != nil {
+			return nil, fmt.Errorf("ошибка разбора глав уведомления %d: %w", n.ID, err)
+		}
+		n.LastError = lastError.String
+		if sentAt.Valid {
+			n.SentAt = &sentAt.Time
+		}
+
+		notifications = append(notifications, n)
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationSent отмечает уведомление доставленным
+func (d *Store) MarkNotificationSent(id int) error {
+	database := d.db
+
+	_, err := database.Exec(`
+		UPDATE notifications
+		SET status = $1, attempts = attempts + 1, last_error = NULL, sent_at = $2
+		WHERE id = $3
+	`, types.NotificationSent, time.Now().UTC(), id)
+
+	if err != nil {
+		return fmt.Errorf("ошибка отметки доставки уведомления: %w", err)
+	}
+
+	return nil
+}
+
+// MarkNotificationRetry учитывает неудачную попытку и откладывает следующую
+func (d *Store) MarkNotificationRetry(id int, lastError string, nextAttemptAt time.Time) error {
+	database := d.db
+
+	_, err := database.Exec(`
+		UPDATE notifications
+		SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2
+		WHERE id = $3
+	`, lastError, nextAttemptAt.UTC(), id)
+
+	if err != nil {
+		return fmt.Errorf("ошибка отметки повтора уведомления: %w", err)
+	}
+
+	return nil
+}
+
+// MarkNotificationFailed учитывает неудачную попытку и прекращает доставку
+func (d *Store) MarkNotificationFailed(id int, lastError string) error {
+	database := d.db
+
+	_, err := database.Exec(`
+		UPDATE notifications
+		SET status = $1, attempts = attempts + 1, last_error = $2
+		WHERE id = $3
+	`, types.NotificationFailed, lastError, id)
+
+	if err != nil {
+		return fmt.Errorf("ошибка отметки сбоя уведомления: %w", err)
+	}
+
+	return nil
+}
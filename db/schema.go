@@ -0,0 +1,288 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+// gooseTable служебная таблица goose, в схему не попадает
+const gooseTable = "goose_db_version"
+
+// SchemaHeader заголовок сгенерированного файла схемы
+func SchemaHeader(dialect Dialect) string {
+	return fmt.Sprintf(`-- Схема базы данных (%s) после всех миграций из db/%s.
+-- Файл сгенерирован командой "go run ./cmd/bot migrate schema" — не редактируйте его вручную.
+-- Проверка расхождений: "go run ./cmd/bot migrate check".
+
+`, dialect, dialect.MigrationsDir())
+}
+
+// DumpSchema возвращает схему подключённой БД: таблицы с колонками и ограничениями, затем индексы.
+// Вывод детерминирован и пригоден для сравнения построчно.
+func (d *Store) DumpSchema() (string, error) {
+	return dumpSchema(d.db.DB, d.db.dialect)
+}
+
+// MigratedSchema применяет встроенные миграции к пустой временной БД и возвращает её схему.
+// Для SQLite это база в памяти, для PostgreSQL — временная схема в той же базе (удаляется после дампа).
+func (d *Store) MigratedSchema() (string, error) {
+	// Вывод goose о применённых миграциях здесь не нужен
+	goose.SetLogger(log.New(io.Discard, "", 0))
+	defer goose.SetLogger(log.Default())
+
+	if d.db.dialect == DialectSQLite {
+		scratch, err := sql.Open("sqlite", "file::memory:?_pragma=foreign_keys(1)")
+		if err != nil {
+			return "", fmt.Errorf("ошибка открытия временной БД: %w", err)
+		}
+		defer scratch.Close()
+
+		// База в памяти живёт, пока открыто соединение
+		scratch.SetMaxOpenConns(1)
+
+		return migratedSchema(scratch, DialectSQLite)
+	}
+
+	name := fmt.Sprintf("schema_check_%d", time.Now().UnixNano())
+	if _, err := d.db.Exec(`CREATE SCHEMA ` + name); err != nil {
+		return "", fmt.Errorf("ошибка создания временной схемы: %w", err)
+	}
+	defer func() {
+		if _, err := d.db.Exec(`DROP SCHEMA ` + name + ` CASCADE`); err != nil {
+			log.Printf("Ошибка удаления временной схемы %s: %v", name, err)
+		}
+	}()
+
+	dsn, err := LoadConfig().DSN()
+	if err != nil {
+		return "", err
+	}
+
+	// Таблицы миграций создаются в первой схеме search_path; public оставляем для расширений
+	scratch, err := sql.Open("postgres", dsn+" "+dsnParam("search_path", name+",public"))
+	if err != nil {
+		return "", fmt.Errorf("ошибка открытия соединения: %w", err)
+	}
+	defer scratch.Close()
+
+	return migratedSchema(scratch, DialectPostgres)
+}
+
+// migratedSchema применяет миграции к пустой БД и снимает её схему
+func migratedSchema(database *sql.DB, dialect Dialect) (string, error) {
+	if err := migrate(database, dialect, "up"); err != nil {
+		return "", fmt.Errorf("ошибка применения миграций к временной БД: %w", err)
+	}
+	return dumpSchema(database, dialect)
+}
+
+// dumpSchema снимает схему БД в формате диалекта
+func dumpSchema(database *sql.DB, dialect Dialect) (string, error) {
+	if dialect == DialectSQLite {
+		return dumpSQLiteSchema(database)
+	}
+	return dumpPostgresSchema(database)
+}
+
+// dumpSQLiteSchema схема SQLite: SQLite хранит исходный текст CREATE в sqlite_master
+func dumpSQLiteSchema(database *sql.DB) (string, error) {
+	rows, err := database.Query(`
+		SELECT sql
+		FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' AND tbl_name <> ?
+		ORDER BY CASE type WHEN 'table' THEN 0 ELSE 1 END, tbl_name, name
+	`, gooseTable)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения схемы: %w", err)
+	}
+	defer rows.Close()
+
+	var sb strings.Builder
+	for rows.Next() {
+		var statement string
+		if err := rows.Scan(&statement); err != nil {
+			return "", fmt.Errorf("ошибка чтения схемы: %w", err)
+		}
+		sb.WriteString(strings.TrimSpace(statement))
+		sb.WriteString(";\n\n")
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("ошибка чтения схемы: %w", err)
+	}
+
+	return sb.String(), nil
+}
+
+// pgTable таблица PostgreSQL для дампа
+type pgTable struct {
+	name        string
+	columns     []string
+	constraints []string
+}
+
+// dumpPostgresSchema схема текущей схемы (current_schema) PostgreSQL по системному каталогу
+func dumpPostgresSchema(database *sql.DB) (string, error) {
+	var schema string
+	if err := database.QueryRow(`SELECT current_schema()`).Scan(&schema); err != nil {
+		return "", fmt.Errorf("ошибка чтения схемы: %w", err)
+	}
+
+	var tables []*pgTable
+	byName := make(map[string]*pgTable)
+
+	rows, err := database.Query(`
+		SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+		       COALESCE(pg_get_expr(d.adbin, d.adrelid), '')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		LEFT JOIN pg_attrdef d ON d.adrelid = c.oid AND d.adnum = a.attnum
+		WHERE n.nspname = $1 AND c.relkind = 'r' AND c.relname <> $2
+		ORDER BY c.relname, a.attnum
+	`, schema, gooseTable)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения колонок: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, column, columnType, defaultValue string
+		var notNull bool
+		if err := rows.Scan(&table, &column, &columnType, &notNull, &defaultValue); err != nil {
+			return "", fmt.Errorf("ошибка чтения колонок: %w", err)
+		}
+
+		t, ok := byName[table]
+		if !ok {
+			t = &pgTable{name: table}
+			byName[table] = t
+			tables = append(tables, t)
+		}
+
+		definition := column + " " + columnType
+		if notNull {
+			definition += " NOT NULL"
+		}
+		if defaultValue != "" {
+			definition += " DEFAULT " + defaultValue
+		}
+		t.columns = append(t.columns, definition)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("ошибка чтения колонок: %w", err)
+	}
+
+	constraintRows, err := database.Query(`
+		SELECT cl.relname, con.conname, pg_get_constraintdef(con.oid)
+		FROM pg_constraint con
+		JOIN pg_class cl ON cl.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = cl.relnamespace
+		WHERE n.nspname = $1 AND cl.relname <> $2
+		ORDER BY cl.relname, con.conname
+	`, schema, gooseTable)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения ограничений: %w", err)
+	}
+	defer constraintRows.Close()
+
+	for constraintRows.Next() {
+		var table, name, definition string
+		if err := constraintRows.Scan(&table, &name, &definition); err != nil {
+			return "", fmt.Errorf("ошибка чтения ограничений: %w", err)
+		}
+		if t, ok := byName[table]; ok {
+			t.constraints = append(t.constraints, "CONSTRAINT "+name+" "+definition)
+		}
+	}
+	if err := constraintRows.Err(); err != nil {
+		return "", fmt.Errorf("ошибка чтения ограничений: %w", err)
+	}
+
+	// Индексы, созданные ограничениями (PRIMARY KEY, UNIQUE), уже описаны в таблицах
+	indexRows, err := database.Query(`
+		SELECT pg_get_indexdef(x.indexrelid)
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE n.nspname = $1 AND t.relname <> $2
+		  AND NOT EXISTS (SELECT 1 FROM pg_constraint c WHERE c.conindid = x.indexrelid)
+		ORDER BY t.relname, i.relname
+	`, schema, gooseTable)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения индексов: %w", err)
+	}
+	defer indexRows.Close()
+
+	var indexes []string
+	for indexRows.Next() {
+		var definition string
+		if err := indexRows.Scan(&definition); err != nil {
+			return "", fmt.Errorf("ошибка чтения индексов: %w", err)
+		}
+		// pg_get_indexdef всегда указывает схему таблицы — убираем, чтобы сравнивать схемы с разными именами
+		indexes = append(indexes, strings.Replace(definition, " ON "+schema+".", " ON ", 1))
+	}
+	if err := indexRows.Err(); err != nil {
+		return "", fmt.Errorf("ошибка чтения индексов: %w", err)
+	}
+
+	var sb strings.Builder
+	for _, t := range tables {
+		sb.WriteString("CREATE TABLE " + t.name + " (\n    ")
+		sb.WriteString(strings.Join(append(t.columns, t.constraints...), ",\n    "))
+		sb.WriteString("\n);\n\n")
+	}
+	for _, index := range indexes {
+		sb.WriteString(index + ";\n")
+	}
+
+	return sb.String(), nil
+}
+
+// DiffSchema построчно сравнивает схемы (без пустых строк): строки только из expected помечаются "-", только из actual — "+".
+// Пустой результат — схемы совпадают.
+func DiffSchema(expected, actual string) []string {
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+
+	count := func(lines []string) map[string]int {
+		counts := make(map[string]int)
+		for _, line := range lines {
+			counts[line]++
+		}
+		return counts
+	}
+	expectedCounts := count(expectedLines)
+	actualCounts := count(actualLines)
+
+	var diff []string
+	for _, line := range expectedLines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if actualCounts[line] > 0 {
+			actualCounts[line]--
+			continue
+		}
+		diff = append(diff, "- "+line)
+	}
+	for _, line := range actualLines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if expectedCounts[line] > 0 {
+			expectedCounts[line]--
+			continue
+		}
+		diff = append(diff, "+ "+line)
+	}
+
+	return diff
+}
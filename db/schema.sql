@@ -1,91 +1,188 @@
--- Manga Crawler Backend - Database Schema
--- PostgreSQL 12+
+-- Схема базы данных (postgres) после всех миграций из db/migrations.
+-- Файл сгенерирован командой "go run ./cmd/bot migrate schema" — не редактируйте его вручную.
+-- Проверка расхождений: "go run ./cmd/bot migrate check".
 
--- ============================================
--- ТАБЛИЦЫ
--- ============================================
+CREATE TABLE chapters (
+    id integer NOT NULL DEFAULT nextval('chapters_id_seq'::regclass),
+    manga_id integer NOT NULL,
+    url text NOT NULL,
+    title text NOT NULL,
+    discovered_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    number text,
+    translator text,
+    CONSTRAINT chapters_manga_id_fkey FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE,
+    CONSTRAINT chapters_manga_id_url_key UNIQUE (manga_id, url),
+    CONSTRAINT chapters_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE chapters_archive (
+    id integer NOT NULL,
+    manga_id integer NOT NULL,
+    url text NOT NULL,
+    title text NOT NULL,
+    number text,
+    translator text,
+    discovered_at timestamp without time zone,
+    archived_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chapters_archive_manga_id_fkey FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE,
+    CONSTRAINT chapters_archive_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE crawl_history (
+    id integer NOT NULL DEFAULT nextval('crawl_history_id_seq'::regclass),
+    source_id integer NOT NULL,
+    manga_id integer,
+    status text NOT NULL,
+    error_kind text,
+    error text,
+    http_status integer,
+    new_chapters integer NOT NULL DEFAULT 0,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT crawl_history_manga_id_fkey FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE,
+    CONSTRAINT crawl_history_pkey PRIMARY KEY (id),
+    CONSTRAINT crawl_history_source_id_fkey FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
+);
+
+CREATE TABLE events (
+    id bigint NOT NULL DEFAULT nextval('events_id_seq'::regclass),
+    type text NOT NULL,
+    actor_id bigint NOT NULL DEFAULT 0,
+    user_id bigint,
+    manga_id integer,
+    source_id integer,
+    payload text NOT NULL DEFAULT '{}'::text,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT events_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE manga (
+    id integer NOT NULL DEFAULT nextval('manga_id_seq'::regclass),
+    source_id integer NOT NULL,
+    url text NOT NULL,
+    title text NOT NULL,
+    last_chapter_url text,
+    last_chapter_title text,
+    last_check_at timestamp without time zone,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    work_id integer,
+    orphaned_since timestamp without time zone,
+    frozen_at timestamp without time zone,
+    CONSTRAINT manga_pkey PRIMARY KEY (id),
+    CONSTRAINT manga_source_id_fkey FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE,
+    CONSTRAINT manga_source_id_url_key UNIQUE (source_id, url),
+    CONSTRAINT manga_work_id_fkey FOREIGN KEY (work_id) REFERENCES works(id) ON DELETE SET NULL
+);
 
--- Пользователи Telegram
-CREATE TABLE IF NOT EXISTS telegram_users (
-    id BIGINT PRIMARY KEY,                              -- Telegram user ID (используется как chat_id)
-    username TEXT,                                      -- @username
-    first_name TEXT,                                    -- Имя
-    last_name TEXT,                                     -- Фамилия
-    is_active BOOLEAN DEFAULT TRUE,                     -- Активен ли пользователь
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,     -- Дата регистрации
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP      -- Дата последнего обновления
+CREATE TABLE notifications (
+    id integer NOT NULL DEFAULT nextval('notifications_id_seq'::regclass),
+    user_id bigint NOT NULL,
+    manga_id integer NOT NULL,
+    chapters text NOT NULL,
+    status text NOT NULL DEFAULT 'pending'::text,
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    sent_at timestamp without time zone,
+    CONSTRAINT notifications_manga_id_fkey FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE,
+    CONSTRAINT notifications_pkey PRIMARY KEY (id),
+    CONSTRAINT notifications_user_id_fkey FOREIGN KEY (user_id) REFERENCES telegram_users(id) ON DELETE CASCADE
 );
 
--- Источники (сайты для парсинга манги)
-CREATE TABLE IF NOT EXISTS sources (
-    id SERIAL PRIMARY KEY,                              -- Уникальный идентификатор
-    parser_name TEXT NOT NULL UNIQUE,                   -- Имя парсера (readmanga, mintmanga)
-    base_url TEXT NOT NULL,                             -- Базовый URL сайта
-    is_active BOOLEAN DEFAULT TRUE,                     -- Активен ли источник
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,     -- Дата добавления
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP      -- Дата последнего обновления
+CREATE TABLE source_sessions (
+    source_id integer NOT NULL,
+    cookies bytea NOT NULL,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT source_sessions_pkey PRIMARY KEY (source_id),
+    CONSTRAINT source_sessions_source_id_fkey FOREIGN KEY (source_id) REFERENCES sources(id) ON DELETE CASCADE
 );
 
--- Манга
-CREATE TABLE IF NOT EXISTS manga (
-    id SERIAL PRIMARY KEY,                              -- Уникальный идентификатор
-    source_id INT NOT NULL,                             -- ID источника
-    url TEXT NOT NULL,                                  -- URL-часть манги (например, asura_2024)
-    title TEXT NOT NULL,                                -- Название манги
-    last_chapter_url TEXT,                              -- URL последней известной главы
-    last_chapter_title TEXT,                            -- Название последней главы
-    last_check_at TIMESTAMP,                            -- Время последней проверки
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,     -- Дата добавления
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,     -- Дата последнего обновления
-    
-    CONSTRAINT fk_manga_source FOREIGN KEY (source_id) 
-        REFERENCES sources(id) ON DELETE CASCADE,
-    CONSTRAINT uq_manga_source_url UNIQUE (source_id, url)
+CREATE TABLE sources (
+    id integer NOT NULL DEFAULT nextval('sources_id_seq'::regclass),
+    parser_name text NOT NULL,
+    base_url text NOT NULL,
+    is_active boolean DEFAULT true,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    respect_robots boolean NOT NULL DEFAULT false,
+    CONSTRAINT sources_parser_name_key UNIQUE (parser_name),
+    CONSTRAINT sources_pkey PRIMARY KEY (id)
 );
 
--- Подписки пользователей на мангу
-CREATE TABLE IF NOT EXISTS user_subscriptions (
-    id SERIAL PRIMARY KEY,                              -- Уникальный идентификатор подписки
-    user_id BIGINT NOT NULL,                            -- ID пользователя Telegram
-    manga_id INT NOT NULL,                              -- ID манги
-    notify BOOLEAN DEFAULT TRUE,                        -- Отправлять ли уведомления
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,     -- Дата подписки
-    
-    CONSTRAINT fk_subscription_user FOREIGN KEY (user_id) 
-        REFERENCES telegram_users(id) ON DELETE CASCADE,
-    CONSTRAINT fk_subscription_manga FOREIGN KEY (manga_id) 
-        REFERENCES manga(id) ON DELETE CASCADE,
-    CONSTRAINT uq_user_manga UNIQUE (user_id, manga_id)
+CREATE TABLE telegram_users (
+    id bigint NOT NULL,
+    username text,
+    first_name text,
+    last_name text,
+    is_active boolean DEFAULT true,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    inactive_since timestamp without time zone,
+    CONSTRAINT telegram_users_pkey PRIMARY KEY (id)
 );
 
--- Главы манги
-CREATE TABLE IF NOT EXISTS chapters (
-    id SERIAL PRIMARY KEY,                              -- Уникальный идентификатор главы
-    manga_id INT NOT NULL,                              -- ID манги
-    url TEXT NOT NULL,                                  -- URL главы
-    title TEXT NOT NULL,                                -- Название главы
-    discovered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,  -- Дата обнаружения главы
-    
-    CONSTRAINT fk_chapter_manga FOREIGN KEY (manga_id) 
-        REFERENCES manga(id) ON DELETE CASCADE,
-    CONSTRAINT uq_chapter_manga_url UNIQUE (manga_id, url)
+CREATE TABLE user_subscriptions (
+    id integer NOT NULL DEFAULT nextval('user_subscriptions_id_seq'::regclass),
+    user_id bigint NOT NULL,
+    manga_id integer NOT NULL,
+    notify boolean DEFAULT true,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    team_mode text NOT NULL DEFAULT 'all'::text,
+    preferred_team text,
+    muted_at timestamp without time zone,
+    muted_until timestamp without time zone,
+    CONSTRAINT user_subscriptions_manga_id_fkey FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE,
+    CONSTRAINT user_subscriptions_pkey PRIMARY KEY (id),
+    CONSTRAINT user_subscriptions_user_id_fkey FOREIGN KEY (user_id) REFERENCES telegram_users(id) ON DELETE CASCADE,
+    CONSTRAINT user_subscriptions_user_id_manga_id_key UNIQUE (user_id, manga_id)
 );
 
--- ============================================
--- ИНДЕКСЫ
--- ============================================
+CREATE TABLE work_chapter_announcements (
+    work_id integer NOT NULL,
+    number text NOT NULL,
+    chapter_id integer,
+    announced_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    translator text NOT NULL DEFAULT ''::text,
+    CONSTRAINT work_chapter_announcements_chapter_id_fkey FOREIGN KEY (chapter_id) REFERENCES chapters(id) ON DELETE SET NULL,
+    CONSTRAINT work_chapter_announcements_pkey PRIMARY KEY (work_id, number, translator),
+    CONSTRAINT work_chapter_announcements_work_id_fkey FOREIGN KEY (work_id) REFERENCES works(id) ON DELETE CASCADE
+);
 
-CREATE INDEX IF NOT EXISTS idx_manga_source_id ON manga(source_id);
-CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON user_subscriptions(user_id);
-CREATE INDEX IF NOT EXISTS idx_subscriptions_manga_id ON user_subscriptions(manga_id);
-CREATE INDEX IF NOT EXISTS idx_chapters_manga_id ON chapters(manga_id);
-CREATE INDEX IF NOT EXISTS idx_chapters_discovered_at ON chapters(discovered_at DESC);
+CREATE TABLE work_titles (
+    id integer NOT NULL DEFAULT nextval('work_titles_id_seq'::regclass),
+    work_id integer NOT NULL,
+    title text NOT NULL,
+    normalized_title text NOT NULL,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT work_titles_normalized_title_key UNIQUE (normalized_title),
+    CONSTRAINT work_titles_pkey PRIMARY KEY (id),
+    CONSTRAINT work_titles_work_id_fkey FOREIGN KEY (work_id) REFERENCES works(id) ON DELETE CASCADE
+);
 
--- ============================================
--- НАЧАЛЬНЫЕ ДАННЫЕ
--- ============================================
+CREATE TABLE works (
+    id integer NOT NULL DEFAULT nextval('works_id_seq'::regclass),
+    title text NOT NULL,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT works_pkey PRIMARY KEY (id)
+);
 
-INSERT INTO sources (parser_name, base_url) VALUES 
-    ('readmanga', 'https://a.zazaza.me'),
-    ('mintmanga', 'https://1.seimanga.me')
-ON CONFLICT (parser_name) DO NOTHING;
+CREATE INDEX idx_chapters_manga_id ON chapters USING btree (manga_id);
+CREATE INDEX idx_chapters_archive_manga_id ON chapters_archive USING btree (manga_id);
+CREATE INDEX idx_crawl_history_manga_id ON crawl_history USING btree (manga_id, created_at DESC);
+CREATE INDEX idx_crawl_history_source_id ON crawl_history USING btree (source_id, created_at DESC);
+CREATE INDEX idx_events_actor_id ON events USING btree (actor_id, created_at DESC);
+CREATE INDEX idx_events_created_at ON events USING btree (created_at);
+CREATE INDEX idx_events_manga_id ON events USING btree (manga_id, created_at DESC);
+CREATE INDEX idx_events_source_id ON events USING btree (source_id, created_at DESC);
+CREATE INDEX idx_events_user_id ON events USING btree (user_id, created_at DESC);
+CREATE INDEX idx_manga_search_key ON manga USING gin (search_key(title) gin_trgm_ops);
+CREATE INDEX idx_manga_source_id ON manga USING btree (source_id);
+CREATE INDEX idx_manga_work_id ON manga USING btree (work_id);
+CREATE INDEX idx_notifications_pending ON notifications USING btree (status, next_attempt_at);
+CREATE INDEX idx_subscriptions_manga_id ON user_subscriptions USING btree (manga_id);
+CREATE INDEX idx_subscriptions_muted_until ON user_subscriptions USING btree (muted_until) WHERE (muted_until IS NOT NULL);
+CREATE INDEX idx_subscriptions_user_id ON user_subscriptions USING btree (user_id);
+CREATE INDEX idx_work_titles_search_key ON work_titles USING gin (search_key(title) gin_trgm_ops);
+CREATE INDEX idx_work_titles_work_id ON work_titles USING btree (work_id);
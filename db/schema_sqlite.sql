@@ -0,0 +1,174 @@
+-- Схема базы данных (sqlite) после всех миграций из db/migrations_sqlite.
+-- Файл сгенерирован командой "go run ./cmd/bot migrate schema" — не редактируйте его вручную.
+-- Проверка расхождений: "go run ./cmd/bot migrate check".
+
+CREATE TABLE chapters (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    manga_id INTEGER NOT NULL REFERENCES manga(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT NOT NULL,
+    number TEXT,                                    -- Нормализованный номер главы
+    translator TEXT,                                -- Команда переводчиков
+    discovered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(manga_id, url)
+);
+
+CREATE TABLE chapters_archive (
+    id INTEGER PRIMARY KEY,                         -- ID главы из chapters
+    manga_id INTEGER NOT NULL REFERENCES manga(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT NOT NULL,
+    number TEXT,
+    translator TEXT,
+    discovered_at TIMESTAMP,
+    archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE crawl_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id INTEGER NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    manga_id INTEGER REFERENCES manga(id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    error_kind TEXT,
+    error TEXT,
+    http_status INTEGER,
+    new_chapters INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type TEXT NOT NULL,
+    actor_id INTEGER NOT NULL DEFAULT 0,          -- Кто выполнил действие (Telegram user ID, 0 — система)
+    user_id INTEGER,                              -- Пользователь, которого касается событие
+    manga_id INTEGER,
+    source_id INTEGER,
+    payload TEXT NOT NULL DEFAULT '{}',           -- Подробности в JSON
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE manga (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id INTEGER NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,                              -- URL-часть манги (например, asura_2024)
+    title TEXT NOT NULL,                            -- Название манги
+    last_chapter_url TEXT,                          -- URL последней известной главы
+    last_chapter_title TEXT,                        -- Название последней главы
+    last_check_at TIMESTAMP,                        -- Время последней проверки
+    work_id INTEGER REFERENCES works(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, orphaned_since TIMESTAMP, frozen_at TIMESTAMP,
+    UNIQUE(source_id, url)
+);
+
+CREATE TABLE notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES telegram_users(id) ON DELETE CASCADE,
+    manga_id INTEGER NOT NULL REFERENCES manga(id) ON DELETE CASCADE,
+    chapters TEXT NOT NULL,                         -- JSON: главы, о которых уведомляем
+    status TEXT NOT NULL DEFAULT 'pending',         -- pending, sent, failed
+    attempts INTEGER NOT NULL DEFAULT 0,            -- Сколько раз пытались отправить
+    last_error TEXT,                                -- Ошибка последней попытки
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP
+);
+
+CREATE TABLE source_sessions (
+    source_id INTEGER PRIMARY KEY REFERENCES sources(id) ON DELETE CASCADE,
+    cookies BLOB NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE sources (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    parser_name TEXT NOT NULL UNIQUE,               -- readmanga, mintmanga
+    base_url TEXT NOT NULL,                         -- https://a.zazaza.me
+    is_active BOOLEAN DEFAULT TRUE,
+    respect_robots BOOLEAN NOT NULL DEFAULT FALSE,  -- Соблюдать robots.txt (Disallow, Crawl-delay)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE telegram_users (
+    id INTEGER PRIMARY KEY,                         -- Telegram user ID (используется как chat_id)
+    username TEXT,                                  -- @username
+    first_name TEXT,                                -- Имя
+    last_name TEXT,                                 -- Фамилия
+    is_active BOOLEAN DEFAULT TRUE,                 -- Активен ли пользователь
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+, inactive_since TIMESTAMP);
+
+CREATE TABLE user_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES telegram_users(id) ON DELETE CASCADE,
+    manga_id INTEGER NOT NULL REFERENCES manga(id) ON DELETE CASCADE,
+    notify BOOLEAN DEFAULT TRUE,                    -- Отправлять уведомления
+    team_mode TEXT NOT NULL DEFAULT 'all',          -- all, team, first
+    preferred_team TEXT,                            -- Выбранная команда перевода
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, muted_at TIMESTAMP, muted_until TIMESTAMP,
+    UNIQUE(user_id, manga_id)
+);
+
+CREATE TABLE "work_chapter_announcements" (
+    work_id INTEGER NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+    number TEXT NOT NULL,
+    translator TEXT NOT NULL DEFAULT '',
+    chapter_id INTEGER REFERENCES chapters(id) ON DELETE SET NULL,
+    announced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (work_id, number, translator)
+);
+
+CREATE TABLE work_titles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    work_id INTEGER NOT NULL REFERENCES works(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,                            -- Название как есть
+    normalized_title TEXT NOT NULL UNIQUE,          -- Нормализованное название
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE works (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,                            -- Основное название
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_chapters_manga_id ON chapters(manga_id);
+
+CREATE INDEX idx_chapters_archive_manga_id ON chapters_archive(manga_id);
+
+CREATE INDEX idx_crawl_history_manga_id ON crawl_history(manga_id, created_at DESC);
+
+CREATE INDEX idx_crawl_history_source_id ON crawl_history(source_id, created_at DESC);
+
+CREATE TRIGGER events_append_only BEFORE UPDATE ON events
+BEGIN
+    SELECT RAISE(ABORT, 'журнал событий только пополняется');
+END;
+
+CREATE INDEX idx_events_actor_id ON events(actor_id, created_at DESC);
+
+CREATE INDEX idx_events_created_at ON events(created_at);
+
+CREATE INDEX idx_events_manga_id ON events(manga_id, created_at DESC);
+
+CREATE INDEX idx_events_source_id ON events(source_id, created_at DESC);
+
+CREATE INDEX idx_events_user_id ON events(user_id, created_at DESC);
+
+CREATE INDEX idx_manga_source_id ON manga(source_id);
+
+CREATE INDEX idx_manga_work_id ON manga(work_id);
+
+CREATE INDEX idx_notifications_pending ON notifications(status, next_attempt_at);
+
+CREATE INDEX idx_subscriptions_manga_id ON user_subscriptions(manga_id);
+
+CREATE INDEX idx_subscriptions_muted_until ON user_subscriptions(muted_until) WHERE muted_until IS NOT NULL;
+
+CREATE INDEX idx_subscriptions_user_id ON user_subscriptions(user_id);
+
+CREATE INDEX idx_work_titles_work_id ON work_titles(work_id);
+
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/utils"
+)
+
+// searchPostgres ранжирует названия через pg_trgm по индексам на search_key(title).
+// Для каждой манги остаётся лучше всего совпавшее название: своё или альтернативное название произведения.
+const searchPostgres = `
+	WITH matches AS (
+		SELECT m.id AS manga_id, m.title AS matched_title, word_similarity($1, search_key(m.title)) AS score
+		FROM manga m
+		WHERE $1 <% search_key(m.title)
+		UNION ALL
+		SELECT m.id, wt.title, word_similarity($1, search_key(wt.title))
+		FROM work_titles wt
+		JOIN manga m ON m.work_id = wt.work_id
+		WHERE $1 <% search_key(wt.title)
+	), best AS (
+		SELECT DISTINCT ON (manga_id) manga_id, matched_title, score
+		FROM matches
+		ORDER BY manga_id, score DESC
+	)
+	SELECT m.id, m.source_id, m.url, m.title, m.last_chapter_url, m.last_chapter_title, m.last_check_at, m.work_id, m.created_at, m.updated_at,
+	       s.parser_name, s.base_url, b.matched_title, b.score
+	FROM best b
+	JOIN manga m ON m.id = b.manga_id
+	JOIN sources s ON s.id = m.source_id
+	WHERE $2::bigint = 0 OR EXISTS (SELECT 1 FROM user_subscriptions us WHERE us.manga_id = m.id AND us.user_id = $2)
+	ORDER BY b.score DESC, m.title, m.id
+	LIMIT $3
+`
+
+// searchCandidates все названия манги для ранжирования в Go (SQLite без pg_trgm)
+const searchCandidates = `
+	WITH titles AS (
+		SELECT m.id AS manga_id, m.title AS matched_title
+		FROM manga m
+		UNION ALL
+		SELECT m.id, wt.title
+		FROM work_titles wt
+		JOIN manga m ON m.work_id = wt.work_id
+	)
+	SELECT m.id, m.source_id, m.url, m.title, m.last_chapter_url, m.last_chapter_title, m.last_check_at, m.work_id, m.created_at, m.updated_at,
+	       s.parser_name, s.base_url, t.matched_title, 0.0
+	FROM titles t
+	JOIN manga m ON m.id = t.manga_id
+	JOIN sources s ON s.id = m.source_id
+	WHERE $1 = 0 OR EXISTS (SELECT 1 FROM user_subscriptions us WHERE us.manga_id = m.id AND us.user_id = $1)
+`
+
+// SearchManga ищет мангу по названиям с учётом опечаток и транслитерации
+func (d *Store) SearchManga(text string, userID int64, limit int) ([]types.MangaSearchResult, error) {
+	key := utils.SearchKey(text)
+	if key == "" || limit <= 0 {
+		return nil, nil
+	}
+
+	database := d.db
+
+	// В SQLite нет pg_trgm: берём все названия и ранжируем их так же, как хранилище в памяти
+	if database.dialect == DialectSQLite {
+		rows, err := database.Query(searchCandidates, userID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка поиска манги: %w", err)
+		}
+		defer rows.Close()
+
+		candidates, err := scanSearchResults(rows)
+		if err != nil {
+			return nil, err
+		}
+		return store.RankSearchResults(key, candidates, limit), nil
+	}
+
+	rows, err := database.Query(searchPostgres, key, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска манги: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSearchResults(rows)
+}
+
+// scanSearchResults читает строки поиска: манга, источник, совпавшее название и похожесть
+func scanSearchResults(rows *sql.Rows) ([]types.MangaSearchResult, error) {
+	var results []types.MangaSearchResult
+	for rows.Next() {
+		var r types.MangaSearchResult
+		var lastChapterURL, lastChapterTitle sql.NullString
+		var lastCheckAt sql.NullTime
+		var workID sql.NullInt64
+
+		err := rows.Scan(&r.ID, &r.SourceID, &r.URL, &r.Title, &lastChapterURL, &lastChapterTitle, &lastCheckAt, &workID, &r.CreatedAt, &r.UpdatedAt,
+			&r.SourceName, &r.SourceBaseURL, &r.MatchedTitle, &r.Score)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования манги: %w", err)
+		}
+
+		if lastChapterURL.Valid {
+			r.LastChapterURL = lastChapterURL.String
+		}
+		if lastChapterTitle.Valid {
+			r.LastChapterTitle = lastChapterTitle.String
+		}
+		if lastCheckAt.Valid {
+			r.LastCheckAt = &lastCheckAt.Time
+		}
+		if workID.Valid {
+			r.WorkID = int(workID.Int64)
+		}
+
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения результатов поиска: %w", err)
+	}
+
+	return results, nil
+}
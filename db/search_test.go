@@ -0,0 +1,67 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/utils"
+)
+
+var (
+	searchKeyReplace   = regexp.MustCompile(`'(\p{Cyrillic})', '([a-z]+)'\)`)
+	searchKeyTranslate = regexp.MustCompile(`'(\p{Cyrillic}{2,})',\s*'([a-z]+)'`)
+)
+
+// sqlSearchKey повторяет SQL-функцию search_key из миграции: lower, цепочка replace и translate
+func sqlSearchKey(t *testing.T, text string) string {
+	t.Helper()
+
+	migration, err := embedMigrations.ReadFile("migrations/009_search.sql")
+	if err != nil {
+		t.Fatalf("чтение миграции: %v", err)
+	}
+
+	replaces := searchKeyReplace.FindAllStringSubmatch(string(migration), -1)
+	if len(replaces) == 0 {
+		t.Fatal("в search_key не найдены replace")
+	}
+
+	key := strings.ToLower(text)
+	for _, m := range replaces {
+		key = strings.ReplaceAll(key, m[1], m[2])
+	}
+
+	translate := searchKeyTranslate.FindStringSubmatch(string(migration))
+	if translate == nil {
+		t.Fatal("в search_key не найден translate")
+	}
+
+	// translate удаляет символы, для которых нет пары во второй строке
+	from, to := []rune(translate[1]), []rune(translate[2])
+	return strings.Map(func(r rune) rune {
+		for i, c := range from {
+			if c == r {
+				if i < len(to) {
+					return to[i]
+				}
+				return -1
+			}
+		}
+		return r
+	}, key)
+}
+
+func TestSearchKeyMatchesMigration(t *testing.T) {
+	texts := []string{"Берсерк", "Ванпанчмен", "Щит и Жезл", "Семья шпиона: код 2", "berserk"}
+	for r := 'а'; r <= 'я'; r++ {
+		texts = append(texts, string(r), strings.ToUpper(string(r)))
+	}
+	texts = append(texts, "ё", "Ё")
+
+	for _, text := range texts {
+		if got, want := sqlSearchKey(t, text), utils.SearchKey(text); got != want {
+			t.Errorf("search_key(%q) = %q, utils.SearchKey = %q", text, got, want)
+		}
+	}
+}
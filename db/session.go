@@ -0,0 +1,47 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// LoadSourceSession (fetcher.SessionStore) возвращает зашифрованные cookie источника (nil — сессии нет)
+func (d *Store) LoadSourceSession(parserName types.SourceName) ([]byte, error) {
+	database := d.db
+
+	var cookies []byte
+	err := database.QueryRow(`
+		SELECT ss.cookies
+		FROM source_sessions ss
+		JOIN sources s ON s.id = ss.source_id
+		WHERE s.parser_name = $1
+	`, parserName).Scan(&cookies)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса сессии источника: %w", err)
+	}
+
+	return cookies, nil
+}
+
+// SaveSourceSession сохраняет зашифрованные cookie источника
+func (d *Store) SaveSourceSession(parserName types.SourceName, cookies []byte) error {
+	database := d.db
+
+	_, err := database.Exec(`
+		INSERT INTO source_sessions (source_id, cookies, updated_at)
+		SELECT id, $2, CURRENT_TIMESTAMP FROM sources WHERE parser_name = $1
+		ON CONFLICT (source_id) DO UPDATE SET cookies = EXCLUDED.cookies, updated_at = EXCLUDED.updated_at
+	`, parserName, cookies)
+
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения сессии источника: %w", err)
+	}
+
+	return nil
+}
@@ -3,16 +3,17 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
 )
 
 // GetActiveSources возвращает все активные источники
-func GetActiveSources() ([]types.Source, error) {
-	database := GetDB()
+func (d *Store) GetActiveSources() ([]types.Source, error) {
+	database := d.db
 
 	rows, err := database.Query(`
-		SELECT id, parser_name, base_url, is_active, created_at, updated_at
+		SELECT id, parser_name, base_url, is_active, respect_robots, created_at, updated_at
 		FROM sources
 		WHERE is_active = true
 	`)
@@ -24,7 +25,7 @@ func GetActiveSources() ([]types.Source, error) {
 	var sources []types.Source
 	for rows.Next() {
 		var s types.Source
-		err := rows.Scan(&s.ID, &s.ParserName, &s.BaseURL, &s.IsActive, &s.CreatedAt, &s.UpdatedAt)
+		err := rows.Scan(&s.ID, &s.ParserName, &s.BaseURL, &s.IsActive, &s.RespectRobots, &s.CreatedAt, &s.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка сканирования источника: %w", err)
 		}
@@ -34,16 +35,37 @@ func GetActiveSources() ([]types.Source, error) {
 	return sources, nil
 }
 
+// GetSourceByID возвращает источник по ID
+func (d *Store) GetSourceByID(id int) (*types.Source, error) {
+	database := d.db
+
+	var s types.Source
+	err := database.QueryRow(`
+		SELECT id, parser_name, base_url, is_active, respect_robots, created_at, updated_at
+		FROM sources
+		WHERE id = $1
+	`, id).Scan(&s.ID, &s.ParserName, &s.BaseURL, &s.IsActive, &s.RespectRobots, &s.CreatedAt, &s.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса источника: %w", err)
+	}
+
+	return &s, nil
+}
+
 // GetSourceByName возвращает источник по имени парсера
-func GetSourceByName(parserName types.SourceName) (*types.Source, error) {
-	database := GetDB()
+func (d *Store) GetSourceByName(parserName types.SourceName) (*types.Source, error) {
+	database := d.db
 
 	var s types.Source
 	err := database.QueryRow(`
-		SELECT id, parser_name, base_url, is_active, created_at, updated_at
+		SELECT id, parser_name, base_url, is_active, respect_robots, created_at, updated_at
 		FROM sources
 		WHERE parser_name = $1
-	`, parserName).Scan(&s.ID, &s.ParserName, &s.BaseURL, &s.IsActive, &s.CreatedAt, &s.UpdatedAt)
+	`, parserName).Scan(&s.ID, &s.ParserName, &s.BaseURL, &s.IsActive, &s.RespectRobots, &s.CreatedAt, &s.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -57,17 +79,17 @@ func GetSourceByName(parserName types.SourceName) (*types.Source, error) {
 
 // GetSourceByBaseURL возвращает источник по базовому URL
 // Поиск выполняется по точному совпадению или по вхождению хоста
-func GetSourceByBaseURL(baseURL string) (*types.Source, error) {
-	database := GetDB()
+func (d *Store) GetSourceByBaseURL(baseURL string) (*types.Source, error) {
+	database := d.db
 
 	var s types.Source
 
 	// Сначала пробуем точное совпадение
 	err := database.QueryRow(`
-		SELECT id, parser_name, base_url, is_active, created_at, updated_at
+		SELECT id, parser_name, base_url, is_active, respect_robots, created_at, updated_at
 		FROM sources
 		WHERE base_url = $1 AND is_active = true
-	`, baseURL).Scan(&s.ID, &s.ParserName, &s.BaseURL, &s.IsActive, &s.CreatedAt, &s.UpdatedAt)
+	`, baseURL).Scan(&s.ID, &s.ParserName, &s.BaseURL, &s.IsActive, &s.RespectRobots, &s.CreatedAt, &s.UpdatedAt)
 
 	if err == nil {
 		return &s, nil
@@ -79,11 +101,11 @@ func GetSourceByBaseURL(baseURL string) (*types.Source, error) {
 
 	// Если точного совпадения нет, ищем по LIKE (для случаев с/без https)
 	err = database.QueryRow(`
-		SELECT id, parser_name, base_url, is_active, created_at, updated_at
+		SELECT id, parser_name, base_url, is_active, respect_robots, created_at, updated_at
 		FROM sources
 		WHERE base_url LIKE '%' || $1 || '%' AND is_active = true
 		LIMIT 1
-	`, baseURL).Scan(&s.ID, &s.ParserName, &s.BaseURL, &s.IsActive, &s.CreatedAt, &s.UpdatedAt)
+	`, baseURL).Scan(&s.ID, &s.ParserName, &s.BaseURL, &s.IsActive, &s.RespectRobots, &s.CreatedAt, &s.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -94,3 +116,20 @@ func GetSourceByBaseURL(baseURL string) (*types.Source, error) {
 
 	return &s, nil
 }
+
+// SetSourceActive включает или выключает проверку источника
+func (d *Store) SetSourceActive(id int, active bool) error {
+	database := d.db
+
+	_, err := database.Exec(`
+		UPDATE sources
+		SET is_active = $1, updated_at = $2
+		WHERE id = $3
+	`, active, time.Now(), id)
+
+	if err != nil {
+		return fmt.Errorf("ошибка обновления источника: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+)
+
+// Store хранилище на SQL (реализация store.Store).
+// Запросы пишутся для PostgreSQL и переводятся под диалект подключения (см. conn).
+type Store struct {
+	db  *conn
+	now func() time.Time // Время обнаружения глав и приглушения подписок
+}
+
+var _ store.Store = (*Store)(nil)
+
+// Dialect возвращает диалект подключения
+func (d *Store) Dialect() Dialect {
+	return d.db.dialect
+}
+
+// SetClock подменяет часы хранилища, чтобы проверки задавали порядок записей без ожидания
+func (d *Store) SetClock(now func() time.Time) {
+	d.now = now
+}
+
+// Close закрывает подключение к БД
+func (d *Store) Close() error {
+	return d.db.Close()
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store/storetest"
+)
+
+func TestSQLiteContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store { return openSQLite(t) })
+}
+
+// openSQLite создаёт пустую базу SQLite во временном каталоге и применяет миграции
+func openSQLite(t *testing.T) *Store {
+	t.Helper()
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "manga.db"))
+
+	s, err := Open()
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if err := s.Migrate(); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	return s
+}
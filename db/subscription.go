@@ -8,34 +8,44 @@ import (
 )
 
 // CreateSubscription создаёт подписку пользователя на мангу
-func CreateSubscription(userID int64, mangaID int) (*types.UserSubscription, error) {
-	database := GetDB()
+func (d *Store) CreateSubscription(userID int64, mangaID int) (*types.UserSubscription, error) {
+	database := d.db
 
-	var sub types.UserSubscription
-	err := database.QueryRow(`
+	sub, err := scanSubscription(database.QueryRow(`
 		INSERT INTO user_subscriptions (user_id, manga_id, notify)
 		VALUES ($1, $2, true)
-		ON CONFLICT (user_id, manga_id) DO UPDATE SET notify = true
-		RETURNING id, user_id, manga_id, notify, created_at
-	`, userID, mangaID).Scan(&sub.ID, &sub.TelegramUserID, &sub.MangaID, &sub.Notify, &sub.CreatedAt)
+		ON CONFLICT (user_id, manga_id) DO UPDATE SET notify = true, muted_at = NULL, muted_until = NULL
+		RETURNING id, user_id, manga_id, notify, team_mode, preferred_team, created_at, muted_at, muted_until
+	`, userID, mangaID))
 
 	if err != nil {
 		return nil, fmt.Errorf("ошибка создания подписки: %w", err)
 	}
 
-	return &sub, nil
+	// У манги снова есть подписчик: снимаем отметку обслуживания и возобновляем проверку
+	_, err = database.Exec(`
+		UPDATE manga SET orphaned_since = NULL, frozen_at = NULL
+		WHERE id = $1 AND (orphaned_since IS NOT NULL OR frozen_at IS NOT NULL)
+	`, mangaID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка возобновления проверки манги: %w", err)
+	}
+
+	return sub, nil
 }
 
 // GetSubscription возвращает подписку пользователя на мангу
-func GetSubscription(userID int64, mangaID int) (*types.UserSubscription, error) {
-	database := GetDB()
+func (d *Store) GetSubscription(userID int64, mangaID int) (*types.UserSubscription, error) {
+	return getSubscription(d.db, userID, mangaID)
+}
 
-	var sub types.UserSubscription
-	err := database.QueryRow(`
-		SELECT id, user_id, manga_id, notify, created_at
+// getSubscription читает подписку в рамках подключения или транзакции
+func getSubscription(database queryer, userID int64, mangaID int) (*types.UserSubscription, error) {
+	sub, err := scanSubscription(database.QueryRow(`
+		SELECT id, user_id, manga_id, notify, team_mode, preferred_team, created_at, muted_at, muted_until
 		FROM user_subscriptions
 		WHERE user_id = $1 AND manga_id = $2
-	`, userID, mangaID).Scan(&sub.ID, &sub.TelegramUserID, &sub.MangaID, &sub.Notify, &sub.CreatedAt)
+	`, userID, mangaID))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -44,12 +54,34 @@ func GetSubscription(userID int64, mangaID int) (*types.UserSubscription, error)
 		return nil, fmt.Errorf("ошибка запроса подписки: %w", err)
 	}
 
+	return sub, nil
+}
+
+// scanSubscription читает подписку из строки
+// id, user_id, manga_id, notify, team_mode, preferred_team, created_at, muted_at, muted_until
+func scanSubscription(row *sql.Row) (*types.UserSubscription, error) {
+	var sub types.UserSubscription
+	var preferredTeam sql.NullString
+	var mutedAt, mutedUntil sql.NullTime
+
+	err := row.Scan(&sub.ID, &sub.TelegramUserID, &sub.MangaID, &sub.Notify, &sub.TeamMode, &preferredTeam, &sub.CreatedAt, &mutedAt, &mutedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.PreferredTeam = preferredTeam.String
+	if mutedAt.Valid {
+		sub.MutedAt = &mutedAt.Time
+	}
+	if mutedUntil.Valid {
+		sub.MutedUntil = &mutedUntil.Time
+	}
 	return &sub, nil
 }
 
 // DeleteSubscription удаляет подписку пользователя на мангу
-func DeleteSubscription(userID int64, mangaID int) error {
-	database := GetDB()
+func (d *Store) DeleteSubscription(userID int64, mangaID int) error {
+	database := d.db
 
 	_, err := database.Exec(`
 		DELETE FROM user_subscriptions
@@ -63,24 +95,35 @@ func DeleteSubscription(userID int64, mangaID int) error {
 	return nil
 }
 
-// MangaWithSource манга с информацией об источнике
-type MangaWithSource struct {
-	types.Manga
-	SourceName    string `db:"source_name"`
-	SourceBaseURL string `db:"source_base_url"`
+// UpdateSubscriptionTeam устанавливает фильтр по командам перевода для подписки
+func (d *Store) UpdateSubscriptionTeam(userID int64, mangaID int, mode types.TeamMode, team string) error {
+	database := d.db
+
+	_, err := database.Exec(`
+		UPDATE user_subscriptions
+		SET team_mode = $1, preferred_team = $2
+		WHERE user_id = $3 AND manga_id = $4
+	`, mode, nullString(team), userID, mangaID)
+
+	if err != nil {
+		return fmt.Errorf("ошибка обновления фильтра команд: %w", err)
+	}
+
+	return nil
 }
 
-// GetUserSubscriptions возвращает все подписки пользователя с информацией об источнике
-func GetUserSubscriptions(userID int64) ([]MangaWithSource, error) {
-	database := GetDB()
+// GetUserSubscriptions возвращает все подписки пользователя (включая приглушённые) с информацией об источнике
+func (d *Store) GetUserSubscriptions(userID int64) ([]types.SubscribedManga, error) {
+	database := d.db
 
 	rows, err := database.Query(`
-		SELECT m.id, m.source_id, m.url, m.title, m.last_chapter_url, m.last_chapter_title, m.last_check_at, m.created_at, m.updated_at,
-		       s.parser_name, s.base_url
+		SELECT m.id, m.source_id, m.url, m.title, m.last_chapter_url, m.last_chapter_title, m.last_check_at, m.work_id, m.created_at, m.updated_at,
+		       s.parser_name, s.base_url,
+		       us.id, us.notify, us.team_mode, us.preferred_team, us.created_at, us.muted_at, us.muted_until
 		FROM manga m
 		JOIN user_subscriptions us ON m.id = us.manga_id
 		JOIN sources s ON m.source_id = s.id
-		WHERE us.user_id = $1 AND us.notify = true
+		WHERE us.user_id = $1
 		ORDER BY s.parser_name, m.title
 	`, userID)
 	if err != nil {
@@ -88,14 +131,16 @@ func GetUserSubscriptions(userID int64) ([]MangaWithSource, error) {
 	}
 	defer rows.Close()
 
-	var mangaList []MangaWithSource
+	var mangaList []types.SubscribedManga
 	for rows.Next() {
-		var m MangaWithSource
-		var lastChapterURL, lastChapterTitle sql.NullString
-		var lastCheckAt sql.NullTime
-
-		err := rows.Scan(&m.ID, &m.SourceID, &m.URL, &m.Title, &lastChapterURL, &lastChapterTitle, &lastCheckAt, &m.CreatedAt, &m.UpdatedAt,
-			&m.SourceName, &m.SourceBaseURL)
+		var m types.SubscribedManga
+		var lastChapterURL, lastChapterTitle, preferredTeam sql.NullString
+		var lastCheckAt, mutedAt, mutedUntil sql.NullTime
+		var workID sql.NullInt64
+
+		err := rows.Scan(&m.ID, &m.SourceID, &m.URL, &m.Title, &lastChapterURL, &lastChapterTitle, &lastCheckAt, &workID, &m.CreatedAt, &m.UpdatedAt,
+			&m.SourceName, &m.SourceBaseURL,
+			&m.Subscription.ID, &m.Subscription.Notify, &m.Subscription.TeamMode, &preferredTeam, &m.Subscription.CreatedAt, &mutedAt, &mutedUntil)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка сканирования манги: %w", err)
 		}
@@ -109,6 +154,19 @@ func GetUserSubscriptions(userID int64) ([]MangaWithSource, error) {
 		if lastCheckAt.Valid {
 			m.LastCheckAt = &lastCheckAt.Time
 		}
+		if workID.Valid {
+			m.WorkID = int(workID.Int64)
+		}
+
+		m.Subscription.TelegramUserID = userID
+		m.Subscription.MangaID = m.ID
+		m.Subscription.PreferredTeam = preferredTeam.String
+		if mutedAt.Valid {
+			m.Subscription.MutedAt = &mutedAt.Time
+		}
+		if mutedUntil.Valid {
+			m.Subscription.MutedUntil = &mutedUntil.Time
+		}
 
 		mangaList = append(mangaList, m)
 	}
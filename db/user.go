@@ -9,8 +9,8 @@ import (
 )
 
 // GetOrCreateUser получает или создаёт пользователя Telegram
-func GetOrCreateUser(id int64, username, firstName, lastName string) (*types.TelegramUser, error) {
-	database := GetDB()
+func (d *Store) GetOrCreateUser(id int64, username, firstName, lastName string) (*types.TelegramUser, error) {
+	database := d.db
 
 	var u types.TelegramUser
 	var usernameNull, firstNameNull, lastNameNull sql.NullString
@@ -34,10 +34,10 @@ func GetOrCreateUser(id int64, username, firstName, lastName string) (*types.Tel
 			u.LastName = lastNameNull.String
 		}
 
-		// Обновляем данные пользователя
+		// Обновляем данные пользователя. Раз он пишет боту — он снова активен (мог разблокировать бота)
 		_, err = database.Exec(`
 			UPDATE telegram_users
-			SET username = $1, first_name = $2, last_name = $3, updated_at = $4
+			SET username = $1, first_name = $2, last_name = $3, is_active = true, inactive_since = NULL, updated_at = $4
 			WHERE id = $5
 		`, nullString(username), nullString(firstName), nullString(lastName), time.Now(), id)
 
@@ -45,6 +45,7 @@ func GetOrCreateUser(id int64, username, firstName, lastName string) (*types.Tel
 			return nil, fmt.Errorf("ошибка обновления пользователя: %w", err)
 		}
 
+		u.IsActive = true
 		u.Username = username
 		u.FirstName = firstName
 		u.LastName = lastName
@@ -83,8 +84,8 @@ func GetOrCreateUser(id int64, username, firstName, lastName string) (*types.Tel
 }
 
 // GetUserByID возвращает пользователя по ID
-func GetUserByID(id int64) (*types.TelegramUser, error) {
-	database := GetDB()
+func (d *Store) GetUserByID(id int64) (*types.TelegramUser, error) {
+	database := d.db
 
 	var u types.TelegramUser
 	var username, firstName, lastName sql.NullString
@@ -115,6 +116,33 @@ func GetUserByID(id int64) (*types.TelegramUser, error) {
 	return &u, nil
 }
 
+// SetUserActive отмечает пользователя активным или неактивным.
+// Момент перехода в неактивные сохраняется для политики хранения (см. RunMaintenance).
+func (d *Store) SetUserActive(id int64, active bool) error {
+	database := d.db
+
+	var err error
+	if active {
+		_, err = database.Exec(`
+			UPDATE telegram_users
+			SET is_active = true, inactive_since = NULL, updated_at = $1
+			WHERE id = $2
+		`, time.Now(), id)
+	} else {
+		_, err = database.Exec(`
+			UPDATE telegram_users
+			SET is_active = false, inactive_since = COALESCE(inactive_since, $1), updated_at = $2
+			WHERE id = $3
+		`, time.Now().UTC(), time.Now(), id)
+	}
+
+	if err != nil {
+		return fmt.Errorf("ошибка обновления активности пользователя: %w", err)
+	}
+
+	return nil
+}
+
 // nullString преобразует строку в sql.NullString
 func nullString(s string) sql.NullString {
 	if s == "" {
@@ -0,0 +1,268 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/utils"
+)
+
+// GetWorkByID возвращает произведение по ID
+func (d *Store) GetWorkByID(id int) (*types.Work, error) {
+	database := d.db
+
+	var w types.Work
+	err := database.QueryRow(`
+		SELECT id, title, created_at, updated_at
+		FROM works
+		WHERE id = $1
+	`, id).Scan(&w.ID, &w.Title, &w.CreatedAt, &w.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса произведения: %w", err)
+	}
+
+	return &w, nil
+}
+
+// GetWorkByTitle ищет произведение по основному или альтернативному названию
+func (d *Store) GetWorkByTitle(title string) (*types.Work, error) {
+	normalized := utils.NormalizeTitle(title)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	database := d.db
+
+	var w types.Work
+	err := database.QueryRow(`
+		SELECT w.id, w.title, w.created_at, w.updated_at
+		FROM works w
+		JOIN work_titles wt ON wt.work_id = w.id
+		WHERE wt.normalized_title = $1
+	`, normalized).Scan(&w.ID, &w.Title, &w.CreatedAt, &w.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска произведения: %w", err)
+	}
+
+	return &w, nil
+}
+
+// CreateWork создаёт произведение и регистрирует его название
+func (d *Store) CreateWork(title string) (*types.Work, error) {
+	database := d.db
+
+	var w types.Work
+	err := database.QueryRow(`
+		INSERT INTO works (title)
+		VALUES ($1)
+		RETURNING id, title, created_at, updated_at
+	`, title).Scan(&w.ID, &w.Title, &w.CreatedAt, &w.UpdatedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания произведения: %w", err)
+	}
+
+	if err := d.AddWorkTitle(w.ID, title); err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// AddWorkTitle добавляет альтернативное название произведению.
+// Если нормализованное название уже занято, ничего не делает.
+func (d *Store) AddWorkTitle(workID int, title string) error {
+	normalized := utils.NormalizeTitle(title)
+	if normalized == "" {
+		return nil
+	}
+
+	database := d.db
+
+	_, err := database.Exec(`
+		INSERT INTO work_titles (work_id, title, normalized_title)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (normalized_title) DO NOTHING
+	`, workID, title, normalized)
+
+	if err != nil {
+		return fmt.Errorf("ошибка добавления названия произведения: %w", err)
+	}
+
+	return nil
+}
+
+// SetMangaWork привязывает мангу к произведению
+func (d *Store) SetMangaWork(mangaID, workID int) error {
+	database := d.db
+
+	_, err := database.Exec(`
+		UPDATE manga
+		SET work_id = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, workID, mangaID)
+
+	if err != nil {
+		return fmt.Errorf("ошибка привязки манги к произведению: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimChapterAnnouncements отмечает главы анонсированными в рамках произведения
+// и возвращает только те, чей номер ещё не объявлялся этой командой переводчиков.
+// У возвращённых глав проставлен IsFirstRelease — номер не объявлялся ни одной командой.
+// Главы без распознанного номера всегда считаются новыми.
+func (d *Store) ClaimChapterAnnouncements(workID int, chapters []types.Chapter) ([]types.Chapter, error) {
+	return claimChapterAnnouncements(d.db, workID, chapters)
+}
+
+// claimChapterAnnouncements отмечает анонс глав в рамках подключения или транзакции
+func claimChapterAnnouncements(database queryer, workID int, chapters []types.Chapter) ([]types.Chapter, error) {
+	var claimed []types.Chapter
+
+	for _, ch := range chapters {
+		if ch.Number == "" {
+			ch.IsFirstRelease = true
+			claimed = append(claimed, ch)
+			continue
+		}
+
+		var announced bool
+		err := database.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM work_chapter_announcements WHERE work_id = $1 AND number = $2)
+		`, workID, ch.Number).Scan(&announced)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка проверки анонса главы: %w", err)
+		}
+
+		result, err := database.Exec(`
+			INSERT INTO work_chapter_announcements (work_id, number, translator, chapter_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (work_id, number, translator) DO NOTHING
+		`, workID, ch.Number, ch.Translator, ch.ID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка отметки анонса главы: %w", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка отметки анонса главы: %w", err)
+		}
+
+		if affected > 0 {
+			ch.IsFirstRelease = !announced
+			claimed = append(claimed, ch)
+		}
+	}
+
+	return claimed, nil
+}
+
+// GetWorkSubscribers возвращает подписчиков всех манг произведения (каждого один раз).
+// Если пользователь подписан на несколько манг произведения, используется самая ранняя подписка.
+func (d *Store) GetWorkSubscribers(workID int) ([]types.WorkSubscriber, error) {
+	return workSubscribers(d.db, workID)
+}
+
+// workSubscribers читает подписчиков произведения в рамках подключения или транзакции
+func workSubscribers(database queryer, workID int) ([]types.WorkSubscriber, error) {
+	rows, err := database.Query(`
+		SELECT tu.id, tu.username, tu.first_name, tu.last_name, tu.is_active, tu.created_at, tu.updated_at,
+		       us.id, us.manga_id, us.notify, us.team_mode, us.preferred_team, us.created_at
+		FROM telegram_users tu
+		JOIN user_subscriptions us ON tu.id = us.user_id
+		JOIN manga m ON m.id = us.manga_id
+		WHERE m.work_id = $1 AND us.notify = true AND tu.is_active = true
+		ORDER BY us.id
+	`, workID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса подписчиков произведения: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[int64]bool)
+
+	var subscribers []types.WorkSubscriber
+	for rows.Next() {
+		var s types.WorkSubscriber
+		var username, firstName, lastName, preferredTeam sql.NullString
+
+		err := rows.Scan(&s.ID, &username, &firstName, &lastName, &s.IsActive, &s.CreatedAt, &s.UpdatedAt,
+			&s.Subscription.ID, &s.Subscription.MangaID, &s.Subscription.Notify, &s.Subscription.TeamMode, &preferredTeam, &s.Subscription.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования подписчика: %w", err)
+		}
+
+		if seen[s.ID] {
+			continue
+		}
+		seen[s.ID] = true
+
+		if username.Valid {
+			s.Username = username.String
+		}
+		if firstName.Valid {
+			s.FirstName = firstName.String
+		}
+		if lastName.Valid {
+			s.LastName = lastName.String
+		}
+
+		s.Subscription.TelegramUserID = s.ID
+		s.Subscription.PreferredTeam = preferredTeam.String
+
+		subscribers = append(subscribers, s)
+	}
+
+	return subscribers, nil
+}
+
+// MergeWorks переносит всю мангу, названия и анонсы произведения sourceID в targetID
+// и удаляет sourceID
+func (d *Store) MergeWorks(targetID, sourceID int) error {
+	if targetID == sourceID {
+		return nil
+	}
+
+	database := d.db
+
+	tx, err := database.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`UPDATE manga SET work_id = $1, updated_at = CURRENT_TIMESTAMP WHERE work_id = $2`,
+		`UPDATE work_titles SET work_id = $1 WHERE work_id = $2`,
+		`INSERT INTO work_chapter_announcements (work_id, number, translator, chapter_id, announced_at)
+		 SELECT $1, number, translator, chapter_id, announced_at FROM work_chapter_announcements WHERE work_id = $2
+		 ON CONFLICT (work_id, number, translator) DO NOTHING`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, targetID, sourceID); err != nil {
+			return fmt.Errorf("ошибка объединения произведений: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM works WHERE id = $1`, sourceID); err != nil {
+		return fmt.Errorf("ошибка удаления произведения: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package download
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// Page скачанная страница главы
+type Page struct {
+	URL  string // Исходный URL изображения
+	Data []byte // Содержимое изображения
+}
+
+// ComicInfo метаданные архива в формате ComicRack (ComicInfo.xml)
+type ComicInfo struct {
+	XMLName     xml.Name `xml:"ComicInfo"`
+	XMLNSXsi    string   `xml:"xmlns:xsi,attr"`
+	XMLNSXsd    string   `xml:"xmlns:xsd,attr"`
+	Title       string   `xml:"Title,omitempty"`
+	Series      string   `xml:"Series,omitempty"`
+	Number      string   `xml:"Number,omitempty"`
+	Translator  string   `xml:"Translator,omitempty"`
+	Web         string   `xml:"Web,omitempty"`
+	PageCount   int      `xml:"PageCount"`
+	LanguageISO string   `xml:"LanguageISO,omitempty"`
+	Manga       string   `xml:"Manga,omitempty"`
+	Notes       string   `xml:"Notes,omitempty"`
+}
+
+// NewComicInfo собирает ComicInfo из данных манги и главы
+func NewComicInfo(manga types.Manga, chapter types.Chapter, pageCount int) ComicInfo {
+	return ComicInfo{
+		XMLNSXsi:    "http://www.w3.org/2001/XMLSchema-instance",
+		XMLNSXsd:    "http://www.w3.org/2001/XMLSchema",
+		Title:       chapter.Title,
+		Series:      manga.Title,
+		Number:      chapter.Number,
+		Translator:  chapter.Translator,
+		Web:         chapter.URL,
+		PageCount:   pageCount,
+		LanguageISO: "ru",
+		Manga:       "YesAndRightToLeft",
+		Notes:       fmt.Sprintf("Обнаружена %s", chapter.DiscoveredAt.Format("2006-01-02 15:04")),
+	}
+}
+
+// WriteCBZ записывает CBZ архив: страницы по порядку (001.jpg, 002.png, ...) и ComicInfo.xml
+func WriteCBZ(w io.Writer, info ComicInfo, pages []Page) error {
+	archive := zip.NewWriter(w)
+
+	for i, page := range pages {
+		// Изображения уже сжаты — сохраняем без повторного сжатия
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:   fmt.Sprintf("%03d%s", i+1, pageExtension(page.URL)),
+			Method: zip.Store,
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка добавления страницы %d: %w", i+1, err)
+		}
+
+		if _, err := file.Write(page.Data); err != nil {
+			return fmt.Errorf("ошибка записи страницы %d: %w", i+1, err)
+		}
+	}
+
+	file, err := archive.Create("ComicInfo.xml")
+	if err != nil {
+		return fmt.Errorf("ошибка добавления ComicInfo.xml: %w", err)
+	}
+
+	if _, err := io.WriteString(file, xml.Header); err != nil {
+		return fmt.Errorf("ошибка записи ComicInfo.xml: %w", err)
+	}
+
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(info); err != nil {
+		return fmt.Errorf("ошибка записи ComicInfo.xml: %w", err)
+	}
+
+	return archive.Close()
+}
+
+// pageExtension возвращает расширение изображения по URL (по умолчанию .jpg)
+func pageExtension(pageURL string) string {
+	pagePath, _, _ := strings.Cut(pageURL, "?")
+
+	switch ext := strings.ToLower(path.Ext(pagePath)); ext {
+	case ".jpg", ".jpeg", ".png", ".webp", ".gif":
+		return ext
+	}
+	return ".jpg"
+}
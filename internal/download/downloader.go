@@ -0,0 +1,142 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/fetcher"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// MaxArchiveSize максимальный размер архива (ограничение Telegram на sendDocument — 50 МБ)
+const MaxArchiveSize = 48 << 20
+
+// ArchiveTooLargeError страницы главы не помещаются в архив допустимого размера
+type ArchiveTooLargeError struct {
+	Limit int
+}
+
+func (e *ArchiveTooLargeError) Error() string {
+	if e.Limit >= 1<<20 {
+		return fmt.Sprintf("глава больше %d МБ", e.Limit>>20)
+	}
+	return fmt.Sprintf("глава больше %d байт", e.Limit)
+}
+
+// Downloader скачивает главы и собирает их в CBZ.
+// Fetcher можно подменить фетчером с транспортом httptest-сервера с фикстурами читалки,
+// чтобы проверять экстракторы и сборку архива без обращения к живым сайтам.
+type Downloader struct {
+	Fetcher   *fetcher.Fetcher
+	PageDelay time.Duration // Пауза между запросами страниц
+	MaxSize   int           // Ограничение суммарного размера страниц (0 — MaxArchiveSize)
+}
+
+// NewDownloader создаёт загрузчик на общем фетчере
+func NewDownloader() *Downloader {
+	return &Downloader{
+		Fetcher:   fetcher.Default(),
+		PageDelay: 300 * time.Millisecond,
+	}
+}
+
+// DownloadChapter скачивает все страницы главы и возвращает CBZ архив
+func (d *Downloader) DownloadChapter(ctx context.Context, source types.Source, manga types.Manga, chapter types.Chapter) ([]byte, error) {
+	extractor, err := GetPageExtractor(source.ParserName)
+	if err != nil {
+		return nil, err
+	}
+
+	// mtr=1 пропускает предупреждение о взрослом контенте на readmanga-подобных сайтах
+	readerURL := chapter.URL
+	if strings.Contains(readerURL, "?") {
+		readerURL += "&mtr=1"
+	} else {
+		readerURL += "?mtr=1"
+	}
+
+	reader, err := d.Fetcher.Get(ctx, fetcher.Request{
+		Source:        source.ParserName,
+		URL:           readerURL,
+		Kind:          fetcher.KindPage,
+		Referer:       source.BaseURL,
+		RespectRobots: source.RespectRobots,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки читалки: %w", err)
+	}
+
+	pageURLs, err := extractor(string(reader.Body), chapter.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Скачиваем главу %s: %d страниц", chapter.URL, len(pageURLs))
+
+	maxSize := d.MaxSize
+	if maxSize <= 0 {
+		maxSize = MaxArchiveSize
+	}
+
+	pages := make([]Page, 0, len(pageURLs))
+	total := 0
+
+	for i, pageURL := range pageURLs {
+		if i > 0 && d.PageDelay > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(d.PageDelay):
+			}
+		}
+
+		page, err := d.Fetcher.Get(ctx, fetcher.Request{
+			Source:        source.ParserName,
+			URL:           pageURL,
+			Kind:          fetcher.KindImage,
+			Referer:       source.BaseURL,
+			MaxBodySize:   int64(maxSize),
+			RespectRobots: source.RespectRobots,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ошибка загрузки страницы %d: %w", i+1, err)
+		}
+
+		total += len(page.Body)
+		if total > maxSize {
+			return nil, &ArchiveTooLargeError{Limit: maxSize}
+		}
+
+		pages = append(pages, Page{URL: pageURL, Data: page.Body})
+	}
+
+	var archive bytes.Buffer
+	if err := WriteCBZ(&archive, NewComicInfo(manga, chapter, len(pages)), pages); err != nil {
+		return nil, err
+	}
+
+	return archive.Bytes(), nil
+}
+
+// ArchiveName имя файла архива: "Название - 15.cbz", без номера — "Название - Экстра.cbz"
+func ArchiveName(manga types.Manga, chapter types.Chapter) string {
+	name := manga.Title
+	if chapter.Number != "" {
+		name = fmt.Sprintf("%s - %s", name, chapter.Number)
+	} else if chapter.Title != "" {
+		name = fmt.Sprintf("%s - %s", name, chapter.Title)
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	return name + ".cbz"
+}
@@ -0,0 +1,257 @@
+package download
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/fetcher"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// readerFixture страница читалки readmanga: первая страница с хостом картинок в первом элементе,
+// вторая — абсолютным URL в пути, третья — путём относительно сайта
+const readerFixture = `<html><body>
+<script type="text/javascript">
+	rm_h.readerDoInit([
+		['%[1]s/','',"img/01.jpg?t=1",1100,1600],
+		['','',"%[1]s/img/02.png",1100,1600],
+		['','',"/img/03.webp",1100,1600]
+	], false, 0);
+</script>
+</body></html>`
+
+// fixtureImages содержимое картинок фикстуры по пути
+var fixtureImages = map[string][]byte{
+	"/img/01.jpg":  bytes.Repeat([]byte{1}, 100),
+	"/img/02.png":  bytes.Repeat([]byte{2}, 100),
+	"/img/03.webp": bytes.Repeat([]byte{3}, 100),
+}
+
+// newFixtureSite поднимает сайт с читалкой главы /manga/vol1/1 и её картинками
+func newFixtureSite(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/manga/vol1/1" {
+			// Без mtr=1 сайт показывает предупреждение о взрослом контенте
+			if r.URL.Query().Get("mtr") != "1" {
+				fmt.Fprint(w, "<html>Внимание! 18+</html>")
+				return
+			}
+			fmt.Fprintf(w, readerFixture, server.URL)
+			return
+		}
+
+		data, ok := fixtureImages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func newTestDownloader(t *testing.T) *Downloader {
+	t.Helper()
+	t.Setenv("FETCH_RATE_DELAY", "0")
+	t.Setenv("FETCH_RETRY_ATTEMPTS", "1")
+
+	return &Downloader{Fetcher: fetcher.New(fetcher.Options{Timeout: 5 * time.Second})}
+}
+
+func fixtureChapter(server *httptest.Server) (types.Source, types.Manga, types.Chapter) {
+	source := types.Source{ParserName: types.SourceReadmanga, BaseURL: server.URL}
+	manga := types.Manga{Title: "Берсерк", URL: "manga"}
+	chapter := types.Chapter{
+		URL:          server.URL + "/manga/vol1/1",
+		Title:        "Том 1. Глава 1",
+		Number:       "1",
+		Translator:   "Команда",
+		DiscoveredAt: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+	}
+	return source, manga, chapter
+}
+
+func TestExtractReadmangaPages(t *testing.T) {
+	html := fmt.Sprintf(readerFixture, "https://img.example")
+
+	pages, err := ExtractReadmangaPages(html, "https://site.example/manga/vol1/1")
+	if err != nil {
+		t.Fatalf("ExtractReadmangaPages: %v", err)
+	}
+
+	want := []string{
+		"https://img.example/img/01.jpg?t=1",
+		"https://img.example/img/02.png",
+		"https://site.example/img/03.webp",
+	}
+	if strings.Join(pages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("страницы:\n%s\nожидалось:\n%s", strings.Join(pages, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestExtractReadmangaPagesErrors(t *testing.T) {
+	tests := map[string]string{
+		"нет скрипта":   "<html><body>Глава удалена</body></html>",
+		"пустой список": "<script>rm_h.readerInit([], false);</script>",
+	}
+
+	for name, html := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ExtractReadmangaPages(html, "https://site.example/manga/vol1/1"); err == nil {
+				t.Error("ожидалась ошибка")
+			}
+		})
+	}
+}
+
+func TestDownloadChapter(t *testing.T) {
+	server := newFixtureSite(t)
+	source, manga, chapter := fixtureChapter(server)
+
+	archive, err := newTestDownloader(t).DownloadChapter(context.Background(), source, manga, chapter)
+	if err != nil {
+		t.Fatalf("DownloadChapter: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("архив не читается: %v", err)
+	}
+
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	wantNames := []string{"001.jpg", "002.png", "003.webp", "ComicInfo.xml"}
+	if strings.Join(names, ",") != strings.Join(wantNames, ",") {
+		t.Fatalf("файлы архива %v, ожидалось %v", names, wantNames)
+	}
+
+	for i, path := range []string{"/img/01.jpg", "/img/02.png", "/img/03.webp"} {
+		if data := readZipFile(t, reader.File[i]); !bytes.Equal(data, fixtureImages[path]) {
+			t.Errorf("%s: содержимое не совпадает с %s", reader.File[i].Name, path)
+		}
+		if reader.File[i].Method != zip.Store {
+			t.Errorf("%s: страницы сохраняются без сжатия", reader.File[i].Name)
+		}
+	}
+
+	comicInfo := readZipFile(t, reader.File[3])
+	if !bytes.HasPrefix(comicInfo, []byte(xml.Header)) {
+		t.Error("ComicInfo.xml без XML заголовка")
+	}
+	for _, attr := range []string{
+		`xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"`,
+		`xmlns:xsd="http://www.w3.org/2001/XMLSchema"`,
+	} {
+		if !bytes.Contains(comicInfo, []byte(attr)) {
+			t.Errorf("в ComicInfo.xml нет %s", attr)
+		}
+	}
+
+	// Атрибуты xmlns при разборе не восстанавливаются, сравниваем остальные поля
+	var info ComicInfo
+	if err := xml.Unmarshal(comicInfo, &info); err != nil {
+		t.Fatalf("ComicInfo.xml не разбирается: %v", err)
+	}
+
+	want := ComicInfo{
+		XMLName:     xml.Name{Local: "ComicInfo"},
+		Title:       "Том 1. Глава 1",
+		Series:      "Берсерк",
+		Number:      "1",
+		Translator:  "Команда",
+		Web:         chapter.URL,
+		PageCount:   3,
+		LanguageISO: "ru",
+		Manga:       "YesAndRightToLeft",
+		Notes:       "Обнаружена 2024-05-01 12:30",
+	}
+	if info != want {
+		t.Errorf("ComicInfo:\n%+v\nожидалось:\n%+v", info, want)
+	}
+}
+
+func TestDownloadChapterTooLarge(t *testing.T) {
+	server := newFixtureSite(t)
+	source, manga, chapter := fixtureChapter(server)
+
+	t.Run("сумма страниц", func(t *testing.T) {
+		downloader := newTestDownloader(t)
+		downloader.MaxSize = 250 // Две страницы по 100 байт помещаются, третья — нет
+
+		_, err := downloader.DownloadChapter(context.Background(), source, manga, chapter)
+
+		var tooLarge *ArchiveTooLargeError
+		if !errors.As(err, &tooLarge) {
+			t.Fatalf("ожидалась ArchiveTooLargeError, получено %v", err)
+		}
+		if tooLarge.Limit != 250 {
+			t.Errorf("лимит %d, ожидалось 250", tooLarge.Limit)
+		}
+	})
+
+	t.Run("одна страница", func(t *testing.T) {
+		downloader := newTestDownloader(t)
+		downloader.MaxSize = 50 // Первая же страница не помещается в ответ
+
+		_, err := downloader.DownloadChapter(context.Background(), source, manga, chapter)
+
+		var bodyTooLarge *fetcher.BodyTooLargeError
+		if !errors.As(err, &bodyTooLarge) {
+			t.Fatalf("ожидалась BodyTooLargeError, получено %v", err)
+		}
+	})
+}
+
+func TestArchiveName(t *testing.T) {
+	tests := []struct {
+		manga   types.Manga
+		chapter types.Chapter
+		want    string
+	}{
+		{types.Manga{Title: "Берсерк"}, types.Chapter{Number: "15"}, "Берсерк - 15.cbz"},
+		{types.Manga{Title: "Берсерк"}, types.Chapter{Title: "Экстра"}, "Берсерк - Экстра.cbz"},
+		{types.Manga{Title: "Берсерк"}, types.Chapter{Number: "15", Title: "Экстра"}, "Берсерк - 15.cbz"},
+		{types.Manga{Title: "Берсерк"}, types.Chapter{Title: "Глава 1/2"}, "Берсерк - Глава 1_2.cbz"},
+		{types.Manga{Title: "Fate/Zero: 1?"}, types.Chapter{}, "Fate_Zero_ 1_.cbz"},
+	}
+
+	for _, tt := range tests {
+		if got := ArchiveName(tt.manga, tt.chapter); got != tt.want {
+			t.Errorf("ArchiveName(%q) = %q, ожидалось %q", tt.manga.Title, got, tt.want)
+		}
+	}
+}
+
+func readZipFile(t *testing.T, file *zip.File) []byte {
+	t.Helper()
+
+	rc, err := file.Open()
+	if err != nil {
+		t.Fatalf("%s: %v", file.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("%s: %v", file.Name, err)
+	}
+	return data
+}
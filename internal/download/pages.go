@@ -0,0 +1,75 @@
+package download
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// PageListFunc извлекает упорядоченный список URL страниц из HTML страницы читалки
+type PageListFunc func(html string, chapterURL string) ([]string, error)
+
+// pageExtractors маппинг SourceName -> экстрактор страниц
+var pageExtractors = map[types.SourceName]PageListFunc{
+	types.SourceReadmanga: ExtractReadmangaPages,
+	types.SourceMintmanga: ExtractReadmangaPages, // mintmanga использует ту же читалку
+}
+
+// GetPageExtractor возвращает экстрактор страниц по имени источника
+func GetPageExtractor(sourceName types.SourceName) (PageListFunc, error) {
+	extractor, exists := pageExtractors[sourceName]
+	if !exists {
+		return nil, fmt.Errorf("скачивание для '%s' не поддерживается", sourceName)
+	}
+	return extractor, nil
+}
+
+// RegisterPageExtractor регистрирует экстрактор страниц для источника
+func RegisterPageExtractor(sourceName types.SourceName, extractor PageListFunc) {
+	pageExtractors[sourceName] = extractor
+}
+
+// readmangaPagePattern элемент массива страниц в вызове rm_h.readerInit / rm_h.readerDoInit:
+// ['https://img.host/','',"auto/12/34/01.jpg?t=1",1100,1600]
+var readmangaPagePattern = regexp.MustCompile(`\[\s*'([^']*)'\s*,\s*'([^']*)'\s*,\s*"([^"]+)"\s*,\s*\d+\s*,\s*\d+\s*\]`)
+
+// ExtractReadmangaPages извлекает страницы из читалки readmanga/mintmanga.
+// Список страниц встроен в скрипт страницы главы как массив [хост, префикс, путь, ширина, высота].
+func ExtractReadmangaPages(html string, chapterURL string) ([]string, error) {
+	start := strings.Index(html, "rm_h.readerInit(")
+	if start == -1 {
+		start = strings.Index(html, "rm_h.readerDoInit(")
+	}
+	if start == -1 {
+		return nil, fmt.Errorf("скрипт читалки не найден на странице %s", chapterURL)
+	}
+
+	base, err := url.Parse(chapterURL)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный URL главы: %w", err)
+	}
+
+	var pages []string
+	for _, match := range readmangaPagePattern.FindAllStringSubmatch(html[start:], -1) {
+		pageURL := match[1] + match[2] + match[3]
+		if strings.HasPrefix(match[3], "http") {
+			pageURL = match[3]
+		}
+
+		parsed, err := url.Parse(pageURL)
+		if err != nil {
+			continue
+		}
+
+		pages = append(pages, base.ResolveReference(parsed).String())
+	}
+
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("список страниц пуст на странице %s", chapterURL)
+	}
+
+	return pages, nil
+}
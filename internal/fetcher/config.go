@@ -0,0 +1,86 @@
+package fetcher
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// Настройки фетчера читаются из переменных окружения.
+// Для каждой настройки можно задать значение для конкретного источника,
+// добавив суффикс с именем парсера: FETCH_RETRY_ATTEMPTS_MINTMANGA=5.
+
+// envKey возвращает имя переменной для источника: FETCH_RETRY_ATTEMPTS + mintmanga -> FETCH_RETRY_ATTEMPTS_MINTMANGA
+func envKey(key string, source types.SourceName) string {
+	return key + "_" + strings.ToUpper(string(source))
+}
+
+// lookupEnv ищет значение сначала для источника, затем общее
+func lookupEnv(key string, source types.SourceName) (string, bool) {
+	if source != "" {
+		if value := os.Getenv(envKey(key, source)); value != "" {
+			return value, true
+		}
+	}
+	if value := os.Getenv(key); value != "" {
+		return value, true
+	}
+	return "", false
+}
+
+// envString возвращает строковую настройку или значение по умолчанию
+func envString(key string, source types.SourceName, defaultValue string) string {
+	if value, ok := lookupEnv(key, source); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// envInt возвращает целочисленную настройку или значение по умолчанию
+func envInt(key string, source types.SourceName, defaultValue int) int {
+	value, ok := lookupEnv(key, source)
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// envDuration возвращает настройку-длительность ("30s", "5m") или значение по умолчанию
+func envDuration(key string, source types.SourceName, defaultValue time.Duration) time.Duration {
+	value, ok := lookupEnv(key, source)
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// envFloat возвращает дробную настройку или значение по умолчанию
+func envFloat(key string, source types.SourceName, defaultValue float64) float64 {
+	value, ok := lookupEnv(key, source)
+	if !ok {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
@@ -0,0 +1,119 @@
+package fetcher
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding кодировки, которые фетчер умеет распаковывать.
+// Заголовок выставляется вручную, поэтому прозрачная распаковка net/http отключена и делается здесь.
+const acceptEncoding = "gzip, deflate, br"
+
+// UnsupportedEncodingError сервер ответил в кодировке, которую мы не запрашивали
+type UnsupportedEncodingError struct {
+	Encoding string
+}
+
+func (e *UnsupportedEncodingError) Error() string {
+	return fmt.Sprintf("неподдерживаемая кодировка ответа: %s", e.Encoding)
+}
+
+// decodedBody распакованное тело ответа. Close закрывает распаковщики, но не исходное тело.
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer // В порядке создания
+}
+
+func (b *decodedBody) Close() error {
+	var firstErr error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		if err := b.closers[i].Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// decodeBody оборачивает тело ответа распаковщиками согласно Content-Encoding.
+// Кодировки применяются в обратном порядке, как того требует RFC 9110.
+// Без заголовка тело с сигнатурой gzip всё равно распаковывается — некоторые источники её не указывают.
+// Результат нужно закрыть, даже если тело прочитано не до конца.
+func decodeBody(header http.Header, body io.Reader) (io.ReadCloser, error) {
+	var encodings []string
+	for _, value := range header.Values("Content-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if encoding != "" && encoding != "identity" {
+				encodings = append(encodings, encoding)
+			}
+		}
+	}
+
+	if len(encodings) == 0 {
+		buffered := bufio.NewReader(body)
+		if magic, _ := buffered.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+			reader, err := gzip.NewReader(buffered)
+			if err != nil {
+				return nil, err
+			}
+			return &decodedBody{Reader: reader, closers: []io.Closer{reader}}, nil
+		}
+		return &decodedBody{Reader: buffered}, nil
+	}
+
+	decoded := &decodedBody{Reader: body}
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var reader io.Reader
+		var err error
+
+		switch encodings[i] {
+		case "gzip", "x-gzip":
+			reader, err = gzip.NewReader(decoded.Reader)
+		case "deflate":
+			reader, err = newDeflateReader(decoded.Reader)
+		case "br":
+			reader = brotli.NewReader(decoded.Reader)
+		default:
+			decoded.Close()
+			return nil, &UnsupportedEncodingError{Encoding: encodings[i]}
+		}
+
+		if err != nil {
+			decoded.Close()
+			return nil, fmt.Errorf("ошибка распаковки %s: %w", encodings[i], err)
+		}
+
+		decoded.Reader = reader
+		if closer, ok := reader.(io.Closer); ok {
+			decoded.closers = append(decoded.closers, closer)
+		}
+	}
+
+	return decoded, nil
+}
+
+// newDeflateReader читает deflate по стандарту (zlib-обёртка),
+// а для серверов, отдающих «сырой» deflate без обёртки, откатывается на flate
+func newDeflateReader(body io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(body)
+
+	header, err := buffered.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Заголовок zlib: CM = 8 и контрольная сумма первых двух байт кратна 31
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+
+	return flate.NewReader(buffered), nil
+}
@@ -0,0 +1,204 @@
+package fetcher
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+const decodePage = "<html><body>Берсерк — глава 1</body></html>"
+
+// compress сжимает данные кодировкой из Content-Encoding; deflate-raw — deflate без zlib-обёртки
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "deflate-raw":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	default:
+		t.Fatalf("неизвестная кодировка %s", encoding)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("сжатие %s: %v", encoding, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("сжатие %s: %v", encoding, err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeBody(t *testing.T) {
+	page := []byte(decodePage)
+
+	tests := []struct {
+		name        string
+		header      string // Content-Encoding
+		body        []byte
+		closers     int // Сколько распаковщиков нужно закрыть
+		wantErr     bool
+		unsupported bool // Ошибка — UnsupportedEncodingError
+	}{
+		{name: "без сжатия", body: page},
+		{name: "identity", header: "identity", body: page},
+		{name: "gzip", header: "gzip", body: compress(t, "gzip", page), closers: 1},
+		{name: "x-gzip", header: "x-gzip", body: compress(t, "gzip", page), closers: 1},
+		{name: "gzip без заголовка", body: compress(t, "gzip", page), closers: 1},
+		{name: "deflate с zlib", header: "deflate", body: compress(t, "deflate", page), closers: 1},
+		{name: "deflate без обёртки", header: "deflate", body: compress(t, "deflate-raw", page), closers: 1},
+		{name: "br", header: "br", body: compress(t, "br", page)},
+		{name: "регистр и пробелы", header: " GZIP ", body: compress(t, "gzip", page), closers: 1},
+		// Кодировки перечислены в порядке применения: сначала gzip, затем br
+		{name: "gzip и br", header: "gzip, br", body: compress(t, "br", compress(t, "gzip", page)), closers: 1},
+		{name: "deflate и gzip", header: "deflate, gzip", body: compress(t, "gzip", compress(t, "deflate", page)), closers: 2},
+		{name: "повтор заголовка", header: "gzip\nidentity\ndeflate", body: compress(t, "deflate", compress(t, "gzip", page)), closers: 2},
+
+		{name: "неподдерживаемая", header: "compress", body: page, wantErr: true, unsupported: true},
+		{name: "неподдерживаемая среди известных", header: "gzip, zstd", body: page, wantErr: true, unsupported: true},
+		{name: "битый gzip", header: "gzip", body: page, wantErr: true},
+		{name: "пустой deflate", header: "deflate", body: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for _, value := range strings.Split(tt.header, "\n") {
+				if value != "" {
+					header.Add("Content-Encoding", value)
+				}
+			}
+
+			decoded, err := decodeBody(header, bytes.NewReader(tt.body))
+			if tt.wantErr {
+				if err == nil {
+					decoded.Close()
+					t.Fatal("ожидалась ошибка")
+				}
+				var unsupported *UnsupportedEncodingError
+				if tt.unsupported && !errors.As(err, &unsupported) {
+					t.Errorf("ожидалась UnsupportedEncodingError, получено %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeBody: %v", err)
+			}
+
+			if closers := len(decoded.(*decodedBody).closers); closers != tt.closers {
+				t.Errorf("распаковщиков для закрытия %d, ожидалось %d", closers, tt.closers)
+			}
+
+			got, err := io.ReadAll(decoded)
+			if err != nil {
+				t.Fatalf("чтение: %v", err)
+			}
+			if string(got) != decodePage {
+				t.Errorf("распаковано %q, ожидалось %q", got, decodePage)
+			}
+			if err := decoded.Close(); err != nil {
+				t.Errorf("Close: %v", err)
+			}
+		})
+	}
+}
+
+// newEncodedSite отдаёт body со сжатием gzip по любому пути
+func newEncodedSite(t *testing.T, body []byte) *httptest.Server {
+	t.Helper()
+
+	compressed := compress(t, "gzip", body)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBodyLimit(t *testing.T) {
+	t.Setenv("FETCH_RATE_DELAY", "0")
+	t.Setenv("FETCH_RETRY_ATTEMPTS", "1")
+
+	// Сжатое тело меньше ограничения, распакованное — больше
+	page := bytes.Repeat([]byte("a"), 4096)
+	server := newEncodedSite(t, page)
+	f := New(Options{Timeout: 5 * time.Second, MaxBodySize: 1024})
+
+	_, err := f.Get(context.Background(), Request{URL: server.URL + "/big", Kind: KindPage})
+	var tooLarge *BodyTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 1024 {
+		t.Fatalf("ожидалась BodyTooLargeError с пределом 1024, получено %v", err)
+	}
+
+	truncated, err := f.Get(context.Background(), Request{URL: server.URL + "/big", Kind: KindPage, TruncateBody: true})
+	if err != nil {
+		t.Fatalf("с обрезкой: %v", err)
+	}
+	if len(truncated.Body) != 1024 {
+		t.Errorf("обрезанное тело %d байт, ожидалось 1024", len(truncated.Body))
+	}
+	if truncated.Header.Get("Content-Encoding") != "" {
+		t.Error("заголовок Content-Encoding остался у распакованного тела")
+	}
+
+	if resp, err := f.Get(context.Background(), Request{URL: server.URL + "/big", Kind: KindPage, MaxBodySize: 8192}); err != nil || len(resp.Body) != len(page) {
+		t.Errorf("с пределом запроса 8192: %v", err)
+	}
+}
+
+func TestSourceBodyLimit(t *testing.T) {
+	t.Setenv("FETCH_RATE_DELAY", "0")
+	t.Setenv("FETCH_RETRY_ATTEMPTS", "1")
+	t.Setenv("FETCH_MAX_BODY_BYTES_READMANGA", "1024")
+	t.Setenv("FETCH_MAX_BODY_BYTES_MINTMANGA", "не число")
+
+	server := newEncodedSite(t, bytes.Repeat([]byte("a"), 2048))
+	f := New(Options{Timeout: 5 * time.Second, MaxBodySize: 4096})
+
+	// Настройка читается при создании фетчера и дальше не перечитывается
+	t.Setenv("FETCH_MAX_BODY_BYTES_READMANGA", "8192")
+	t.Setenv("FETCH_MAX_BODY_BYTES_MINTMANGA", "16")
+
+	tests := []struct {
+		source    types.SourceName
+		wantLimit int64 // 0 — тело помещается
+	}{
+		{types.SourceReadmanga, 1024},
+		{types.SourceMintmanga, 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		_, err := f.Get(context.Background(), Request{Source: tt.source, URL: server.URL + "/page", Kind: KindPage})
+
+		var tooLarge *BodyTooLargeError
+		switch {
+		case tt.wantLimit == 0 && err != nil:
+			t.Errorf("%q: %v", tt.source, err)
+		case tt.wantLimit != 0 && (!errors.As(err, &tooLarge) || tooLarge.Limit != tt.wantLimit):
+			t.Errorf("%q: ожидалась BodyTooLargeError с пределом %d, получено %v", tt.source, tt.wantLimit, err)
+		}
+	}
+}
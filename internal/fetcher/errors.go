@@ -0,0 +1,167 @@
+package fetcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrorKind тип ошибки запроса к источнику
+type ErrorKind string
+
+const (
+	ErrorBlocked    ErrorKind = "blocked"      // Источник отклоняет наши запросы (403, 429, 451)
+	ErrorChallenge  ErrorKind = "challenge"    // Страница проверки Cloudflare / DDoS-Guard / капча
+	ErrorNotFound   ErrorKind = "not_found"    // Страница не существует (404, 410)
+	ErrorServer     ErrorKind = "server_error" // Ошибка на стороне источника (5xx)
+	ErrorNetwork    ErrorKind = "network"      // Таймаут, обрыв соединения, DNS
+	ErrorParse      ErrorKind = "parse_error"  // Ответ получен, но разобрать его не удалось (сменилась вёрстка)
+	ErrorDisallowed ErrorKind = "disallowed"   // Путь запрещён robots.txt источника
+	ErrorOther      ErrorKind = "other"        // Прочие ошибки
+)
+
+// ChallengeError источник вернул страницу проверки вместо контента
+type ChallengeError struct {
+	Provider   string // cloudflare, ddos-guard, captcha
+	StatusCode int
+	URL        string
+}
+
+func (e *ChallengeError) Error() string {
+	return fmt.Sprintf("страница проверки %s (статус %d)", e.Provider, e.StatusCode)
+}
+
+// ParseError ответ получен, но не содержит ожидаемых данных
+type ParseError struct {
+	URL    string
+	Detail string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Detail, e.Err)
+	}
+	return e.Detail
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Classify определяет тип ошибки запроса
+func Classify(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	var challengeErr *ChallengeError
+	if errors.As(err, &challengeErr) {
+		return ErrorChallenge
+	}
+
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return ErrorParse
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch code := statusErr.StatusCode; {
+		case code == http.StatusForbidden || code == http.StatusTooManyRequests || code == http.StatusUnavailableForLegalReasons:
+			return ErrorBlocked
+		case code == http.StatusNotFound || code == http.StatusGone:
+			return ErrorNotFound
+		case code >= 500:
+			return ErrorServer
+		}
+		return ErrorOther
+	}
+
+	var robotsErr *RobotsDisallowedError
+	if errors.As(err, &robotsErr) {
+		return ErrorDisallowed
+	}
+
+	var fixtureErr *FixtureNotFoundError
+	if errors.As(err, &fixtureErr) {
+		return ErrorOther
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorNetwork
+	}
+
+	return ErrorOther
+}
+
+// IsBlocking ошибка означает, что источник нас блокирует (бан или проверка)
+func IsBlocking(kind ErrorKind) bool {
+	return kind == ErrorBlocked || kind == ErrorChallenge
+}
+
+// StatusCodeOf возвращает HTTP статус из ошибки (0 — статуса нет)
+func StatusCodeOf(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+
+	var challengeErr *ChallengeError
+	if errors.As(err, &challengeErr) {
+		return challengeErr.StatusCode
+	}
+
+	return 0
+}
+
+// challengeSignatures признаки страниц проверки в теле ответа
+var challengeSignatures = []struct {
+	provider string
+	markers  []string
+}{
+	{"cloudflare", []string{"cf-chl-", "cf_chl_opt", "challenge-platform", "<title>Just a moment...</title>", "Attention Required! | Cloudflare"}},
+	{"ddos-guard", []string{"ddos-guard.net", "DDoS-Guard", "__ddg1"}},
+	{"captcha", []string{"g-recaptcha", "h-captcha", "hcaptcha.com", "smartcaptcha", "captcha.yandex"}},
+}
+
+// detectChallenge ищет признаки страницы проверки в ответе.
+// Для успешных HTML страниц проверяется только заголовок cf-mitigated и разметка без
+// признаков обычной страницы: капча в форме комментариев не должна считаться проверкой.
+func detectChallenge(statusCode int, header http.Header, body []byte, kind Kind) string {
+	if header.Get("Cf-Mitigated") == "challenge" {
+		return "cloudflare"
+	}
+
+	isError := statusCode == http.StatusForbidden || statusCode == http.StatusServiceUnavailable || statusCode == http.StatusTooManyRequests
+	looksLikeHTML := bytes.Contains(bytes.ToLower(body[:min(len(body), 1024)]), []byte("<html"))
+
+	switch {
+	case isError:
+	case kind == KindFeed && looksLikeHTML:
+		// Вместо XML пришла HTML страница — почти наверняка проверка
+	case kind == KindPage && len(body) < 32<<10 && looksLikeHTML:
+		// Страница проверки маленькая, полноценная страница манги — сотни килобайт
+	default:
+		return ""
+	}
+
+	server := strings.ToLower(header.Get("Server"))
+	if isError && strings.Contains(server, "ddos-guard") {
+		return "ddos-guard"
+	}
+
+	for _, signature := range challengeSignatures {
+		for _, marker := range signature.markers {
+			if bytes.Contains(body, []byte(marker)) {
+				return signature.provider
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,409 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+const (
+	// DefaultMaxBodySize максимальный размер тела ответа по умолчанию
+	DefaultMaxBodySize = 10 << 20
+
+	// errorBodySize сколько читать из тела ошибочного ответа
+	errorBodySize = 64 << 10
+)
+
+// Request запрос к источнику
+type Request struct {
+	Source        types.SourceName // Источник (для настроек, переопределённых per-source)
+	URL           string           // Полный URL
+	Kind          Kind             // Тип ресурса (страница, фид, изображение)
+	Referer       string           // Базовый URL источника для заголовка Referer
+	MaxBodySize   int64            // Ограничение размера тела (0 — значение по умолчанию фетчера)
+	TruncateBody  bool             // Обрезать тело до MaxBodySize вместо BodyTooLargeError
+	RespectRobots bool             // Проверять robots.txt и соблюдать Crawl-delay
+}
+
+// Response ответ источника с уже распакованным телом
+type Response struct {
+	URL        string      // Итоговый URL (после редиректов)
+	StatusCode int         // HTTP статус
+	Header     http.Header // Заголовки ответа
+	Body       []byte      // Тело ответа
+}
+
+// StatusError ответ со статусом, отличным от 2xx
+type StatusError struct {
+	StatusCode int
+	Status     string
+	URL        string
+	RetryAfter time.Duration // Значение Retry-After (для 429 и 503)
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("статус ошибки: %d %s", e.StatusCode, e.Status)
+}
+
+// BodyTooLargeError тело ответа превышает допустимый размер
+type BodyTooLargeError struct {
+	Limit int64
+	URL   string
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("ответ больше %d байт", e.Limit)
+}
+
+// Options настройки фетчера
+type Options struct {
+	Timeout     time.Duration     // Таймаут одного запроса
+	MaxBodySize int64             // Ограничение размера тела ответа
+	Transport   http.RoundTripper // Транспорт для прямых запросов (nil — общий пул соединений)
+	Proxies     string            // Список прокси через запятую (http://, https://, socks5://)
+	Mode        Mode              // live, record или replay
+	FixturesDir string            // Каталог фикстур для record/replay
+}
+
+// Fetcher общий HTTP клиент для всех парсеров: один транспорт с пулом соединений,
+// keep-alive и переиспользованием TLS сессий, заголовки браузера, распаковка,
+// ограничение размера ответа и статистика по хостам.
+type Fetcher struct {
+	timeout      time.Duration
+	transport    http.RoundTripper
+	proxies      *ProxyPool
+	fingerprints *fingerprintPool
+	maxBodySize  int64
+	maxBodySizes map[types.SourceName]int64 // FETCH_MAX_BODY_BYTES_<ИСТОЧНИК>, прочитанные при создании
+	mode         Mode
+	fixtures     *fixtureStore
+	limiter      *rateLimiter
+	robots       *robotsCache
+
+	mu    sync.Mutex
+	stats map[string]*HostStats
+
+	sessionMu    sync.Mutex
+	sessionStore SessionStore
+	jars         map[types.SourceName]*sessionJar
+}
+
+var (
+	defaultFetcher *Fetcher
+	defaultOnce    sync.Once
+)
+
+// Default возвращает общий для всего приложения фетчер
+func Default() *Fetcher {
+	defaultOnce.Do(func() {
+		defaultFetcher = New(Options{
+			Proxies:     os.Getenv("FETCH_PROXIES"),
+			MaxBodySize: int64(envInt("FETCH_MAX_BODY_BYTES", "", DefaultMaxBodySize)),
+			Mode:        parseMode(os.Getenv("FETCH_MODE")),
+			FixturesDir: os.Getenv("FETCH_FIXTURES_DIR"),
+		})
+	})
+	return defaultFetcher
+}
+
+// New создаёт фетчер. Отдельные экземпляры нужны только для подмены транспорта.
+func New(opts Options) *Fetcher {
+	if opts.Timeout == 0 {
+		opts.Timeout = 60 * time.Second
+	}
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if opts.Transport == nil {
+		opts.Transport = newTransport()
+	}
+	if opts.Mode == "" {
+		opts.Mode = ModeLive
+	}
+	if opts.FixturesDir == "" {
+		opts.FixturesDir = defaultFixturesDir
+	}
+
+	// Воспроизведение идёт без сети — прокси не нужны
+	if opts.Mode == ModeReplay {
+		opts.Proxies = ""
+	}
+
+	f := &Fetcher{
+		timeout:      opts.Timeout,
+		transport:    opts.Transport,
+		proxies:      newProxyPool(opts.Proxies, newTransport),
+		fingerprints: newFingerprintPool(loadFingerprints()),
+		maxBodySize:  opts.MaxBodySize,
+		maxBodySizes: loadMaxBodySizes(),
+		stats:        make(map[string]*HostStats),
+		jars:         make(map[types.SourceName]*sessionJar),
+		mode:         opts.Mode,
+		fixtures:     &fixtureStore{dir: opts.FixturesDir},
+		limiter:      &rateLimiter{next: make(map[string]time.Time)},
+		robots:       &robotsCache{entries: make(map[string]*robotsEntry)},
+	}
+
+	if f.mode != ModeLive {
+		log.Printf("Режим фетчера: %s, фикстуры: %s", f.mode, opts.FixturesDir)
+	}
+
+	if f.proxies.Len() > 0 {
+		log.Printf("Пул прокси: %d шт.", f.proxies.Len())
+		f.proxies.StartHealthChecks(context.Background())
+	}
+
+	return f
+}
+
+// loadMaxBodySizes читает ограничения размера тела, заданные для отдельных источников.
+// Общее FETCH_MAX_BODY_BYTES передаётся в Options.MaxBodySize.
+func loadMaxBodySizes() map[types.SourceName]int64 {
+	sizes := make(map[types.SourceName]int64)
+	for _, source := range types.AllSources {
+		if size := envInt(envKey("FETCH_MAX_BODY_BYTES", source), "", 0); size > 0 {
+			sizes[source] = int64(size)
+		}
+	}
+	return sizes
+}
+
+// maxBodySizeFor ограничение размера тела для источника
+func (f *Fetcher) maxBodySizeFor(source types.SourceName) int64 {
+	if size, ok := f.maxBodySizes[source]; ok {
+		return size
+	}
+	return f.maxBodySize
+}
+
+// ProxyStatuses состояние прокси пула
+func (f *Fetcher) ProxyStatuses() []ProxyStatus {
+	return f.proxies.Statuses()
+}
+
+// newTransport создаёт транспорт с пулом соединений
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   15 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		ExpectContinueTimeout: 5 * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+}
+
+// Get выполняет GET запрос. Статус, отличный от 2xx, возвращается как *StatusError.
+// Временные ошибки повторяются по RetryPolicyFor(req.Source) с экспоненциальной задержкой.
+func (f *Fetcher) Get(ctx context.Context, req Request) (*Response, error) {
+	parsed, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный URL: %w", err)
+	}
+
+	crawlDelay, err := f.checkRobots(ctx, req, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := RetryPolicyFor(req.Source)
+	jar := f.jarFor(req.Source, req.Referer)
+	login := loginConfigFor(req.Source)
+	relogged := false
+
+	for attempt := 1; ; attempt++ {
+		// В режиме replay сети нет — выдерживать паузы незачем
+		if f.mode != ModeReplay {
+			if err := f.limiter.wait(ctx, parsed.Host, rateDelay(req, crawlDelay)); err != nil {
+				return nil, err
+			}
+		}
+
+		start := time.Now()
+		resp, err := f.do(ctx, req, jar)
+
+		// Сессия истекла — входим заново и повторяем запрос (один раз за вызов)
+		if !relogged && sessionExpired(login, resp) {
+			relogged = true
+			if loginErr := f.login(ctx, req.Source, login, jar, req.Referer); loginErr != nil {
+				log.Printf("Ошибка входа на %s: %v", req.Source, loginErr)
+			} else {
+				resp, err = f.do(ctx, req, jar)
+			}
+		}
+
+		f.persistSession(req.Source, jar)
+
+		// Блокировку могли вызвать заголовки — следующий запрос пойдёт с другим профилем браузера
+		if err != nil && IsBlocking(Classify(err)) {
+			f.fingerprints.Rotate(req.Source)
+		}
+
+		var status int
+		var size int64
+		if resp != nil {
+			status = resp.StatusCode
+			size = int64(len(resp.Body))
+		}
+		f.record(parsed.Host, status, size, time.Since(start), err)
+
+		if err == nil || attempt >= policy.MaxAttempts || !IsRetryable(err) {
+			return resp, err
+		}
+
+		var retryAfter time.Duration
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			retryAfter = statusErr.RetryAfter
+		}
+
+		delay, ok := policy.Delay(attempt, retryAfter)
+		if !ok {
+			log.Printf("Сервер %s просит подождать %v — откладываем до следующей проверки", parsed.Host, retryAfter)
+			return resp, err
+		}
+
+		log.Printf("Повтор запроса %s через %v (попытка %d из %d): %v", req.URL, delay.Round(time.Millisecond), attempt+1, policy.MaxAttempts, err)
+		f.recordRetry(parsed.Host)
+
+		if err := sleep(ctx, delay); err != nil {
+			return resp, err
+		}
+	}
+}
+
+// clientFor возвращает клиента для запроса к источнику: прокси по FETCH_PROXY и cookie jar сессии
+func (f *Fetcher) clientFor(source types.SourceName, jar *sessionJar) (*http.Client, *Proxy, error) {
+	if f.mode == ModeReplay {
+		client := &http.Client{Timeout: f.timeout, Transport: &replayTransport{store: f.fixtures}}
+		if jar != nil {
+			client.Jar = jar
+		}
+		return client, nil, nil
+	}
+
+	proxy, err := f.selectProxy(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client := &http.Client{Timeout: f.timeout, Transport: f.transport}
+	if proxy != nil {
+		client.Transport = proxy.transport
+	}
+	if f.mode == ModeRecord {
+		client.Transport = &recordingTransport{next: client.Transport, store: f.fixtures}
+	}
+	if jar != nil {
+		client.Jar = jar
+	}
+
+	return client, proxy, nil
+}
+
+// do выполняет запрос и читает тело
+func (f *Fetcher) do(ctx context.Context, req Request, jar *sessionJar) (*Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+
+	httpReq.Header = buildHeaders(f.fingerprints.For(req.Source), req.Kind, req.Referer)
+
+	client, proxy, err := f.clientFor(req.Source, jar)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		if proxy != nil {
+			// Отменённый запрос ничего не говорит о прокси
+			if ctx.Err() == nil {
+				f.proxies.ReportFailure(proxy, err)
+			}
+			return nil, fmt.Errorf("ошибка запроса через прокси %s: %w", proxy.Redacted(), err)
+		}
+		return nil, fmt.Errorf("ошибка запроса: %w", err)
+	}
+	defer resp.Body.Close()
+
+	result := &Response{
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}
+
+	success := resp.StatusCode >= 200 && resp.StatusCode <= 299
+
+	// У ошибочных ответов читаем только начало — его достаточно для распознавания страницы проверки
+	limit := req.MaxBodySize
+	if limit == 0 {
+		limit = f.maxBodySizeFor(req.Source)
+	}
+	if !success {
+		limit = errorBodySize
+	}
+
+	// Ограничение действует на распакованный размер, чтобы сжатая «бомба» не заняла всю память
+	decoded, err := decodeBody(resp.Header, resp.Body)
+	if err != nil {
+		if success {
+			return result, fmt.Errorf("ошибка декодирования ответа: %w", err)
+		}
+		log.Printf("Ошибка декодирования ответа %s: %v", req.URL, err)
+		decoded = io.NopCloser(strings.NewReader(""))
+	}
+	defer decoded.Close()
+
+	body, err := io.ReadAll(io.LimitReader(decoded, limit+1))
+	if err != nil && success {
+		if ctx.Err() == nil {
+			f.proxies.ReportFailure(proxy, err)
+		}
+		return result, fmt.Errorf("ошибка чтения ответа: %w", err)
+	}
+	if int64(len(body)) > limit {
+		if success && !req.TruncateBody {
+			return result, &BodyTooLargeError{Limit: limit, URL: req.URL}
+		}
+		body = body[:limit]
+	}
+
+	// Тело уже распаковано — заголовки длины и кодировки к нему больше не относятся
+	result.Header.Del("Content-Encoding")
+	result.Header.Del("Content-Length")
+
+	decompressed := body
+
+	if provider := detectChallenge(resp.StatusCode, resp.Header, decompressed, req.Kind); provider != "" {
+		f.proxies.ReportResult(proxy, true)
+		return result, &ChallengeError{Provider: provider, StatusCode: resp.StatusCode, URL: req.URL}
+	}
+
+	f.proxies.ReportResult(proxy, Classify(&StatusError{StatusCode: resp.StatusCode}) == ErrorBlocked)
+
+	if !success {
+		return result, &StatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			URL:        req.URL,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	result.Body = decompressed
+	return result, nil
+}
@@ -0,0 +1,139 @@
+package fetcher
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// defaultFingerprintTTL сколько источник ходит с одним профилем, если не было ошибок
+const defaultFingerprintTTL = 24 * time.Hour
+
+//go:embed fingerprints.json
+var defaultFingerprints []byte
+
+// Fingerprint профиль браузера: User-Agent и согласованные с ним заголовки.
+// Client hints (Sec-CH-UA*) отправляют только Chromium-браузеры, у остальных поля пустые.
+type Fingerprint struct {
+	Name            string            `json:"name"`
+	UserAgent       string            `json:"user_agent"`
+	Accept          map[string]string `json:"accept"` // Accept по типу ресурса: page, feed, image
+	AcceptLanguage  string            `json:"accept_language"`
+	SecCHUA         string            `json:"sec_ch_ua"`
+	SecCHUAMobile   string            `json:"sec_ch_ua_mobile"`
+	SecCHUAPlatform string            `json:"sec_ch_ua_platform"`
+}
+
+// loadFingerprints читает профили из FETCH_FINGERPRINTS_FILE, а без него (или при ошибке) — встроенные
+func loadFingerprints() []Fingerprint {
+	if path := os.Getenv("FETCH_FINGERPRINTS_FILE"); path != "" {
+		profiles, err := readFingerprints(path)
+		if err == nil {
+			log.Printf("Загружено %d профилей браузера из %s", len(profiles), path)
+			return profiles
+		}
+		log.Printf("Ошибка загрузки профилей браузера из %s: %v — используются встроенные", path, err)
+	}
+
+	profiles, err := parseFingerprints(defaultFingerprints)
+	if err != nil {
+		panic(fmt.Sprintf("встроенные профили браузера повреждены: %v", err))
+	}
+	return profiles
+}
+
+func readFingerprints(path string) ([]Fingerprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseFingerprints(data)
+}
+
+func parseFingerprints(data []byte) ([]Fingerprint, error) {
+	var profiles []Fingerprint
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("ошибка разбора JSON: %w", err)
+	}
+
+	valid := profiles[:0]
+	for _, p := range profiles {
+		if p.UserAgent == "" {
+			log.Printf("Профиль браузера %q без user_agent пропущен", p.Name)
+			continue
+		}
+		valid = append(valid, p)
+	}
+
+	if len(valid) == 0 {
+		return nil, fmt.Errorf("нет ни одного профиля")
+	}
+	return valid, nil
+}
+
+// stickyFingerprint профиль, закреплённый за источником
+type stickyFingerprint struct {
+	index      int // Индекс профиля в пуле
+	assignedAt time.Time
+}
+
+// fingerprintPool раздаёт источникам профили браузера. Источник держит профиль
+// FETCH_FINGERPRINT_TTL, чтобы запросы одной «сессии» выглядели одним браузером,
+// и меняет его раньше только после блокировки.
+type fingerprintPool struct {
+	mu       sync.Mutex
+	profiles []Fingerprint
+	assigned map[types.SourceName]*stickyFingerprint
+}
+
+func newFingerprintPool(profiles []Fingerprint) *fingerprintPool {
+	return &fingerprintPool{
+		profiles: profiles,
+		assigned: make(map[types.SourceName]*stickyFingerprint),
+	}
+}
+
+// For возвращает профиль источника, выбирая новый по истечении TTL
+func (p *fingerprintPool) For(source types.SourceName) Fingerprint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current, ok := p.assigned[source]
+	if ok && time.Since(current.assignedAt) < envDuration("FETCH_FINGERPRINT_TTL", source, defaultFingerprintTTL) {
+		return p.profiles[current.index]
+	}
+
+	return p.assignLocked(source, current)
+}
+
+// Rotate меняет профиль источника на другой
+func (p *fingerprintPool) Rotate(source types.SourceName) Fingerprint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	profile := p.assignLocked(source, p.assigned[source])
+	log.Printf("Профиль браузера для %s сменён на %s", source, profile.Name)
+	return profile
+}
+
+// assignLocked закрепляет за источником случайный профиль, по возможности отличный от текущего
+func (p *fingerprintPool) assignLocked(source types.SourceName, current *stickyFingerprint) Fingerprint {
+	index := rand.IntN(len(p.profiles))
+
+	if current != nil && len(p.profiles) > 1 {
+		index = rand.IntN(len(p.profiles) - 1)
+		if index >= current.index {
+			index++
+		}
+	}
+
+	p.assigned[source] = &stickyFingerprint{index: index, assignedAt: time.Now()}
+	return p.profiles[index]
+}
@@ -0,0 +1,210 @@
+package fetcher
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mode режим работы фетчера (FETCH_MODE)
+type Mode string
+
+const (
+	ModeLive   Mode = "live"   // Обычные запросы к источникам
+	ModeRecord Mode = "record" // Запросы к источникам с сохранением ответов в фикстуры
+	ModeReplay Mode = "replay" // Ответы только из фикстур, без сети
+)
+
+// defaultFixturesDir каталог фикстур по умолчанию (FETCH_FIXTURES_DIR)
+const defaultFixturesDir = "testdata/fixtures"
+
+// parseMode разбирает FETCH_MODE, неизвестное значение означает live
+func parseMode(value string) Mode {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case ModeRecord, ModeReplay:
+		return mode
+	case ModeLive, "":
+		return ModeLive
+	default:
+		log.Printf("Неизвестный FETCH_MODE=%q, используется live", value)
+		return ModeLive
+	}
+}
+
+// Fixture сохранённый ответ источника. Тело хранится как пришло по сети (в том числе сжатым),
+// чтобы при воспроизведении отрабатывали те же распаковка и проверки.
+type Fixture struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// FixtureNotFoundError в режиме replay для запроса нет сохранённого ответа
+type FixtureNotFoundError struct {
+	Method string
+	URL    string
+	Path   string
+}
+
+func (e *FixtureNotFoundError) Error() string {
+	return fmt.Sprintf("нет фикстуры для %s %s (%s)", e.Method, e.URL, e.Path)
+}
+
+// fixtureStore каталог фикстур: <dir>/<host>/<путь>-<хеш>.json
+type fixtureStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+var unsafePathChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// path возвращает файл фикстуры запроса. Ключ — метод, URL и тело (для POST логина).
+func (s *fixtureStore) path(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.String() + "\n"))
+	hash.Write(body)
+	sum := hex.EncodeToString(hash.Sum(nil))[:12]
+
+	name := strings.Trim(unsafePathChars.ReplaceAllString(req.URL.Path, "_"), "_")
+	if len(name) > 60 {
+		name = name[:60]
+	}
+	if name == "" {
+		name = "index"
+	}
+
+	host := unsafePathChars.ReplaceAllString(req.URL.Host, "_")
+	return filepath.Join(s.dir, host, fmt.Sprintf("%s-%s.json", name, sum))
+}
+
+func (s *fixtureStore) load(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("ошибка разбора фикстуры %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+func (s *fixtureStore) save(path string, fixture *Fixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// readRequestBody читает тело запроса для ключа фикстуры и возвращает его обратно в запрос
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// recordingTransport выполняет запрос и сохраняет ответ в фикстуру
+type recordingTransport struct {
+	next  http.RoundTripper
+	store *fixtureStore
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	path := t.store.path(req, reqBody)
+	fixture := &Fixture{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+
+	if err := t.store.save(path, fixture); err != nil {
+		log.Printf("Ошибка записи фикстуры %s: %v", path, err)
+	} else {
+		log.Printf("Ответ %s сохранён в %s", req.URL, path)
+	}
+
+	return resp, nil
+}
+
+// replayTransport отдаёт ответы из фикстур, не обращаясь к сети
+type replayTransport struct {
+	store *fixtureStore
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	path := t.store.path(req, reqBody)
+
+	fixture, err := t.store.load(path)
+	if os.IsNotExist(err) {
+		return nil, &FixtureNotFoundError{Method: req.Method, URL: req.URL.String(), Path: path}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.StatusCode, http.StatusText(fixture.StatusCode)),
+		StatusCode:    fixture.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        fixture.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,179 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFixtureSite поднимает сайт, отдающий на каждый путь свой ответ с нестандартными статусом и заголовком
+func newFixtureSite(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("X-Page", r.URL.Path)
+		w.Header().Add("Set-Cookie", "session=abc")
+		w.Header().Add("Set-Cookie", "theme=dark")
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, "<html>"+r.URL.RequestURI()+"</html>")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// listFixtures возвращает файлы фикстур каталога относительно него
+func listFixtures(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("обход фикстур: %v", err)
+	}
+	return files
+}
+
+func roundTrip(t *testing.T, transport http.RoundTripper, url string) (*http.Response, string) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("чтение тела %s: %v", url, err)
+	}
+	return resp, string(body)
+}
+
+func TestFixtureRecordReplay(t *testing.T) {
+	server := newFixtureSite(t)
+	fixtures := &fixtureStore{dir: t.TempDir()}
+	url := server.URL + "/manga/berserk?page=2"
+
+	recorded, recordedBody := roundTrip(t, &recordingTransport{next: http.DefaultTransport, store: fixtures}, url)
+
+	// Повторная запись того же запроса попадает в тот же файл
+	roundTrip(t, &recordingTransport{next: http.DefaultTransport, store: fixtures}, url)
+	files := listFixtures(t, fixtures.dir)
+	if len(files) != 1 {
+		t.Fatalf("фикстуры %v, ожидался один файл", files)
+	}
+	if !strings.HasPrefix(filepath.Base(files[0]), "manga_berserk-") {
+		t.Errorf("файл фикстуры %s не содержит пути запроса", files[0])
+	}
+
+	// Воспроизведение работает без сети
+	server.Close()
+	replayed, replayedBody := roundTrip(t, &replayTransport{store: fixtures}, url)
+
+	if replayed.StatusCode != recorded.StatusCode {
+		t.Errorf("статус %d, записан %d", replayed.StatusCode, recorded.StatusCode)
+	}
+	if replayedBody != recordedBody || replayedBody != "<html>/manga/berserk?page=2</html>" {
+		t.Errorf("тело %q, записано %q", replayedBody, recordedBody)
+	}
+	for _, key := range []string{"Content-Type", "X-Page", "Set-Cookie"} {
+		if got, want := replayed.Header.Values(key), recorded.Header.Values(key); strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("заголовок %s = %v, записан %v", key, got, want)
+		}
+	}
+}
+
+func TestFixturePathStable(t *testing.T) {
+	fixtures := &fixtureStore{dir: "fixtures"}
+
+	path := func(method, url, body string) string {
+		req, _ := http.NewRequest(method, url, nil)
+		return fixtures.path(req, []byte(body))
+	}
+
+	first := path(http.MethodGet, "https://readmanga.example/berserk", "")
+	if got := path(http.MethodGet, "https://readmanga.example/berserk", ""); got != first {
+		t.Errorf("путь фикстуры изменился: %s и %s", first, got)
+	}
+	if filepath.Dir(first) != filepath.Join("fixtures", "readmanga.example") {
+		t.Errorf("фикстура %s вне каталога хоста", first)
+	}
+
+	for _, other := range []string{
+		path(http.MethodGet, "https://readmanga.example/berserk?page=2", ""),
+		path(http.MethodPost, "https://readmanga.example/berserk", ""),
+		path(http.MethodPost, "https://readmanga.example/berserk", "login=reader"),
+	} {
+		if other == first {
+			t.Errorf("разные запросы получили одну фикстуру %s", other)
+		}
+	}
+}
+
+func TestFixtureNotFound(t *testing.T) {
+	f := New(Options{Timeout: 5 * time.Second, Mode: ModeReplay, FixturesDir: t.TempDir()})
+
+	_, err := f.Get(context.Background(), Request{URL: "https://readmanga.example/berserk", Kind: KindPage})
+
+	var notFound *FixtureNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("ожидалась FixtureNotFoundError, получено %v", err)
+	}
+	if notFound.URL != "https://readmanga.example/berserk" || notFound.Method != http.MethodGet {
+		t.Errorf("ошибка %+v не описывает запрос", notFound)
+	}
+	if kind := Classify(err); kind != ErrorOther {
+		t.Errorf("Classify = %s, ожидалось %s", kind, ErrorOther)
+	}
+}
+
+func TestFetcherRecordReplay(t *testing.T) {
+	t.Setenv("FETCH_RATE_DELAY", "0")
+	t.Setenv("FETCH_RETRY_ATTEMPTS", "1")
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/rss+xml")
+		io.WriteString(w, "<rss></rss>")
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	req := Request{URL: server.URL + "/rss/berserk", Kind: KindFeed}
+
+	recorder := New(Options{Timeout: 5 * time.Second, Mode: ModeRecord, FixturesDir: dir})
+	recorded, err := recorder.Get(context.Background(), req)
+	if err != nil {
+		t.Fatalf("запись: %v", err)
+	}
+
+	player := New(Options{Timeout: 5 * time.Second, Mode: ModeReplay, FixturesDir: dir})
+	replayed, err := player.Get(context.Background(), req)
+	if err != nil {
+		t.Fatalf("воспроизведение: %v", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("к сайту было %d запросов, ожидался 1", requests)
+	}
+	if string(replayed.Body) != string(recorded.Body) || replayed.StatusCode != recorded.StatusCode {
+		t.Errorf("воспроизведено %d %q, записано %d %q", replayed.StatusCode, replayed.Body, recorded.StatusCode, recorded.Body)
+	}
+}
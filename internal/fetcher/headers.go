@@ -0,0 +1,67 @@
+package fetcher
+
+import (
+	"net/http"
+)
+
+// Kind тип запрашиваемого ресурса — определяет заголовок Accept
+type Kind int
+
+const (
+	KindPage  Kind = iota // HTML страница
+	KindFeed              // RSS/XML фид
+	KindImage             // Изображение страницы главы
+)
+
+// String имя типа ресурса, как в поле accept профиля браузера
+func (k Kind) String() string {
+	switch k {
+	case KindFeed:
+		return "feed"
+	case KindImage:
+		return "image"
+	default:
+		return "page"
+	}
+}
+
+// accept значения заголовка Accept по умолчанию, если профиль браузера их не задаёт
+var accept = map[Kind]string{
+	KindPage:  "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	KindFeed:  "application/xml,text/xml,application/rss+xml",
+	KindImage: "image/avif,image/webp,image/apng,image/*,*/*;q=0.8",
+}
+
+// buildHeaders возвращает заголовки браузера из профиля для запроса
+func buildHeaders(profile Fingerprint, kind Kind, referer string) http.Header {
+	acceptValue := profile.Accept[kind.String()]
+	if acceptValue == "" {
+		acceptValue = accept[kind]
+	}
+
+	acceptLanguage := profile.AcceptLanguage
+	if acceptLanguage == "" {
+		acceptLanguage = "ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7"
+	}
+
+	header := http.Header{
+		"User-Agent":      {profile.UserAgent},
+		"Accept":          {acceptValue},
+		"Accept-Language": {acceptLanguage},
+		"Accept-Encoding": {acceptEncoding},
+		"Connection":      {"keep-alive"},
+	}
+
+	// Client hints — только если их отправляет браузер профиля
+	if profile.SecCHUA != "" {
+		header.Set("Sec-CH-UA", profile.SecCHUA)
+		header.Set("Sec-CH-UA-Mobile", profile.SecCHUAMobile)
+		header.Set("Sec-CH-UA-Platform", profile.SecCHUAPlatform)
+	}
+
+	if referer != "" {
+		header.Set("Referer", referer+"/")
+	}
+
+	return header
+}
@@ -0,0 +1,323 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// Режимы работы источника с прокси (FETCH_PROXY, FETCH_PROXY_<ИСТОЧНИК>):
+const (
+	ProxyModeDirect   = "direct"   // Без прокси (по умолчанию)
+	ProxyModeRotate   = "rotate"   // Здоровый прокси из пула по кругу, без прокси — если пул пуст
+	ProxyModeRequired = "required" // Только через прокси; если здоровых нет — запрос не выполняется
+	// Любое другое значение — URL закреплённого за источником прокси
+)
+
+// Proxy прокси из пула (http, https или socks5)
+type Proxy struct {
+	URL       *url.URL
+	transport *http.Transport
+
+	mu               sync.Mutex
+	healthy          bool
+	bans             int       // Подряд ответов-банов (403/429)
+	quarantinedUntil time.Time // До какого момента прокси не используется
+	lastError        string
+	lastProbeAt      time.Time
+}
+
+// ProxyStatus состояние прокси для отчётов
+type ProxyStatus struct {
+	URL              string
+	Healthy          bool
+	Bans             int
+	QuarantinedUntil time.Time
+	LastError        string
+	LastProbeAt      time.Time
+}
+
+// ProxyPool пул прокси с проверкой здоровья, ротацией и карантином
+type ProxyPool struct {
+	proxies []*Proxy
+
+	mu   sync.Mutex
+	next int
+
+	probeURL      string
+	probeInterval time.Duration
+	banThreshold  int
+	quarantine    time.Duration
+}
+
+// newProxyPool создаёт пул из списка URL через запятую (FETCH_PROXIES).
+// newTransport — фабрика транспорта, у которого будет настроен прокси.
+func newProxyPool(rawList string, newTransport func() *http.Transport) *ProxyPool {
+	pool := &ProxyPool{
+		probeURL:      envString("FETCH_PROXY_PROBE_URL", "", "https://www.gstatic.com/generate_204"),
+		probeInterval: envDuration("FETCH_PROXY_PROBE_INTERVAL", "", 5*time.Minute),
+		banThreshold:  max(1, envInt("FETCH_PROXY_BAN_THRESHOLD", "", 3)),
+		quarantine:    envDuration("FETCH_PROXY_QUARANTINE", "", 30*time.Minute),
+	}
+
+	for _, raw := range strings.Split(rawList, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		proxy, err := newProxy(raw, newTransport)
+		if err != nil {
+			log.Printf("Прокси %s пропущен: %v", raw, err)
+			continue
+		}
+
+		pool.proxies = append(pool.proxies, proxy)
+	}
+
+	return pool
+}
+
+// newProxy создаёт прокси со своим транспортом (и пулом соединений)
+func newProxy(raw string, newTransport func() *http.Transport) (*Proxy, error) {
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный URL прокси: %w", err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("неподдерживаемая схема прокси: %s", proxyURL.Scheme)
+	}
+
+	transport := newTransport()
+	transport.Proxy = http.ProxyURL(proxyURL)
+
+	// До первой проверки считаем прокси здоровым
+	return &Proxy{URL: proxyURL, transport: transport, healthy: true}, nil
+}
+
+// Len количество прокси в пуле
+func (p *ProxyPool) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.proxies)
+}
+
+// Pick возвращает следующий доступный прокси по кругу или nil, если доступных нет
+func (p *ProxyPool) Pick() *Proxy {
+	if p.Len() == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for range p.proxies {
+		proxy := p.proxies[p.next%len(p.proxies)]
+		p.next++
+
+		if proxy.available() {
+			return proxy
+		}
+	}
+
+	return nil
+}
+
+// Find возвращает прокси пула по URL или nil
+func (p *ProxyPool) Find(rawURL string) *Proxy {
+	if p == nil {
+		return nil
+	}
+
+	for _, proxy := range p.proxies {
+		if proxy.URL.String() == rawURL {
+			return proxy
+		}
+	}
+	return nil
+}
+
+// Statuses состояние всех прокси пула
+func (p *ProxyPool) Statuses() []ProxyStatus {
+	if p == nil {
+		return nil
+	}
+
+	statuses := make([]ProxyStatus, 0, len(p.proxies))
+	for _, proxy := range p.proxies {
+		statuses = append(statuses, proxy.status())
+	}
+	return statuses
+}
+
+// ReportResult учитывает результат запроса через прокси: бан (403/429, страница проверки) или успех
+func (p *ProxyPool) ReportResult(proxy *Proxy, banned bool) {
+	if proxy == nil {
+		return
+	}
+
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+
+	if !banned {
+		proxy.bans = 0
+		return
+	}
+
+	proxy.bans++
+	if proxy.bans >= p.banThreshold {
+		proxy.quarantinedUntil = time.Now().Add(p.quarantine)
+		proxy.bans = 0
+		log.Printf("Прокси %s отправлен в карантин до %s: источник банит запросы", proxy.Redacted(), proxy.quarantinedUntil.Format("15:04"))
+	}
+}
+
+// ReportFailure учитывает сетевую ошибку запроса через прокси (отказ в соединении, обрыв, таймаут).
+// Прокси считается недоступным до следующей успешной проверки здоровья; счётчик банов не меняется.
+func (p *ProxyPool) ReportFailure(proxy *Proxy, err error) {
+	if proxy == nil {
+		return
+	}
+
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+
+	proxy.lastError = err.Error()
+	if proxy.healthy {
+		proxy.healthy = false
+		log.Printf("Прокси %s недоступен: %v", proxy.Redacted(), err)
+	}
+}
+
+// StartHealthChecks периодически проверяет все прокси запросом к FETCH_PROXY_PROBE_URL
+func (p *ProxyPool) StartHealthChecks(ctx context.Context) {
+	if p.Len() == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.probeInterval)
+		defer ticker.Stop()
+
+		for {
+			p.probeAll(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// probeAll проверяет каждый прокси одним запросом
+func (p *ProxyPool) probeAll(ctx context.Context) {
+	for _, proxy := range p.proxies {
+		err := proxy.probe(ctx, p.probeURL)
+
+		proxy.mu.Lock()
+		wasHealthy := proxy.healthy
+		proxy.healthy = err == nil
+		proxy.lastProbeAt = time.Now()
+		if err != nil {
+			proxy.lastError = err.Error()
+		}
+		proxy.mu.Unlock()
+
+		if wasHealthy && err != nil {
+			log.Printf("Прокси %s недоступен: %v", proxy.Redacted(), err)
+		} else if !wasHealthy && err == nil {
+			log.Printf("Прокси %s снова доступен", proxy.Redacted())
+		}
+	}
+}
+
+// probe выполняет проверочный запрос через прокси
+func (proxy *Proxy) probe(ctx context.Context, probeURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := proxy.transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("статус проверки: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// available здоров и не в карантине
+func (proxy *Proxy) available() bool {
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+
+	return proxy.healthy && time.Now().After(proxy.quarantinedUntil)
+}
+
+// status снимок состояния прокси
+func (proxy *Proxy) status() ProxyStatus {
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+
+	return ProxyStatus{
+		URL:              proxy.Redacted(),
+		Healthy:          proxy.healthy,
+		Bans:             proxy.bans,
+		QuarantinedUntil: proxy.quarantinedUntil,
+		LastError:        proxy.lastError,
+		LastProbeAt:      proxy.lastProbeAt,
+	}
+}
+
+// Redacted URL прокси без пароля (для логов)
+func (proxy *Proxy) Redacted() string {
+	return proxy.URL.Redacted()
+}
+
+// selectProxy выбирает прокси для запроса к источнику согласно FETCH_PROXY[_<ИСТОЧНИК>].
+// Возвращает nil, если запрос идёт напрямую.
+func (f *Fetcher) selectProxy(source types.SourceName) (*Proxy, error) {
+	mode := envString("FETCH_PROXY", source, ProxyModeDirect)
+
+	switch mode {
+	case ProxyModeDirect:
+		return nil, nil
+	case ProxyModeRotate:
+		return f.proxies.Pick(), nil
+	case ProxyModeRequired:
+		if proxy := f.proxies.Pick(); proxy != nil {
+			return proxy, nil
+		}
+		return nil, fmt.Errorf("нет доступных прокси для %s", source)
+	}
+
+	// Закреплённый прокси: берём из пула, чтобы учитывать его здоровье и баны
+	proxy := f.proxies.Find(mode)
+	if proxy == nil {
+		return nil, fmt.Errorf("закреплённый прокси %s для %s отсутствует в FETCH_PROXIES", mode, source)
+	}
+	if !proxy.available() {
+		return nil, fmt.Errorf("закреплённый прокси %s для %s недоступен", proxy.Redacted(), source)
+	}
+	return proxy, nil
+}
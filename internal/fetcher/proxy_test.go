@@ -0,0 +1,225 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// testProxy HTTP-прокси, который сам отвечает на запросы и запоминает их число
+type testProxy struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	requests int
+	status   int  // Статус ответа (0 — 200)
+	truncate bool // Оборвать тело ответа
+}
+
+func newTestProxy(t *testing.T) *testProxy {
+	t.Helper()
+
+	p := &testProxy{}
+	p.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.requests++
+		status, truncate := p.status, p.truncate
+		p.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("X-Proxy", p.server.URL)
+		if truncate {
+			// Объявленная длина больше отданной — клиент получит обрыв при чтении тела
+			w.Header().Set("Content-Length", "1000")
+			io.WriteString(w, "<html>")
+			return
+		}
+		if status != 0 {
+			w.WriteHeader(status)
+		}
+		io.WriteString(w, "<html>"+r.URL.String()+"</html>")
+	}))
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *testProxy) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.requests
+}
+
+func (p *testProxy) set(status int, truncate bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status, p.truncate = status, truncate
+}
+
+// newProxyFetcher создаёт фетчер с пулом из указанных прокси. Пул создаётся без фоновых
+// проверок здоровья, чтобы они не меняли состояние прокси во время теста.
+func newProxyFetcher(t *testing.T, proxies ...string) *Fetcher {
+	t.Helper()
+	t.Setenv("FETCH_RATE_DELAY", "0")
+	t.Setenv("FETCH_RETRY_ATTEMPTS", "1")
+	t.Setenv("FETCH_PROXY_BAN_THRESHOLD", "2")
+	t.Setenv("FETCH_PROXY_QUARANTINE", "1h")
+
+	f := New(Options{Timeout: 5 * time.Second})
+	f.proxies = newProxyPool(strings.Join(proxies, ","), newTransport)
+	return f
+}
+
+// fetchVia выполняет запрос к источнику и возвращает прокси, который его обслужил
+func fetchVia(f *Fetcher, source types.SourceName) (string, error) {
+	resp, err := f.Get(context.Background(), Request{Source: source, URL: "http://readmanga.example/berserk", Kind: KindPage})
+	if resp == nil {
+		return "", err
+	}
+	return resp.Header.Get("X-Proxy"), err
+}
+
+func proxyStatus(f *Fetcher, proxy *testProxy) ProxyStatus {
+	return f.proxies.Find(proxy.server.URL).status()
+}
+
+func TestProxyRotation(t *testing.T) {
+	a, b := newTestProxy(t), newTestProxy(t)
+	f := newProxyFetcher(t, a.server.URL, b.server.URL)
+	t.Setenv("FETCH_PROXY", ProxyModeRotate)
+
+	var got []string
+	for range 4 {
+		via, err := fetchVia(f, types.SourceReadmanga)
+		if err != nil {
+			t.Fatalf("запрос: %v", err)
+		}
+		got = append(got, via)
+	}
+
+	want := []string{a.server.URL, b.server.URL, a.server.URL, b.server.URL}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("прокси по порядку %v, ожидалось %v", got, want)
+	}
+}
+
+func TestProxyNetworkFailure(t *testing.T) {
+	a := newTestProxy(t)
+	f := newProxyFetcher(t, a.server.URL)
+	t.Setenv("FETCH_PROXY", ProxyModeRotate)
+
+	a.server.Close()
+	if _, err := fetchVia(f, types.SourceReadmanga); err == nil {
+		t.Fatal("запрос через остановленный прокси прошёл")
+	}
+
+	// Прокси выпадает из ротации до следующей успешной проверки здоровья
+	if status := proxyStatus(f, a); status.Healthy || status.LastError == "" {
+		t.Errorf("прокси после сетевой ошибки: %+v, ожидался недоступным", status)
+	}
+	if proxy := f.proxies.Pick(); proxy != nil {
+		t.Errorf("Pick вернул недоступный прокси %s", proxy.Redacted())
+	}
+}
+
+func TestProxyPinned(t *testing.T) {
+	a, b := newTestProxy(t), newTestProxy(t)
+	f := newProxyFetcher(t, a.server.URL, b.server.URL)
+	t.Setenv("FETCH_PROXY", ProxyModeRotate)
+	t.Setenv("FETCH_PROXY_READMANGA", b.server.URL)
+
+	for range 3 {
+		via, err := fetchVia(f, types.SourceReadmanga)
+		if err != nil {
+			t.Fatalf("запрос: %v", err)
+		}
+		if via != b.server.URL {
+			t.Errorf("запрос прошёл через %s, ожидался закреплённый %s", via, b.server.URL)
+		}
+	}
+
+	// Настройка источника не влияет на остальные
+	if via, _ := fetchVia(f, types.SourceMintmanga); via != a.server.URL {
+		t.Errorf("mintmanga прошла через %s, ожидалась ротация с %s", via, a.server.URL)
+	}
+
+	t.Setenv("FETCH_PROXY_READMANGA", "http://10.255.0.1:3128")
+	if _, err := fetchVia(f, types.SourceReadmanga); err == nil || !strings.Contains(err.Error(), "отсутствует в FETCH_PROXIES") {
+		t.Errorf("прокси вне пула: %v", err)
+	}
+}
+
+func TestProxyRequired(t *testing.T) {
+	a := newTestProxy(t)
+	f := newProxyFetcher(t, a.server.URL)
+	t.Setenv("FETCH_PROXY", ProxyModeRequired)
+
+	if via, err := fetchVia(f, types.SourceReadmanga); err != nil || via != a.server.URL {
+		t.Fatalf("запрос через %q: %v", via, err)
+	}
+
+	f.proxies.ReportFailure(f.proxies.Find(a.server.URL), io.ErrUnexpectedEOF)
+
+	_, err := fetchVia(f, types.SourceReadmanga)
+	if err == nil || !strings.Contains(err.Error(), "нет доступных прокси") {
+		t.Errorf("без здоровых прокси: %v, ожидался отказ", err)
+	}
+	if requests := a.count(); requests != 1 {
+		t.Errorf("через прокси прошло %d запросов, ожидался 1", requests)
+	}
+}
+
+func TestProxyQuarantine(t *testing.T) {
+	a, b := newTestProxy(t), newTestProxy(t)
+	f := newProxyFetcher(t, a.server.URL, b.server.URL)
+	t.Setenv("FETCH_PROXY", ProxyModeRotate)
+	a.set(http.StatusForbidden, false)
+
+	// a банится дважды подряд (FETCH_PROXY_BAN_THRESHOLD=2) и уходит в карантин
+	for range 4 {
+		fetchVia(f, types.SourceReadmanga)
+	}
+
+	status := proxyStatus(f, a)
+	if !time.Now().Before(status.QuarantinedUntil) {
+		t.Fatalf("прокси не в карантине: %+v", status)
+	}
+	if status.Bans != 0 {
+		t.Errorf("после карантина осталось %d банов", status.Bans)
+	}
+
+	for range 3 {
+		if via, err := fetchVia(f, types.SourceReadmanga); err != nil || via != b.server.URL {
+			t.Errorf("запрос через %q (%v), ожидался %s", via, err, b.server.URL)
+		}
+	}
+}
+
+func TestProxyBansSurviveReadError(t *testing.T) {
+	a := newTestProxy(t)
+	f := newProxyFetcher(t, a.server.URL)
+	t.Setenv("FETCH_PROXY", ProxyModeRequired)
+
+	a.set(http.StatusTooManyRequests, false)
+	fetchVia(f, types.SourceReadmanga)
+
+	a.set(0, true)
+	if _, err := fetchVia(f, types.SourceReadmanga); err == nil || !strings.Contains(err.Error(), "ошибка чтения ответа") {
+		t.Fatalf("ожидалась ошибка чтения ответа, получено %v", err)
+	}
+
+	// Оборванный ответ — не успех: бан не сбрасывается, прокси отмечен недоступным
+	status := proxyStatus(f, a)
+	if status.Bans != 1 {
+		t.Errorf("банов %d, ожидался 1", status.Bans)
+	}
+	if status.Healthy {
+		t.Error("прокси с оборванным ответом остался здоровым")
+	}
+}
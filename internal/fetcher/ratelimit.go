@@ -0,0 +1,46 @@
+package fetcher
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultRateDelay минимальная пауза между запросами страниц и фидов к одному хосту.
+// На проверку манги уходит два запроса (страница и RSS), что сохраняет прежний темп в 4 секунды на мангу.
+const defaultRateDelay = 2 * time.Second
+
+// rateLimiter выдерживает паузу между запросами к одному хосту
+type rateLimiter struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+// wait резервирует слот для запроса к хосту и ждёт его наступления.
+// Параллельные запросы к одному хосту выстраиваются в очередь с интервалом delay.
+func (l *rateLimiter) wait(ctx context.Context, host string, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(delay)
+	l.mu.Unlock()
+
+	return sleep(ctx, time.Until(slot))
+}
+
+// rateDelay пауза перед запросом: FETCH_RATE_DELAY для страниц и фидов
+// (картинки глав ограничивает загрузчик), но не меньше Crawl-delay из robots.txt
+func rateDelay(req Request, crawlDelay time.Duration) time.Duration {
+	var delay time.Duration
+	if req.Kind != KindImage {
+		delay = envDuration("FETCH_RATE_DELAY", req.Source, defaultRateDelay)
+	}
+	return max(delay, crawlDelay)
+}
@@ -0,0 +1,135 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// RetryPolicy политика повторов запросов к источнику
+type RetryPolicy struct {
+	MaxAttempts   int           // Всего попыток, включая первую
+	BaseDelay     time.Duration // Задержка перед первым повтором, далее удваивается
+	MaxDelay      time.Duration // Максимальная задержка между попытками
+	Jitter        float64       // Доля случайного разброса задержки (0..1)
+	MaxRetryAfter time.Duration // Если сервер просит ждать дольше — не повторяем в этом цикле
+}
+
+// RetryPolicyFor возвращает политику повторов для источника.
+// Переменные: FETCH_RETRY_ATTEMPTS, FETCH_RETRY_BASE_DELAY, FETCH_RETRY_MAX_DELAY,
+// FETCH_RETRY_JITTER, FETCH_RETRY_MAX_RETRY_AFTER (с суффиксом _<ИСТОЧНИК> для переопределения).
+func RetryPolicyFor(source types.SourceName) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:   max(1, envInt("FETCH_RETRY_ATTEMPTS", source, 3)),
+		BaseDelay:     envDuration("FETCH_RETRY_BASE_DELAY", source, 2*time.Second),
+		MaxDelay:      envDuration("FETCH_RETRY_MAX_DELAY", source, 30*time.Second),
+		Jitter:        min(1, max(0, envFloat("FETCH_RETRY_JITTER", source, 0.5))),
+		MaxRetryAfter: envDuration("FETCH_RETRY_MAX_RETRY_AFTER", source, 2*time.Minute),
+	}
+}
+
+// Delay возвращает задержку перед повтором после попытки attempt (с 1).
+// retryAfter — пожелание сервера из заголовка Retry-After (0 — нет).
+// Возвращает false, если сервер просит ждать дольше MaxRetryAfter.
+func (p RetryPolicy) Delay(attempt int, retryAfter time.Duration) (time.Duration, bool) {
+	if retryAfter > p.MaxRetryAfter {
+		return 0, false
+	}
+
+	delay := p.BaseDelay << (attempt - 1)
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+
+	// Разброс ±Jitter/2, чтобы повторы разных манг не приходили одновременно
+	if p.Jitter > 0 {
+		spread := float64(delay) * p.Jitter
+		delay += time.Duration(spread*rand.Float64() - spread/2)
+	}
+
+	return max(delay, retryAfter), true
+}
+
+// IsRetryable классифицирует ошибку: true — временная (таймаут, обрыв соединения,
+// 408/425/429/5xx шлюза), false — постоянная (404, 403, ошибка в URL, отмена контекста).
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+			http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout, 520, 521, 522, 523, 524: // 52x — ошибки origin за Cloudflare
+			return true
+		}
+		return false
+	}
+
+	var bodyErr *BodyTooLargeError
+	if errors.As(err, &bodyErr) {
+		return false
+	}
+
+	var fixtureErr *FixtureNotFoundError
+	if errors.As(err, &fixtureErr) {
+		return false
+	}
+
+	var robotsErr *RobotsDisallowedError
+	if errors.As(err, &robotsErr) {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	// Сетевые ошибки (таймауты, сброс соединения, DNS) считаем временными
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// parseRetryAfter разбирает заголовок Retry-After: секунды или HTTP дата
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// sleep ждёт d или отмены контекста
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
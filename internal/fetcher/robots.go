@@ -0,0 +1,279 @@
+package fetcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+const (
+	// defaultRobotsTTL сколько хранится загруженный robots.txt
+	defaultRobotsTTL = 24 * time.Hour
+	// robotsRetryTTL через сколько повторить загрузку robots.txt после ошибки
+	robotsRetryTTL = time.Hour
+	// robotsMaxSize robots.txt больше 500 КиБ обрезается (RFC 9309)
+	robotsMaxSize = 500 << 10
+)
+
+// RobotsDisallowedError путь запрещён robots.txt источника
+type RobotsDisallowedError struct {
+	URL string
+}
+
+func (e *RobotsDisallowedError) Error() string {
+	return fmt.Sprintf("%s запрещён robots.txt", e.URL)
+}
+
+// robotsRule правило Allow/Disallow
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsGroup правила для группы User-agent
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// Robots разобранный robots.txt для нашего агента
+type Robots struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// Allowed проверяет путь (с query) по правилам: выигрывает самое длинное совпадение,
+// при равной длине — Allow
+func (r *Robots) Allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+
+	allowed := true
+	matched := -1
+
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > matched || (len(rule.pattern) == matched && rule.allow) {
+			allowed = rule.allow
+			matched = len(rule.pattern)
+		}
+	}
+
+	return allowed
+}
+
+// CrawlDelay пауза между запросами из Crawl-delay
+func (r *Robots) CrawlDelay() time.Duration {
+	if r == nil {
+		return 0
+	}
+	return r.crawlDelay
+}
+
+// robotsMatch сопоставляет путь с шаблоном robots.txt: * — любая последовательность, $ — конец пути
+func robotsMatch(pattern, path string) bool {
+	if pattern == "" {
+		return false
+	}
+
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+
+	for i, part := range parts[1:] {
+		// Последний кусок при $ должен совпасть с концом пути
+		if anchored && i == len(parts)-2 {
+			return len(path)-pos >= len(part) && strings.HasSuffix(path, part)
+		}
+
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+
+	return !anchored || pos == len(path)
+}
+
+// parseRobots разбирает robots.txt и выбирает группу для агента:
+// группу с совпадающим именем, иначе группу «*»
+func parseRobots(body []byte, agent string) *Robots {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || !lastWasAgent {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+			continue
+		case "allow", "disallow":
+			// Пустой Disallow ничего не запрещает
+			if current != nil && value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if current != nil {
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					current.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+
+		lastWasAgent = false
+	}
+
+	agent = strings.ToLower(agent)
+
+	var fallback *robotsGroup
+	for _, group := range groups {
+		for _, name := range group.agents {
+			if name == "*" {
+				if fallback == nil {
+					fallback = group
+				}
+			} else if agent != "" && strings.Contains(agent, name) {
+				return &Robots{rules: group.rules, crawlDelay: group.crawlDelay}
+			}
+		}
+	}
+
+	if fallback == nil {
+		return &Robots{}
+	}
+	return &Robots{rules: fallback.rules, crawlDelay: fallback.crawlDelay}
+}
+
+// robotsEntry закешированный robots.txt хоста
+type robotsEntry struct {
+	robots    *Robots
+	expiresAt time.Time
+}
+
+// robotsCache кеш robots.txt по хостам
+type robotsCache struct {
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+}
+
+// disallowAll правила, запрещающие весь сайт
+func disallowAll() *Robots {
+	return &Robots{rules: []robotsRule{{allow: false, pattern: "/"}}}
+}
+
+// robotsFor возвращает правила robots.txt хоста, загружая их при необходимости.
+// 4xx означает отсутствие ограничений, от файла больше 500 КиБ разбирается начало.
+// При сетевой ошибке или 5xx используется прошлая копия, а загрузка повторяется через час;
+// если копии нет, после 5xx сайт до повтора считается закрытым целиком (RFC 9309).
+func (f *Fetcher) robotsFor(ctx context.Context, source types.SourceName, target *url.URL) *Robots {
+	origin := target.Scheme + "://" + target.Host
+
+	f.robots.mu.Lock()
+	entry, ok := f.robots.entries[origin]
+	f.robots.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.robots
+	}
+
+	robotsURL := origin + "/robots.txt"
+	agent := envString("FETCH_ROBOTS_AGENT", source, "*")
+
+	var robots *Robots
+	ttl := envDuration("FETCH_ROBOTS_TTL", source, defaultRobotsTTL)
+
+	resp, err := f.do(ctx, Request{Source: source, URL: robotsURL, Kind: KindPage, MaxBodySize: robotsMaxSize, TruncateBody: true}, nil)
+
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		body := resp.Body
+		if len(body) >= robotsMaxSize {
+			// Последняя строка обрезана посередине — отбрасываем её
+			if i := bytes.LastIndexByte(body, '\n'); i >= 0 {
+				body = body[:i]
+			}
+			log.Printf("%s больше %d КиБ, разбирается только начало", robotsURL, robotsMaxSize>>10)
+		}
+		robots = parseRobots(body, agent)
+		log.Printf("Загружен %s (Crawl-delay: %v)", robotsURL, robots.CrawlDelay())
+	case errors.As(err, &statusErr) && statusErr.StatusCode >= 400 && statusErr.StatusCode < 500:
+		robots = &Robots{}
+	default:
+		log.Printf("Ошибка загрузки %s: %v", robotsURL, err)
+		if ok {
+			robots = entry.robots
+		} else if errors.As(err, &statusErr) && statusErr.StatusCode >= 500 {
+			robots = disallowAll()
+		}
+		ttl = robotsRetryTTL
+	}
+
+	f.robots.mu.Lock()
+	f.robots.entries[origin] = &robotsEntry{robots: robots, expiresAt: time.Now().Add(ttl)}
+	f.robots.mu.Unlock()
+
+	return robots
+}
+
+// checkRobots возвращает ошибку, если robots.txt запрещает URL, и паузу из Crawl-delay
+func (f *Fetcher) checkRobots(ctx context.Context, req Request, target *url.URL) (time.Duration, error) {
+	if !req.RespectRobots {
+		return 0, nil
+	}
+
+	robots := f.robotsFor(ctx, req.Source, target)
+
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+
+	if !robots.Allowed(path) {
+		return 0, &RobotsDisallowedError{URL: req.URL}
+	}
+
+	return robots.CrawlDelay(), nil
+}
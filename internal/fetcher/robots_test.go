@@ -0,0 +1,105 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRobotsSite поднимает сайт с заданным ответом на /robots.txt; остальные пути отдают 200
+func newRobotsSite(t *testing.T, status int, robots string) (*Fetcher, *httptest.Server) {
+	t.Helper()
+	t.Setenv("FETCH_RATE_DELAY", "0")
+	t.Setenv("FETCH_RETRY_ATTEMPTS", "1")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(status)
+			w.Write([]byte(robots))
+			return
+		}
+		w.Write([]byte("<html></html>"))
+	}))
+	t.Cleanup(server.Close)
+
+	return New(Options{Timeout: 5 * time.Second}), server
+}
+
+// getRespectingRobots запрашивает путь сайта с проверкой robots.txt
+func getRespectingRobots(f *Fetcher, server *httptest.Server, path string) error {
+	_, err := f.Get(context.Background(), Request{URL: server.URL + path, Kind: KindPage, RespectRobots: true})
+	return err
+}
+
+func TestRobotsAllowed(t *testing.T) {
+	robots := parseRobots([]byte(`
+User-agent: *
+Disallow: /private
+Allow: /private/public$
+Disallow: /*?search=
+
+User-agent: mangabot
+Disallow: /
+`), "Mozilla/5.0")
+
+	tests := map[string]bool{
+		"/manga/berserk":       true,
+		"/private":             false,
+		"/private/list":        false,
+		"/private/public":      true,
+		"/private/public/more": false,
+		"/list?search=berserk": false,
+	}
+
+	for path, want := range tests {
+		if got := robots.Allowed(path); got != want {
+			t.Errorf("Allowed(%q) = %v, ожидалось %v", path, got, want)
+		}
+	}
+}
+
+func TestRobotsTruncated(t *testing.T) {
+	// Правило в начале файла должно действовать, даже если файл больше 500 КиБ
+	body := "User-agent: *\nDisallow: /private\n" +
+		strings.Repeat("# комментарий\n", robotsMaxSize/10) +
+		"Disallow: /manga\n"
+
+	f, server := newRobotsSite(t, http.StatusOK, body)
+
+	var disallowed *RobotsDisallowedError
+	if err := getRespectingRobots(f, server, "/private/1"); !errors.As(err, &disallowed) {
+		t.Errorf("ожидалась RobotsDisallowedError, получено %v", err)
+	}
+	if err := getRespectingRobots(f, server, "/manga/berserk"); err != nil {
+		t.Errorf("правило за пределами 500 КиБ применилось: %v", err)
+	}
+}
+
+func TestRobotsStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		allowed bool
+	}{
+		{"4xx — без ограничений", http.StatusNotFound, true},
+		{"5xx — сайт закрыт до повтора", http.StatusInternalServerError, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, server := newRobotsSite(t, tt.status, "")
+
+			err := getRespectingRobots(f, server, "/manga/berserk")
+
+			var disallowed *RobotsDisallowedError
+			if got := !errors.As(err, &disallowed); got != tt.allowed {
+				t.Errorf("разрешено %v, ожидалось %v (ошибка: %v)", got, tt.allowed, err)
+			}
+		})
+	}
+}
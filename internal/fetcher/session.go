@@ -0,0 +1,401 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// SessionStore хранилище зашифрованных cookie источников (реализуется в db)
+type SessionStore interface {
+	LoadSourceSession(source types.SourceName) ([]byte, error)
+	SaveSourceSession(source types.SourceName, data []byte) error
+}
+
+// storedCookie cookie в сохраняемом виде
+type storedCookie struct {
+	Scheme   string    `json:"scheme"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Expires  time.Time `json:"expires,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"http_only,omitempty"`
+}
+
+// sessionJar cookie jar источника. Стандартный cookiejar не умеет отдавать
+// все cookie для сохранения, поэтому установленные cookie дублируются в entries.
+type sessionJar struct {
+	jar *cookiejar.Jar
+
+	mu      sync.Mutex
+	entries map[string]storedCookie
+	dirty   bool
+}
+
+func newSessionJar() *sessionJar {
+	jar, _ := cookiejar.New(nil)
+	return &sessionJar{jar: jar, entries: make(map[string]storedCookie)}
+}
+
+// SetCookies реализует http.CookieJar
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, c := range cookies {
+		domain := strings.TrimPrefix(c.Domain, ".")
+		if domain == "" {
+			domain = u.Hostname()
+		}
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+
+		key := domain + path + "|" + c.Name
+
+		expires := c.Expires
+		if c.MaxAge > 0 {
+			expires = time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+		}
+		if c.MaxAge < 0 || (!expires.IsZero() && expires.Before(time.Now())) {
+			delete(j.entries, key)
+			j.dirty = true
+			continue
+		}
+
+		j.entries[key] = storedCookie{
+			Scheme:   u.Scheme,
+			Domain:   domain,
+			Path:     path,
+			Name:     c.Name,
+			Value:    c.Value,
+			Expires:  expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+		j.dirty = true
+	}
+}
+
+// Cookies реализует http.CookieJar
+func (j *sessionJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// restore загружает сохранённые cookie в jar
+func (j *sessionJar) restore(cookies []storedCookie) {
+	for _, c := range cookies {
+		if !c.Expires.IsZero() && c.Expires.Before(time.Now()) {
+			continue
+		}
+
+		u := &url.URL{Scheme: c.Scheme, Host: c.Domain, Path: c.Path}
+		j.SetCookies(u, []*http.Cookie{{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}})
+	}
+
+	j.mu.Lock()
+	j.dirty = false
+	j.mu.Unlock()
+}
+
+// snapshot возвращает cookie для сохранения, если они менялись
+func (j *sessionJar) snapshot() ([]storedCookie, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.dirty {
+		return nil, false
+	}
+	j.dirty = false
+
+	cookies := make([]storedCookie, 0, len(j.entries))
+	for _, c := range j.entries {
+		cookies = append(cookies, c)
+	}
+	return cookies, true
+}
+
+// clear удаляет все cookie (перед повторным логином)
+func (j *sessionJar) clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.jar, _ = cookiejar.New(nil)
+	j.entries = make(map[string]storedCookie)
+	j.dirty = true
+}
+
+// loginConfig настройки логина источника (FETCH_LOGIN_*_<ИСТОЧНИК>)
+type loginConfig struct {
+	URL           string // URL формы логина (POST)
+	Username      string
+	Password      string
+	UsernameField string // Имя поля логина в форме
+	PasswordField string // Имя поля пароля в форме
+	ExpiredMarker string // Подстрока в теле страницы, означающая что сессия истекла
+}
+
+// loginConfigFor возвращает настройки логина источника или nil, если логин не настроен
+func loginConfigFor(source types.SourceName) *loginConfig {
+	if source == "" {
+		return nil
+	}
+
+	cfg := loginConfig{
+		URL:           os.Getenv(envKey("FETCH_LOGIN_URL", source)),
+		Username:      os.Getenv(envKey("FETCH_LOGIN_USERNAME", source)),
+		Password:      os.Getenv(envKey("FETCH_LOGIN_PASSWORD", source)),
+		UsernameField: envString("FETCH_LOGIN_USERNAME_FIELD", source, "username"),
+		PasswordField: envString("FETCH_LOGIN_PASSWORD_FIELD", source, "password"),
+		ExpiredMarker: os.Getenv(envKey("FETCH_LOGIN_EXPIRED_MARKER", source)),
+	}
+
+	if cfg.URL == "" || cfg.Username == "" || cfg.Password == "" {
+		return nil
+	}
+	return &cfg
+}
+
+// SetSessionStore подключает хранилище сессий и загружает сохранённые cookie
+func (f *Fetcher) SetSessionStore(store SessionStore) {
+	f.sessionMu.Lock()
+	f.sessionStore = store
+	f.sessionMu.Unlock()
+}
+
+// sessionKey ключ шифрования cookie из SESSION_SECRET (nil — сохранение отключено)
+func sessionKey() []byte {
+	secret := os.Getenv("SESSION_SECRET")
+	if secret == "" {
+		return nil
+	}
+	key := sha256.Sum256([]byte(secret))
+	return key[:]
+}
+
+// jarFor возвращает cookie jar источника, при первом обращении загружая сохранённую сессию
+// и статические cookie из FETCH_COOKIES_<ИСТОЧНИК> ("name=value; name2=value2").
+func (f *Fetcher) jarFor(source types.SourceName, referer string) *sessionJar {
+	if source == "" {
+		return nil
+	}
+
+	f.sessionMu.Lock()
+	defer f.sessionMu.Unlock()
+
+	if jar, exists := f.jars[source]; exists {
+		return jar
+	}
+
+	jar := newSessionJar()
+	f.jars[source] = jar
+
+	if f.sessionStore != nil {
+		if cookies, err := f.loadSession(source); err != nil {
+			log.Printf("Ошибка загрузки сессии %s: %v", source, err)
+		} else if len(cookies) > 0 {
+			jar.restore(cookies)
+			log.Printf("Загружена сессия %s: %d cookie", source, len(cookies))
+		}
+	}
+
+	if static := os.Getenv(envKey("FETCH_COOKIES", source)); static != "" && referer != "" {
+		if base, err := url.Parse(referer); err == nil {
+			header := http.Header{"Cookie": {static}}
+			jar.SetCookies(base, (&http.Request{Header: header}).Cookies())
+		}
+	}
+
+	return jar
+}
+
+// loadSession читает и расшифровывает сохранённые cookie источника
+func (f *Fetcher) loadSession(source types.SourceName) ([]storedCookie, error) {
+	key := sessionKey()
+	if key == nil {
+		return nil, nil
+	}
+
+	data, err := f.sessionStore.LoadSourceSession(source)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+
+	plain, err := decrypt(key, data)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка расшифровки cookie (сменился SESSION_SECRET?): %w", err)
+	}
+
+	var cookies []storedCookie
+	if err := json.Unmarshal(plain, &cookies); err != nil {
+		return nil, fmt.Errorf("ошибка разбора cookie: %w", err)
+	}
+
+	return cookies, nil
+}
+
+// persistSession сохраняет cookie источника, если они изменились
+func (f *Fetcher) persistSession(source types.SourceName, jar *sessionJar) {
+	f.sessionMu.Lock()
+	store := f.sessionStore
+	f.sessionMu.Unlock()
+
+	key := sessionKey()
+	if store == nil || key == nil || jar == nil {
+		return
+	}
+
+	cookies, changed := jar.snapshot()
+	if !changed {
+		return
+	}
+
+	plain, err := json.Marshal(cookies)
+	if err != nil {
+		log.Printf("Ошибка сериализации cookie %s: %v", source, err)
+		return
+	}
+
+	data, err := encrypt(key, plain)
+	if err != nil {
+		log.Printf("Ошибка шифрования cookie %s: %v", source, err)
+		return
+	}
+
+	if err := store.SaveSourceSession(source, data); err != nil {
+		log.Printf("Ошибка сохранения сессии %s: %v", source, err)
+	}
+}
+
+// sessionExpired проверяет, что ответ означает истёкшую сессию:
+// 401, редирект на форму логина или маркер FETCH_LOGIN_EXPIRED_MARKER в теле
+func sessionExpired(cfg *loginConfig, resp *Response) bool {
+	if cfg == nil || resp == nil {
+		return false
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+
+	if loginURL, err := url.Parse(cfg.URL); err == nil {
+		if finalURL, err := url.Parse(resp.URL); err == nil && finalURL.Path == loginURL.Path {
+			return true
+		}
+	}
+
+	return cfg.ExpiredMarker != "" && strings.Contains(string(resp.Body), cfg.ExpiredMarker)
+}
+
+// login выполняет вход на источник по данным из конфигурации
+func (f *Fetcher) login(ctx context.Context, source types.SourceName, cfg *loginConfig, jar *sessionJar, referer string) error {
+	log.Printf("Вход на %s...", source)
+
+	jar.clear()
+
+	form := url.Values{
+		cfg.UsernameField: {cfg.Username},
+		cfg.PasswordField: {cfg.Password},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("ошибка создания запроса логина: %w", err)
+	}
+
+	req.Header = buildHeaders(f.fingerprints.For(source), KindPage, referer)
+	req.Header.Del("Accept-Encoding")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client, _, err := f.clientFor(source, jar)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса логина: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("логин отклонён: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	if len(jar.Cookies(req.URL)) == 0 {
+		return fmt.Errorf("логин не вернул cookie сессии")
+	}
+
+	f.persistSession(source, jar)
+	log.Printf("Вход на %s выполнен", source)
+	return nil
+}
+
+// encrypt шифрует данные AES-GCM (nonce в начале результата)
+func encrypt(key, plain []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+// decrypt расшифровывает данные, зашифрованные encrypt
+func decrypt(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("слишком короткие данные")
+	}
+
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, nil)
+}
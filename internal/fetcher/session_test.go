@@ -0,0 +1,275 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+func TestSessionEncryptDecrypt(t *testing.T) {
+	key := sha256.Sum256([]byte("secret"))
+	other := sha256.Sum256([]byte("other"))
+	plain := []byte(`[{"name":"session","value":"abc"}]`)
+
+	first, err := encrypt(key[:], plain)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	second, err := encrypt(key[:], plain)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	// Каждый раз новый nonce: одинаковые cookie не дают одинаковых данных в БД
+	if bytes.Equal(first, second) {
+		t.Error("два шифрования одних данных совпали")
+	}
+	if bytes.Contains(first, []byte("abc")) {
+		t.Error("зашифрованные данные содержат значение cookie")
+	}
+
+	for _, data := range [][]byte{first, second} {
+		got, err := decrypt(key[:], data)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("decrypt = %q, %v, ожидалось %q", got, err, plain)
+		}
+	}
+
+	tampered := bytes.Clone(first)
+	tampered[len(tampered)-1] ^= 1
+
+	for name, tt := range map[string]struct {
+		key  []byte
+		data []byte
+	}{
+		"другой ключ":    {other[:], first},
+		"изменённые":     {key[:], tampered},
+		"короткие":       {key[:], first[:5]},
+		"пустые":         {key[:], nil},
+		"ключ не по AES": {[]byte("short"), first},
+	} {
+		if got, err := decrypt(tt.key, tt.data); err == nil {
+			t.Errorf("%s: расшифровано %q, ожидалась ошибка", name, got)
+		}
+	}
+}
+
+// memorySessions хранилище сессий в памяти
+type memorySessions struct {
+	mu   sync.Mutex
+	data map[types.SourceName][]byte
+}
+
+func (m *memorySessions) LoadSourceSession(source types.SourceName) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data[source], nil
+}
+
+func (m *memorySessions) SaveSourceSession(source types.SourceName, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data == nil {
+		m.data = make(map[types.SourceName][]byte)
+	}
+	m.data[source] = data
+	return nil
+}
+
+// loginSite сайт с входом по форме: страница без действующей сессии отвечает 401
+// (или 200 с маркером "Войти", если marker), POST /login выдаёт новую сессию
+type loginSite struct {
+	server *httptest.Server
+	marker bool
+
+	mu      sync.Mutex
+	session string // Действующее значение cookie session
+	logins  int
+	reject  bool // Отклонять вход
+}
+
+func newLoginSite(t *testing.T) *loginSite {
+	t.Helper()
+
+	site := &loginSite{}
+	site.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		site.mu.Lock()
+		defer site.mu.Unlock()
+
+		if r.URL.Path == "/login" {
+			r.ParseForm()
+			if site.reject || r.PostForm.Get("username") != "reader" || r.PostForm.Get("password") != "secret" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			site.logins++
+			site.session = "s" + strconv.Itoa(site.logins)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: site.session, Path: "/", Expires: time.Now().Add(time.Hour)})
+			return
+		}
+
+		if cookie, err := r.Cookie("session"); err != nil || site.session == "" || cookie.Value != site.session {
+			if site.marker {
+				io.WriteString(w, "<html><a href=\"/login\">Войти</a></html>")
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, "<html>глава для "+site.session+"</html>")
+	}))
+	t.Cleanup(site.server.Close)
+	return site
+}
+
+func (s *loginSite) state() (session string, logins int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session, s.logins
+}
+
+// expire делает текущую сессию недействительной, как при истечении на стороне сайта
+func (s *loginSite) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session = "expired"
+}
+
+func (s *loginSite) get(f *Fetcher) (*Response, error) {
+	return f.Get(context.Background(), Request{
+		Source:  types.SourceReadmanga,
+		URL:     s.server.URL + "/manga/berserk",
+		Referer: s.server.URL,
+		Kind:    KindPage,
+	})
+}
+
+func newLoginFetcher(t *testing.T, site *loginSite) *Fetcher {
+	t.Helper()
+	t.Setenv("FETCH_RATE_DELAY", "0")
+	t.Setenv("FETCH_RETRY_ATTEMPTS", "1")
+	t.Setenv("FETCH_LOGIN_URL_READMANGA", site.server.URL+"/login")
+	t.Setenv("FETCH_LOGIN_USERNAME_READMANGA", "reader")
+	t.Setenv("FETCH_LOGIN_PASSWORD_READMANGA", "secret")
+	return New(Options{Timeout: 5 * time.Second})
+}
+
+func TestSessionRelogin(t *testing.T) {
+	for _, marker := range []bool{false, true} {
+		t.Run("marker="+strconv.FormatBool(marker), func(t *testing.T) {
+			site := newLoginSite(t)
+			site.marker = marker
+			if marker {
+				t.Setenv("FETCH_LOGIN_EXPIRED_MARKER_READMANGA", "Войти")
+			}
+			f := newLoginFetcher(t, site)
+
+			// Первый запрос без сессии: вход и повтор в том же вызове
+			resp, err := site.get(f)
+			if err != nil || string(resp.Body) != "<html>глава для s1</html>" {
+				t.Fatalf("первый запрос: %v", err)
+			}
+
+			// Действующая сессия переиспользуется
+			if _, err := site.get(f); err != nil {
+				t.Fatalf("второй запрос: %v", err)
+			}
+			if _, logins := site.state(); logins != 1 {
+				t.Errorf("входов %d, ожидался 1", logins)
+			}
+
+			// Сайт сбросил сессию — вход выполняется заново
+			site.expire()
+			resp, err = site.get(f)
+			if err != nil || string(resp.Body) != "<html>глава для s2</html>" {
+				t.Fatalf("после истечения сессии: %v", err)
+			}
+			if _, logins := site.state(); logins != 2 {
+				t.Errorf("входов %d, ожидалось 2", logins)
+			}
+		})
+	}
+}
+
+func TestSessionReloginRejected(t *testing.T) {
+	site := newLoginSite(t)
+	site.reject = true
+	f := newLoginFetcher(t, site)
+
+	// Вход не удался — возвращается исходный ответ, повторного входа в том же вызове нет
+	_, err := site.get(f)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("ожидалась ошибка 401, получено %v", err)
+	}
+	if _, logins := site.state(); logins != 0 {
+		t.Errorf("успешных входов %d", logins)
+	}
+}
+
+func TestSessionPersist(t *testing.T) {
+	site := newLoginSite(t)
+	sessions := &memorySessions{}
+	t.Setenv("SESSION_SECRET", "secret")
+
+	first := newLoginFetcher(t, site)
+	first.SetSessionStore(sessions)
+	if _, err := site.get(first); err != nil {
+		t.Fatalf("запрос: %v", err)
+	}
+
+	saved, _ := sessions.LoadSourceSession(types.SourceReadmanga)
+	if len(saved) == 0 {
+		t.Fatal("сессия не сохранена")
+	}
+	if session, _ := site.state(); bytes.Contains(saved, []byte(session)) {
+		t.Error("cookie сохранены без шифрования")
+	}
+
+	// После перезапуска сессия загружается из хранилища — входить заново не нужно
+	restarted := newLoginFetcher(t, site)
+	restarted.SetSessionStore(sessions)
+	if _, err := site.get(restarted); err != nil {
+		t.Fatalf("запрос после перезапуска: %v", err)
+	}
+	if _, logins := site.state(); logins != 1 {
+		t.Errorf("входов %d, ожидался 1", logins)
+	}
+
+	// С другим ключом сохранённая сессия не расшифровывается, и выполняется вход
+	t.Setenv("SESSION_SECRET", "other")
+	rotated := newLoginFetcher(t, site)
+	rotated.SetSessionStore(sessions)
+	if _, err := site.get(rotated); err != nil {
+		t.Fatalf("запрос с новым ключом: %v", err)
+	}
+	if _, logins := site.state(); logins != 2 {
+		t.Errorf("входов %d, ожидалось 2", logins)
+	}
+}
+
+func TestSessionWithoutSecret(t *testing.T) {
+	site := newLoginSite(t)
+	sessions := &memorySessions{}
+	t.Setenv("SESSION_SECRET", "")
+
+	f := newLoginFetcher(t, site)
+	f.SetSessionStore(sessions)
+	if _, err := site.get(f); err != nil {
+		t.Fatalf("запрос: %v", err)
+	}
+
+	if saved, _ := sessions.LoadSourceSession(types.SourceReadmanga); saved != nil {
+		t.Errorf("без SESSION_SECRET сохранено %d байт", len(saved))
+	}
+}
@@ -0,0 +1,95 @@
+package fetcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HostStats статистика запросов к одному хосту
+type HostStats struct {
+	Host          string        // Хост
+	Requests      int           // Всего запросов
+	Errors        int           // Запросов, завершившихся ошибкой (сеть или статус не 2xx)
+	Retries       int           // Повторных попыток
+	Bytes         int64         // Прочитано байт (после распаковки)
+	TotalDuration time.Duration // Суммарное время запросов
+	LastStatus    int           // Последний HTTP статус (0 — сетевая ошибка)
+	LastError     string        // Последняя ошибка
+	LastRequestAt time.Time     // Время последнего запроса
+}
+
+// AvgDuration среднее время запроса
+func (s HostStats) AvgDuration() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalDuration / time.Duration(s.Requests)
+}
+
+// record учитывает результат запроса в статистике хоста
+func (f *Fetcher) record(host string, status int, bytes int64, duration time.Duration, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stats, exists := f.stats[host]
+	if !exists {
+		stats = &HostStats{Host: host}
+		f.stats[host] = stats
+	}
+
+	stats.Requests++
+	stats.Bytes += bytes
+	stats.TotalDuration += duration
+	stats.LastStatus = status
+	stats.LastRequestAt = time.Now()
+
+	if err != nil {
+		stats.Errors++
+		stats.LastError = err.Error()
+	}
+}
+
+// recordRetry учитывает повторную попытку запроса
+func (f *Fetcher) recordRetry(host string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if stats, exists := f.stats[host]; exists {
+		stats.Retries++
+	}
+}
+
+// Stats возвращает копию статистики по всем хостам, отсортированную по имени хоста
+func (f *Fetcher) Stats() []HostStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]HostStats, 0, len(f.stats))
+	for _, stats := range f.stats {
+		result = append(result, *stats)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Host < result[j].Host
+	})
+
+	return result
+}
+
+// StatsReport текстовый отчёт по хостам для логов
+func (f *Fetcher) StatsReport() string {
+	stats := f.Stats()
+	if len(stats) == 0 {
+		return "Запросов к источникам ещё не было"
+	}
+
+	var sb strings.Builder
+	for _, s := range stats {
+		sb.WriteString(fmt.Sprintf("%s: запросов %d, ошибок %d, повторов %d, %d КБ, среднее %v, последний статус %d\n",
+			s.Host, s.Requests, s.Errors, s.Retries, s.Bytes/1024, s.AvgDuration().Round(time.Millisecond), s.LastStatus))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,166 @@
+package maintenance
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// minChaptersPerManga меньше не храним. Минимум отсекает только явно малые значения:
+// парсеры сравнивают главы с RSS-фидом, и глава фида, не вошедшая в лимит, удаляется
+// и на следующей проверке приходит как новая. Поэтому лимит должен быть не меньше длины фида.
+const minChaptersPerManga = 10
+
+// Config настройки периодического обслуживания
+type Config struct {
+	Interval time.Duration // Пауза между запусками (0 — обслуживание отключено)
+	DryRun   bool          // Только отчёт, без изменений
+	Policy   types.RetentionPolicy
+}
+
+// LoadConfig читает MAINTENANCE_INTERVAL, MAINTENANCE_DRY_RUN и политику хранения (RETENTION_*)
+func LoadConfig() Config {
+	return Config{
+		Interval: envDuration("MAINTENANCE_INTERVAL", 24*time.Hour),
+		DryRun:   envBool("MAINTENANCE_DRY_RUN", false),
+		Policy:   LoadPolicy(),
+	}
+}
+
+// LoadPolicy читает политику хранения из переменных RETENTION_*
+func LoadPolicy() types.RetentionPolicy {
+	policy := types.RetentionPolicy{
+		ChaptersPerManga:  envInt("RETENTION_CHAPTERS_PER_MANGA", 200),
+		OrphanGrace:       envDuration("RETENTION_ORPHAN_GRACE", 30*24*time.Hour),
+		OrphanAction:      types.OrphanFreeze,
+		InactiveUserGrace: envDuration("RETENTION_INACTIVE_USERS_AFTER", 30*24*time.Hour),
+		NotificationsTTL:  envDuration("RETENTION_NOTIFICATIONS", 7*24*time.Hour),
+		CrawlHistoryTTL:   envDuration("RETENTION_CRAWL_HISTORY", 30*24*time.Hour),
+		EventsTTL:         envDuration("RETENTION_EVENTS", 365*24*time.Hour),
+	}
+
+	if policy.ChaptersPerManga > 0 && policy.ChaptersPerManga < minChaptersPerManga {
+		log.Printf("RETENTION_CHAPTERS_PER_MANGA=%d слишком мало, используется %d", policy.ChaptersPerManga, minChaptersPerManga)
+		policy.ChaptersPerManga = minChaptersPerManga
+	}
+
+	switch action := os.Getenv("RETENTION_CHAPTERS_ACTION"); action {
+	case "", "delete":
+	case "archive":
+		policy.ArchiveChapters = true
+	default:
+		log.Printf("Некорректное значение RETENTION_CHAPTERS_ACTION=%q, главы удаляются", action)
+	}
+
+	switch action := types.OrphanAction(os.Getenv("RETENTION_ORPHAN_ACTION")); action {
+	case "":
+	case types.OrphanFreeze, types.OrphanDelete:
+		policy.OrphanAction = action
+	default:
+		log.Printf("Некорректное значение RETENTION_ORPHAN_ACTION=%q, манга замораживается", action)
+	}
+
+	return policy
+}
+
+// Start запускает обслуживание по расписанию
+func Start(st store.Store) {
+	cfg := LoadConfig()
+	if cfg.Interval <= 0 {
+		log.Println("Обслуживание БД отключено (MAINTENANCE_INTERVAL=0)")
+		return
+	}
+
+	log.Printf("Запуск обслуживания БД (каждые %v)", cfg.Interval)
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := Run(st, cfg.Policy, cfg.DryRun); err != nil {
+			log.Printf("Ошибка обслуживания БД: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// Run выполняет один проход обслуживания и пишет отчёт в лог
+func Run(st store.Store, policy types.RetentionPolicy, dryRun bool) (types.MaintenanceReport, error) {
+	report, err := st.RunMaintenance(policy, time.Now().UTC(), dryRun)
+	if err != nil {
+		return report, err
+	}
+
+	log.Printf("Обслуживание БД:\n%s", FormatReport(report))
+	return report, nil
+}
+
+// FormatReport возвращает отчёт в читаемом виде
+func FormatReport(report types.MaintenanceReport) string {
+	var b strings.Builder
+
+	if report.DryRun {
+		b.WriteString("Пробный запуск, изменения не сохранены\n")
+	}
+
+	fmt.Fprintf(&b, "  глав удалено: %d (из них в архив: %d)\n", report.ChaptersDeleted, report.ChaptersArchived)
+	fmt.Fprintf(&b, "  манги без подписчиков: новых %d, заморожено %d, удалено %d\n",
+		report.MangaOrphaned, report.MangaFrozen, report.MangaDeleted)
+	fmt.Fprintf(&b, "  неактивных пользователей удалено: %d\n", report.UsersDeleted)
+	fmt.Fprintf(&b, "  уведомлений удалено: %d\n", report.NotificationsDeleted)
+	fmt.Fprintf(&b, "  записей истории проверок удалено: %d\n", report.CrawlHistoryDeleted)
+	fmt.Fprintf(&b, "  событий журнала удалено: %d", report.EventsDeleted)
+
+	return b.String()
+}
+
+// envInt возвращает целочисленную переменную окружения или значение по умолчанию
+func envInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// envDuration возвращает переменную-длительность ("24h", "720h") или значение по умолчанию
+func envDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// envBool возвращает логическую переменную окружения или значение по умолчанию
+func envBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
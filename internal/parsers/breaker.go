@@ -0,0 +1,230 @@
+package parsers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/fetcher"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// Настройки предохранителя задаются через окружение:
+// BREAKER_THRESHOLD — сколько ошибок подряд размыкают предохранитель,
+// BREAKER_PROBE_INTERVAL — первая пауза до пробного запроса, дальше она удваивается,
+// BREAKER_MAX_PROBE_INTERVAL — верхняя граница паузы.
+const (
+	defaultBreakerThreshold     = 5
+	defaultBreakerProbeInterval = 20 * time.Minute
+	defaultBreakerMaxInterval   = 6 * time.Hour
+)
+
+// BreakerState состояние предохранителя источника
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Источник работает, обходим как обычно
+	BreakerOpen     BreakerState = "open"      // Источник недоступен, обход приостановлен
+	BreakerHalfOpen BreakerState = "half-open" // Идёт пробный запрос
+)
+
+// Breaker предохранитель источника: после серии ошибок подряд приостанавливает обход
+// и проверяет источник одиночными запросами через растущие интервалы
+type Breaker struct {
+	mu sync.Mutex
+
+	threshold    int
+	baseInterval time.Duration
+	maxInterval  time.Duration
+
+	state     BreakerState
+	failures  int
+	interval  time.Duration
+	openedAt  time.Time
+	nextProbe time.Time
+	lastErr   error
+
+	now func() time.Time // Часы (подменяются в тестах)
+}
+
+// NewBreaker создаёт предохранитель с настройками из окружения
+func NewBreaker() *Breaker {
+	return &Breaker{
+		threshold:    envBreakerInt("BREAKER_THRESHOLD", defaultBreakerThreshold),
+		baseInterval: envBreakerDuration("BREAKER_PROBE_INTERVAL", defaultBreakerProbeInterval),
+		maxInterval:  envBreakerDuration("BREAKER_MAX_PROBE_INTERVAL", defaultBreakerMaxInterval),
+		state:        BreakerClosed,
+		now:          time.Now,
+	}
+}
+
+// Allow сообщает, можно ли обходить источник сейчас.
+// probe = true означает, что разрешён только один пробный запрос.
+func (b *Breaker) Allow() (allowed, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return true, false
+	case BreakerOpen:
+		if b.now().Before(b.nextProbe) {
+			return false, false
+		}
+		b.state = BreakerHalfOpen
+		return true, true
+	default:
+		return true, true
+	}
+}
+
+// Success отмечает успешный запрос. recovered = true, если предохранитель замкнулся после простоя.
+func (b *Breaker) Success() (recovered bool, downtime time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	recovered = b.state != BreakerClosed
+	if recovered {
+		downtime = b.now().Sub(b.openedAt)
+	}
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.interval = 0
+	b.lastErr = nil
+
+	return recovered, downtime
+}
+
+// Failure отмечает ошибку источника. opened = true, если предохранитель только что разомкнулся.
+// Неудачная проба не считается новым размыканием — пауза до следующей просто удваивается.
+func (b *Breaker) Failure(err error) (opened bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastErr = err
+
+	switch b.state {
+	case BreakerClosed:
+		if b.failures < b.threshold {
+			return false
+		}
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+		b.interval = b.baseInterval
+		opened = true
+	default:
+		b.state = BreakerOpen
+		b.interval = min(b.interval*2, b.maxInterval)
+	}
+
+	b.nextProbe = b.now().Add(b.interval)
+	return opened
+}
+
+// State возвращает текущее состояние предохранителя
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// NextProbe возвращает время следующего пробного запроса
+func (b *Breaker) NextProbe() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextProbe
+}
+
+// Failures возвращает число ошибок подряд
+func (b *Breaker) Failures() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures
+}
+
+var (
+	breakers   = make(map[types.SourceName]*Breaker)
+	breakersMu sync.Mutex
+)
+
+// breakerFor возвращает предохранитель источника
+func breakerFor(sourceName types.SourceName) *Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	b, ok := breakers[sourceName]
+	if !ok {
+		b = NewBreaker()
+		breakers[sourceName] = b
+	}
+	return b
+}
+
+// StoreError ошибка нашего хранилища во время проверки манги. Источник при этом ответил,
+// поэтому такие ошибки не размыкают предохранитель и не вызывают уведомлений о недоступности.
+type StoreError struct {
+	Err error
+}
+
+func (e *StoreError) Error() string {
+	return fmt.Sprintf("ошибка хранилища: %v", e.Err)
+}
+
+func (e *StoreError) Unwrap() error {
+	return e.Err
+}
+
+// crawlErrorStore тип ошибки хранилища в истории проверок
+const crawlErrorStore = "store"
+
+// crawlErrorKind тип ошибки для истории проверок: ошибки хранилища отдельно от ошибок источника
+func crawlErrorKind(err error) string {
+	var storeErr *StoreError
+	if errors.As(err, &storeErr) {
+		return crawlErrorStore
+	}
+	return string(fetcher.Classify(err))
+}
+
+// isSourceFailure отделяет сбои самого источника от проблем отдельной манги и нашей БД:
+// ненайденная страница, сменившаяся вёрстка, запрет robots.txt или ошибка хранилища
+// не говорят о том, что источник лежит
+func isSourceFailure(err error) bool {
+	var storeErr *StoreError
+	if errors.As(err, &storeErr) {
+		return false
+	}
+
+	switch fetcher.Classify(err) {
+	case fetcher.ErrorNotFound, fetcher.ErrorParse, fetcher.ErrorDisallowed:
+		return false
+	default:
+		return true
+	}
+}
+
+func envBreakerInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("Некорректное значение %s=%q, используется %d", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+func envBreakerDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("Некорректное значение %s=%q, используется %s", key, value, defaultValue)
+	}
+	return defaultValue
+}
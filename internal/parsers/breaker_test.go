@@ -0,0 +1,372 @@
+package parsers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/fetcher"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/telegram"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// testClock управляемые часы предохранителя
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newTestBreaker создаёт предохранитель на управляемых часах: размыкается после 3 ошибок,
+// первая проба через минуту, пауза не больше 3 минут
+func newTestBreaker(t *testing.T) (*Breaker, *testClock) {
+	t.Helper()
+	t.Setenv("BREAKER_THRESHOLD", "3")
+	t.Setenv("BREAKER_PROBE_INTERVAL", "1m")
+	t.Setenv("BREAKER_MAX_PROBE_INTERVAL", "3m")
+
+	clock := &testClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	b := NewBreaker()
+	b.now = clock.Now
+	return b, clock
+}
+
+// errServer ошибка недоступного источника
+var errServer = &fetcher.StatusError{StatusCode: http.StatusBadGateway}
+
+func assertAllow(t *testing.T, b *Breaker, wantAllowed, wantProbe bool) {
+	t.Helper()
+	if allowed, probe := b.Allow(); allowed != wantAllowed || probe != wantProbe {
+		t.Fatalf("Allow() = (%v, %v), ожидалось (%v, %v)", allowed, probe, wantAllowed, wantProbe)
+	}
+}
+
+func assertState(t *testing.T, b *Breaker, want BreakerState) {
+	t.Helper()
+	if got := b.State(); got != want {
+		t.Fatalf("состояние %s, ожидалось %s", got, want)
+	}
+}
+
+func TestBreakerOpenHalfOpenClosed(t *testing.T) {
+	b, clock := newTestBreaker(t)
+
+	for i := 1; i < 3; i++ {
+		if b.Failure(errServer) {
+			t.Fatalf("предохранитель разомкнулся после %d ошибок", i)
+		}
+		assertAllow(t, b, true, false)
+	}
+	if !b.Failure(errServer) {
+		t.Fatal("предохранитель не разомкнулся после 3 ошибок")
+	}
+	assertState(t, b, BreakerOpen)
+	assertAllow(t, b, false, false)
+
+	clock.Advance(time.Minute)
+	assertAllow(t, b, true, true)
+	assertState(t, b, BreakerHalfOpen)
+
+	clock.Advance(30 * time.Second)
+	recovered, downtime := b.Success()
+	if !recovered {
+		t.Fatal("успешная проба не отмечена восстановлением")
+	}
+	if downtime != 90*time.Second {
+		t.Errorf("простой %s, ожидалось 1m30s", downtime)
+	}
+	assertState(t, b, BreakerClosed)
+	if b.Failures() != 0 {
+		t.Errorf("после восстановления осталось %d ошибок", b.Failures())
+	}
+
+	if recovered, _ := b.Success(); recovered {
+		t.Error("повторный успех снова отмечен восстановлением")
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	b, _ := newTestBreaker(t)
+
+	b.Failure(errServer)
+	b.Failure(errServer)
+	b.Success()
+	b.Failure(errServer)
+	b.Failure(errServer)
+
+	assertState(t, b, BreakerClosed)
+}
+
+func TestBreakerProbeBackoff(t *testing.T) {
+	b, clock := newTestBreaker(t)
+	for range 3 {
+		b.Failure(errServer)
+	}
+
+	// Пауза между пробами удваивается до BREAKER_MAX_PROBE_INTERVAL
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		if got := b.NextProbe().Sub(clock.Now()); got != want {
+			t.Fatalf("пауза до пробы %s, ожидалось %s", got, want)
+		}
+
+		clock.Advance(want - time.Second)
+		assertAllow(t, b, false, false)
+
+		clock.Advance(time.Second)
+		assertAllow(t, b, true, true)
+
+		if b.Failure(errServer) {
+			t.Fatal("неудачная проба отмечена новым размыканием")
+		}
+		assertState(t, b, BreakerOpen)
+	}
+}
+
+func TestIsSourceFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"сервер", errServer, true},
+		{"блокировка", &fetcher.StatusError{StatusCode: http.StatusForbidden}, true},
+		{"сеть", &net.DNSError{Err: "no such host", IsTimeout: true}, true},
+		{"не найдено", &fetcher.StatusError{StatusCode: http.StatusNotFound}, false},
+		{"вёрстка", &fetcher.ParseError{Detail: "не найден список глав"}, false},
+		{"хранилище", &StoreError{Err: errors.New("database is locked")}, false},
+		{"хранилище в обёртке", fmt.Errorf("проверка: %w", &StoreError{Err: errors.New("database is locked")}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSourceFailure(tt.err); got != tt.want {
+				t.Errorf("isSourceFailure(%v) = %v, ожидалось %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// parserRun тестовый источник с подменённым парсером и предохранителем на управляемых часах
+type parserRun struct {
+	store   *store.Memory
+	source  types.Source
+	breaker *Breaker
+	clock   *testClock
+	checked []string // Названия проверенных манг по порядку
+}
+
+// newParserRun регистрирует тестовый источник. Парсер проверяет мангу по очереди:
+// ошибка из errs проходит через handleCrawlError, остальные манги считаются успешными.
+func newParserRun(t *testing.T, baseURL string, errs map[string]error) *parserRun {
+	t.Helper()
+
+	b, clock := newTestBreaker(t)
+	name := types.SourceName("test-" + strings.ReplaceAll(t.Name(), "/", "-"))
+
+	run := &parserRun{store: store.NewMemory(), breaker: b, clock: clock}
+	run.source = run.store.AddSource(name, baseURL)
+
+	breakersMu.Lock()
+	breakers[name] = b
+	breakersMu.Unlock()
+
+	RegisterParser(name, func(bot *telegram.TelegramBot, st store.Store, source types.Source, mangaList []types.Manga) error {
+		for _, manga := range mangaList {
+			run.checked = append(run.checked, manga.Title)
+			if err, ok := errs[manga.Title]; ok {
+				if handleCrawlError(bot, st, source, manga, err) {
+					break
+				}
+				continue
+			}
+			handleCrawlSuccess(bot, st, source, manga, 0)
+		}
+		return nil
+	})
+
+	t.Cleanup(func() {
+		delete(parsers, name)
+		breakersMu.Lock()
+		delete(breakers, name)
+		breakersMu.Unlock()
+	})
+	return run
+}
+
+// manga создаёт мангу источника
+func (r *parserRun) manga(t *testing.T, titles ...string) []types.Manga {
+	t.Helper()
+
+	var list []types.Manga
+	for _, title := range titles {
+		manga, err := r.store.CreateManga(r.source.ID, strings.ToLower(title), title)
+		if err != nil {
+			t.Fatalf("CreateManga: %v", err)
+		}
+		list = append(list, *manga)
+	}
+	return list
+}
+
+// open размыкает предохранитель и переводит часы к первой пробе
+func (r *parserRun) open() {
+	for range 3 {
+		r.breaker.Failure(errServer)
+	}
+	r.clock.Advance(time.Minute)
+}
+
+func (r *parserRun) run(t *testing.T, mangaList []types.Manga) {
+	t.Helper()
+	if err := RunParser(&telegram.TelegramBot{}, r.store, r.source, mangaList); err != nil {
+		t.Fatalf("RunParser: %v", err)
+	}
+}
+
+func assertChecked(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("проверены %v, ожидалось %v", got, want)
+	}
+}
+
+func TestRunParserSkipsOpenSource(t *testing.T) {
+	run := newParserRun(t, "https://example.test", nil)
+	mangaList := run.manga(t, "Берсерк", "Вагабонд")
+
+	run.open()
+	run.clock.Advance(-time.Second)
+	run.run(t, mangaList)
+
+	assertChecked(t, run.checked)
+	assertState(t, run.breaker, BreakerOpen)
+}
+
+func TestRunParserProbe(t *testing.T) {
+	tests := []struct {
+		name        string
+		errs        map[string]error
+		wantChecked []string
+		wantState   BreakerState
+	}{
+		{
+			name:        "успешная проба — обход продолжается",
+			wantChecked: []string{"Берсерк", "Вагабонд", "Клеймор"},
+			wantState:   BreakerClosed,
+		},
+		{
+			name:        "неудачная проба — остальные не проверяются",
+			errs:        map[string]error{"Берсерк": errServer},
+			wantChecked: []string{"Берсерк"},
+			wantState:   BreakerOpen,
+		},
+		{
+			name:        "страница не найдена — источник отвечает",
+			errs:        map[string]error{"Берсерк": &fetcher.StatusError{StatusCode: http.StatusNotFound}},
+			wantChecked: []string{"Берсерк", "Вагабонд", "Клеймор"},
+			wantState:   BreakerClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := newParserRun(t, "https://example.test", tt.errs)
+			mangaList := run.manga(t, "Берсерк", "Вагабонд", "Клеймор")
+
+			run.open()
+			run.run(t, mangaList)
+
+			assertChecked(t, run.checked, tt.wantChecked...)
+			assertState(t, run.breaker, tt.wantState)
+
+			if tt.wantState == BreakerOpen {
+				if got := run.breaker.NextProbe().Sub(run.clock.Now()); got != 2*time.Minute {
+					t.Errorf("пауза после неудачной пробы %s, ожидалось 2m", got)
+				}
+			}
+		})
+	}
+}
+
+// failingIngestStore хранилище, которое не может сохранить главы указанной манги
+type failingIngestStore struct {
+	store.Store
+	mangaID int
+}
+
+func (s *failingIngestStore) IngestChapters(mangaID, workID int, chapters []types.Chapter) ([]types.Chapter, error) {
+	if mangaID == s.mangaID {
+		return nil, errors.New("database is locked")
+	}
+	return s.Store.IngestChapters(mangaID, workID, chapters)
+}
+
+// newReadmangaSite поднимает источник: страница манги ссылается на её RSS, фид отдаёт одну главу
+func newReadmangaSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	t.Setenv("FETCH_RATE_DELAY", "0")
+	t.Setenv("FETCH_RETRY_ATTEMPTS", "1")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, ok := strings.CutPrefix(r.URL.Path, "/rss/"); ok {
+			w.Header().Set("Content-Type", "application/rss+xml")
+			fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel>
+	<title>%[1]s</title>
+	<link>http://%[2]s/%[1]s</link>
+	<item><title>%[1]s 1 - 1</title><link>http://%[2]s/%[1]s/vol1/1</link></item>
+</channel></rss>`, name, r.Host)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<html><head><link title="RSS" href="/rss%s"></head></html>`, r.URL.Path)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunParserProbeEndsOnStoreError(t *testing.T) {
+	server := newReadmangaSite(t)
+	run := newParserRun(t, server.URL, nil)
+	RegisterParser(run.source.ParserName, ReadmangaParser)
+
+	mangaList := run.manga(t, "Berserk", "Vagabond")
+	st := &failingIngestStore{Store: run.store, mangaID: mangaList[0].ID}
+
+	run.open()
+	if err := RunParser(&telegram.TelegramBot{}, st, run.source, mangaList); err != nil {
+		t.Fatalf("RunParser: %v", err)
+	}
+
+	// Источник ответил — проба завершена, ошибка БД не считается сбоем источника
+	assertState(t, run.breaker, BreakerClosed)
+
+	history, err := run.store.GetMangaCrawlHistory(mangaList[0].ID, 10)
+	if err != nil {
+		t.Fatalf("GetMangaCrawlHistory: %v", err)
+	}
+	if len(history) != 1 || history[0].Status != types.CrawlError || history[0].ErrorKind != crawlErrorStore {
+		t.Errorf("история пробы %+v, ожидалась ошибка типа %q", history, crawlErrorStore)
+	}
+
+	// Остальные манги после пробы проверены
+	chapters, err := run.store.GetChaptersByMangaID(mangaList[1].ID)
+	if err != nil {
+		t.Fatalf("GetChaptersByMangaID: %v", err)
+	}
+	if len(chapters) != 1 {
+		t.Errorf("у второй манги %d глав, ожидалась 1", len(chapters))
+	}
+}
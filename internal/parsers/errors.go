@@ -0,0 +1,86 @@
+package parsers
+
+import (
+	"log"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/fetcher"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/telegram"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// recordCrawl сохраняет результат проверки манги в историю
+func recordCrawl(st store.Store, source types.Source, manga types.Manga, newChapters int, crawlErr error) {
+	record := types.CrawlRecord{
+		SourceID:    source.ID,
+		MangaID:     manga.ID,
+		Status:      types.CrawlOK,
+		NewChapters: newChapters,
+	}
+
+	if crawlErr != nil {
+		record.Status = types.CrawlError
+		record.ErrorKind = crawlErrorKind(crawlErr)
+		record.Error = crawlErr.Error()
+		record.HTTPStatus = fetcher.StatusCodeOf(crawlErr)
+	}
+
+	if err := st.RecordCrawl(record); err != nil {
+		log.Printf("Ошибка записи истории проверки: %v", err)
+	}
+}
+
+// handleCrawlSuccess записывает успешную проверку в историю и замыкает предохранитель источника
+func handleCrawlSuccess(telegramBot *telegram.TelegramBot, st store.Store, source types.Source, manga types.Manga, newChapters int) {
+	recordCrawl(st, source, manga, newChapters, nil)
+	reportSourceUp(telegramBot, source)
+}
+
+// reportSourceUp отмечает, что источник отвечает, и сообщает админу о восстановлении после простоя
+func reportSourceUp(telegramBot *telegram.TelegramBot, source types.Source) {
+	if recovered, downtime := breakerFor(source.ParserName).Success(); recovered {
+		log.Printf("Источник %s снова доступен (простой %s)", source.ParserName, downtime.Round(time.Minute))
+		telegram.SendSourceRecoveredNotification(telegramBot, source.ParserName, downtime)
+	}
+}
+
+// handleCrawlError записывает ошибку проверки в историю и решает, нужно ли уведомлять админа.
+// Админ получает сообщение, только когда изменилась вёрстка или когда предохранитель источника
+// размыкается (и замыкается снова — см. reportSourceUp). Остальные ошибки только пишутся в лог
+// и историю, чтобы нестабильный источник не засыпал админа уведомлениями.
+// Возвращает true, если проверку остальных манг источника стоит прекратить.
+func handleCrawlError(telegramBot *telegram.TelegramBot, st store.Store, source types.Source, manga types.Manga, err error) bool {
+	recordCrawl(st, source, manga, 0, err)
+
+	kind := fetcher.Classify(err)
+
+	// Страница не найдена, не разобралась, запрещена robots.txt или не сохранилась в БД — источник при этом отвечает.
+	// Проба тоже завершается: предохранитель замыкается, а не остаётся полуоткрытым.
+	if !isSourceFailure(err) {
+		reportSourceUp(telegramBot, source)
+
+		if kind == fetcher.ErrorParse {
+			telegram.SendLayoutChangedNotification(telegramBot, source.ParserName, manga.Title, err)
+		}
+		return false
+	}
+
+	breaker := breakerFor(source.ParserName)
+
+	if breaker.Failure(err) {
+		log.Printf("Источник %s приостановлен после %d ошибок подряд", source.ParserName, breaker.Failures())
+		telegram.SendSourceDownNotification(telegramBot, source.ParserName, breaker.Failures(), err, breaker.NextProbe())
+		return true
+	}
+
+	if breaker.State() != BreakerClosed {
+		log.Printf("Проба источника %s не удалась, следующая в %s", source.ParserName, breaker.NextProbe().Format("15:04"))
+		return true
+	}
+
+	log.Printf("Ошибка источника %s (%s), подряд: %d", source.ParserName, kind, breaker.Failures())
+
+	// Нас блокируют — остальные запросы этого цикла только усугубят бан
+	return fetcher.IsBlocking(kind)
+}
@@ -3,9 +3,9 @@ package parsers
 import (
 	"fmt"
 	"log"
-	"time"
 
-	"github.com/SemenovDmitry/manga-crawler-backend/db"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/fetcher"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/telegram"
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/utils"
@@ -16,33 +16,41 @@ import (
 // 1. Ищет RSS ссылку на странице манги
 // 2. Получает RSS фид с главами
 // 3. Сохраняет новые главы в БД
-// 4. Отправляет уведомления в Telegram подписчикам
-// Между запросами делает паузу 4 секунды для избежания бана.
-func ReadmangaParser(telegramBot *telegram.TelegramBot, source types.Source, mangaList []types.Manga) error {
+// 4. Ставит в очередь уведомления подписчикам (отправляет telegram.StartDispatcher)
+// Паузы между запросами выдерживает фетчер (FETCH_RATE_DELAY и Crawl-delay из robots.txt).
+// Сбои источника считает предохранитель (см. RunParser).
+func ReadmangaParser(telegramBot *telegram.TelegramBot, st store.Store, source types.Source, mangaList []types.Manga) error {
 	log.Printf("Начало проверки обновлений для источника: %s\n\n", source.ParserName)
 
-	for index, manga := range mangaList {
+	f := fetcher.Default()
+
+	for _, manga := range mangaList {
 		log.Printf("Проверяем мангу: %s (ID: %d)", manga.Title, manga.ID)
 
 		// Ищем RSS ссылку на странице манги
-		rssUrl, err := utils.FindRSSLink(source.BaseURL, manga.URL)
+		rssUrl, err := utils.FindRSSLink(f, source, manga.URL)
 		if err != nil {
 			log.Printf("Ошибка поиска RSS ссылки для %s: %v", manga.Title, err)
-			telegram.SendErrorNotification(telegramBot, manga.Title)
+			if handleCrawlError(telegramBot, st, source, manga, err) {
+				break
+			}
 			continue
 		}
 
 		// Получаем RSS фид
-		feed, err := utils.GetRSSFeed(rssUrl)
+		feed, err := utils.GetRSSFeed(f, source, rssUrl)
 		if err != nil {
 			log.Printf("Ошибка получения RSS для %s: %v", manga.Title, err)
-			telegram.SendErrorNotification(telegramBot, manga.Title)
+			if handleCrawlError(telegramBot, st, source, manga, err) {
+				break
+			}
 			continue
 		}
 
 		if len(feed.Items) == 0 {
 			log.Printf("Нет глав для %s", manga.Title)
-			db.UpdateMangaLastCheck(manga.ID)
+			st.UpdateMangaLastCheck(manga.ID)
+			handleCrawlSuccess(telegramBot, st, source, manga, 0)
 			continue
 		}
 
@@ -50,46 +58,36 @@ func ReadmangaParser(telegramBot *telegram.TelegramBot, source types.Source, man
 		transformedFeed, err := utils.TransformRSSFeed(feed)
 		if err != nil {
 			log.Printf("Ошибка преобразования RSS для %s: %v", manga.Title, err)
+			if handleCrawlError(telegramBot, st, source, manga, err) {
+				break
+			}
 			continue
 		}
 
-		// Сохраняем новые главы в БД и получаем только реально новые
-		newChapters, err := db.CreateChapters(manga.ID, transformedFeed.Chapters)
+		// Привязываем мангу к произведению (до сохранения глав, чтобы уже известные главы не анонсировались повторно)
+		workID, err := store.EnsureMangaWork(st, &manga)
 		if err != nil {
-			log.Printf("Ошибка сохранения глав для %s: %v", manga.Title, err)
+			log.Printf("Ошибка привязки %s к произведению: %v", manga.Title, err)
+			if handleCrawlError(telegramBot, st, source, manga, &StoreError{Err: err}) {
+				break
+			}
 			continue
 		}
 
-		fmt.Printf("Новых глав: %d\n\n", len(newChapters))
-
-		// Если есть новые главы — обновляем последнюю главу и отправляем уведомления
-		if len(newChapters) > 0 {
-			// Обновляем информацию о последней главе
-			lastChapter := newChapters[0]
-			if err := db.UpdateMangaLastChapter(manga.ID, lastChapter.URL, lastChapter.Title); err != nil {
-				log.Printf("Ошибка обновления последней главы: %v", err)
-			}
-
-			// Получаем подписчиков манги
-			subscribers, err := db.GetMangaSubscribers(manga.ID)
-			if err != nil {
-				log.Printf("Ошибка получения подписчиков: %v", err)
-			}
-
-			// Отправляем уведомления всем подписчикам
-			for _, subscriber := range subscribers {
-				telegram.SendMangaUpdateToUser(telegramBot, subscriber.ID, source.BaseURL, manga, newChapters)
+		// Сохраняем главы вместе с последней главой и временем проверки и получаем только реально новые.
+		// В той же транзакции ставятся в очередь уведомления подписчикам — их доставляет диспетчер.
+		// Ошибка БД — это проваленная проверка, а не «новых глав нет».
+		newChapters, err := st.IngestChapters(manga.ID, workID, transformedFeed.Chapters)
+		if err != nil {
+			log.Printf("Ошибка сохранения глав для %s: %v", manga.Title, err)
+			if handleCrawlError(telegramBot, st, source, manga, &StoreError{Err: err}) {
+				break
 			}
-		} else {
-			// Просто обновляем время последней проверки
-			db.UpdateMangaLastCheck(manga.ID)
+			continue
 		}
 
-		// Ждём 4 секунды перед следующей мангой (чтобы не получить бан)
-		if index < len(mangaList)-1 {
-			fmt.Println("Ожидание 4 секунды...")
-			time.Sleep(4 * time.Second)
-		}
+		fmt.Printf("Новых глав: %d\n\n", len(newChapters))
+		handleCrawlSuccess(telegramBot, st, source, manga, len(newChapters))
 	}
 
 	log.Println("Проверка обновлений завершена")
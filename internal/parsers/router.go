@@ -2,13 +2,15 @@ package parsers
 
 import (
 	"fmt"
+	"log"
 
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/telegram"
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
 )
 
 // ParserFunc тип функции парсера
-type ParserFunc func(telegramBot *telegram.TelegramBot, source types.Source, mangaList []types.Manga) error
+type ParserFunc func(telegramBot *telegram.TelegramBot, st store.Store, source types.Source, mangaList []types.Manga) error
 
 // parsers маппинг SourceName -> парсер
 var parsers = map[types.SourceName]ParserFunc{
@@ -25,13 +27,37 @@ func GetParser(sourceName types.SourceName) (ParserFunc, error) {
 	return parser, nil
 }
 
-// RunParser запускает парсер для указанного источника
-func RunParser(telegramBot *telegram.TelegramBot, source types.Source, mangaList []types.Manga) error {
+// RunParser запускает парсер для указанного источника.
+// Если предохранитель источника разомкнут, обход пропускается; когда подходит время пробы,
+// проверяется одна манга, и только после её успеха — остальные.
+func RunParser(telegramBot *telegram.TelegramBot, st store.Store, source types.Source, mangaList []types.Manga) error {
 	parser, err := GetParser(source.ParserName)
 	if err != nil {
 		return err
 	}
-	return parser(telegramBot, source, mangaList)
+
+	breaker := breakerFor(source.ParserName)
+
+	allowed, probe := breaker.Allow()
+	if !allowed {
+		log.Printf("Источник %s приостановлен, следующая проба в %s", source.ParserName, breaker.NextProbe().Format("15:04"))
+		return nil
+	}
+
+	if !probe || len(mangaList) == 0 {
+		return parser(telegramBot, st, source, mangaList)
+	}
+
+	log.Printf("Пробный запрос к приостановленному источнику %s", source.ParserName)
+	if err := parser(telegramBot, st, source, mangaList[:1]); err != nil {
+		return err
+	}
+
+	if breaker.State() != BreakerClosed {
+		return nil
+	}
+
+	return parser(telegramBot, st, source, mangaList[1:])
 }
 
 // RegisterParser регистрирует новый парсер
@@ -0,0 +1,1350 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/utils"
+)
+
+// announcementKey номер главы, объявленный командой в рамках произведения
+type announcementKey struct {
+	workID     int
+	number     string
+	translator string
+}
+
+// workTitle название произведения в исходном написании
+type workTitle struct {
+	workID int
+	title  string
+}
+
+// subscriptionKey подписка пользователя на мангу
+type subscriptionKey struct {
+	userID  int64
+	mangaID int
+}
+
+// Memory хранилище в памяти. Повторяет поведение Postgres (уникальность, сортировки, фильтры)
+// и подходит для тестов обработчиков и парсеров без базы данных.
+type Memory struct {
+	mu sync.Mutex
+
+	nextID int
+
+	sources       map[int]types.Source
+	manga         map[int]types.Manga
+	chapters      map[int]types.Chapter
+	users         map[int64]types.TelegramUser
+	subscriptions map[subscriptionKey]types.UserSubscription
+	works         map[int]types.Work
+	workTitles    map[string]workTitle // Нормализованное название -> произведение
+	announcements map[announcementKey]int
+	crawls        []types.CrawlRecord
+	notifications []types.Notification // В порядке создания
+	events        []types.Event        // В порядке записи
+
+	chaptersArchive   map[int]types.Chapter
+	orphanedSince     map[int]time.Time   // Манга без подписчиков (отмечает обслуживание)
+	frozen            map[int]time.Time   // Замороженная манга (не проверяется)
+	userInactiveSince map[int64]time.Time // Неактивные пользователи
+
+	now func() time.Time // Время обнаружения глав и приглушения подписок
+}
+
+var _ Store = (*Memory)(nil)
+
+// NewMemory создаёт пустое хранилище в памяти
+func NewMemory() *Memory {
+	return &Memory{
+		sources:       make(map[int]types.Source),
+		manga:         make(map[int]types.Manga),
+		chapters:      make(map[int]types.Chapter),
+		users:         make(map[int64]types.TelegramUser),
+		subscriptions: make(map[subscriptionKey]types.UserSubscription),
+		works:         make(map[int]types.Work),
+		workTitles:    make(map[string]workTitle),
+		announcements: make(map[announcementKey]int),
+
+		chaptersArchive:   make(map[int]types.Chapter),
+		orphanedSince:     make(map[int]time.Time),
+		frozen:            make(map[int]time.Time),
+		userInactiveSince: make(map[int64]time.Time),
+
+		now: time.Now,
+	}
+}
+
+// SetClock подменяет часы хранилища, как db.Store.SetClock
+func (m *Memory) SetClock(now func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+// id выдаёт следующий идентификатор (общий счётчик для всех таблиц)
+func (m *Memory) id() int {
+	m.nextID++
+	return m.nextID
+}
+
+// AddSource добавляет источник (в БД источники заводятся миграциями)
+func (m *Memory) AddSource(parserName types.SourceName, baseURL string) types.Source {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	s := types.Source{
+		ID:         m.id(),
+		ParserName: parserName,
+		BaseURL:    baseURL,
+		IsActive:   true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	m.sources[s.ID] = s
+	return s
+}
+
+// ---- Источники ----
+
+func (m *Memory) GetActiveSources() ([]types.Source, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sources []types.Source
+	for _, s := range m.sources {
+		if s.IsActive {
+			sources = append(sources, s)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].ID < sources[j].ID })
+	return sources, nil
+}
+
+func (m *Memory) GetSourceByID(id int) (*types.Source, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sources[id]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (m *Memory) GetSourceByName(parserName types.SourceName) (*types.Source, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.sources {
+		if s.ParserName == parserName {
+			return &s, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Memory) GetSourceByBaseURL(baseURL string) (*types.Source, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var candidates []types.Source
+	for _, s := range m.sources {
+		if !s.IsActive {
+			continue
+		}
+		if s.BaseURL == baseURL {
+			return &s, nil
+		}
+		if strings.Contains(s.BaseURL, baseURL) {
+			candidates = append(candidates, s)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+	return &candidates[0], nil
+}
+
+func (m *Memory) SetSourceActive(id int, active bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	source, ok := m.sources[id]
+	if !ok {
+		return nil
+	}
+
+	source.IsActive = active
+	source.UpdatedAt = time.Now()
+	m.sources[id] = source
+	return nil
+}
+
+// ---- Манга ----
+
+func (m *Memory) GetMangaBySourceID(sourceID int) ([]types.Manga, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var mangaList []types.Manga
+	for _, manga := range m.manga {
+		if _, frozen := m.frozen[manga.ID]; manga.SourceID == sourceID && !frozen {
+			mangaList = append(mangaList, manga)
+		}
+	}
+	sort.Slice(mangaList, func(i, j int) bool { return mangaList[i].ID < mangaList[j].ID })
+	return mangaList, nil
+}
+
+func (m *Memory) GetMangaByID(id int) (*types.Manga, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	manga, ok := m.manga[id]
+	if !ok {
+		return nil, nil
+	}
+	return &manga, nil
+}
+
+func (m *Memory) GetMangaBySourceAndURL(sourceID int, url string) (*types.Manga, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, manga := range m.manga {
+		if manga.SourceID == sourceID && manga.URL == url {
+			return &manga, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Memory) CreateManga(sourceID int, url, title string) (*types.Manga, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sources[sourceID]; !ok {
+		return nil, fmt.Errorf("ошибка создания манги: источник %d не найден", sourceID)
+	}
+	for _, manga := range m.manga {
+		if manga.SourceID == sourceID && manga.URL == url {
+			return nil, fmt.Errorf("ошибка создания манги: %s уже отслеживается", url)
+		}
+	}
+
+	now := time.Now()
+	manga := types.Manga{
+		ID:        m.id(),
+		SourceID:  sourceID,
+		URL:       url,
+		Title:     title,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	m.manga[manga.ID] = manga
+	return &manga, nil
+}
+
+func (m *Memory) UpdateMangaLastChapter(mangaID int, chapterURL, chapterTitle string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	manga, ok := m.manga[mangaID]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	manga.LastChapterURL = chapterURL
+	manga.LastChapterTitle = chapterTitle
+	manga.LastCheckAt = &now
+	manga.UpdatedAt = now
+	m.manga[mangaID] = manga
+	return nil
+}
+
+func (m *Memory) UpdateMangaLastCheck(mangaID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	manga, ok := m.manga[mangaID]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	manga.LastCheckAt = &now
+	manga.UpdatedAt = now
+	m.manga[mangaID] = manga
+	return nil
+}
+
+func (m *Memory) GetMangaWithSubscribers(mangaID int) (*types.Manga, error) {
+	manga, err := m.GetMangaByID(mangaID)
+	if err != nil || manga == nil {
+		return manga, err
+	}
+
+	subscribers, err := m.GetMangaSubscribers(mangaID)
+	if err != nil {
+		return nil, err
+	}
+
+	manga.Subscribers = subscribers
+	return manga, nil
+}
+
+func (m *Memory) GetMangaSubscribers(mangaID int) ([]types.TelegramUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var subscribers []types.TelegramUser
+	for _, sub := range m.sortedSubscriptions() {
+		if sub.MangaID != mangaID || !sub.Notify {
+			continue
+		}
+		if user, ok := m.users[sub.TelegramUserID]; ok && user.IsActive {
+			subscribers = append(subscribers, user)
+		}
+	}
+	return subscribers, nil
+}
+
+// SearchManga ранжирует названия так же, как db.Store на SQLite
+func (m *Memory) SearchManga(text string, userID int64, limit int) ([]types.MangaSearchResult, error) {
+	key := utils.SearchKey(text)
+	if key == "" || limit <= 0 {
+		return nil, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var candidates []types.MangaSearchResult
+	for _, manga := range m.manga {
+		if _, subscribed := m.subscriptions[subscriptionKey{userID: userID, mangaID: manga.ID}]; userID != 0 && !subscribed {
+			continue
+		}
+
+		source := m.sources[manga.SourceID]
+		result := types.MangaSearchResult{
+			MangaWithSource: types.MangaWithSource{
+				Manga:         manga,
+				SourceName:    string(source.ParserName),
+				SourceBaseURL: source.BaseURL,
+			},
+			MatchedTitle: manga.Title,
+		}
+		candidates = append(candidates, result)
+
+		if manga.WorkID == 0 {
+			continue
+		}
+		for _, title := range m.workTitles {
+			if title.workID == manga.WorkID {
+				result.MatchedTitle = title.title
+				candidates = append(candidates, result)
+			}
+		}
+	}
+
+	return RankSearchResults(key, candidates, limit), nil
+}
+
+// ---- Главы ----
+
+func (m *Memory) GetChaptersByMangaID(mangaID int) ([]types.Chapter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var chapters []types.Chapter
+	for _, ch := range m.chapters {
+		if ch.MangaID == mangaID {
+			chapters = append(chapters, ch)
+		}
+	}
+
+	// Как в Postgres: сначала свежие, при равном времени — позже добавленные
+	sort.Slice(chapters, func(i, j int) bool {
+		if !chapters[i].DiscoveredAt.Equal(chapters[j].DiscoveredAt) {
+			return chapters[i].DiscoveredAt.After(chapters[j].DiscoveredAt)
+		}
+		return chapters[i].ID > chapters[j].ID
+	})
+	return chapters, nil
+}
+
+func (m *Memory) CreateChapter(mangaID int, url, title, number, translator string) (*types.Chapter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.createChapter(mangaID, types.Chapter{URL: url, Title: title, Number: number, Translator: translator}, m.now()), nil
+}
+
+// CreateChapters как в db.Store: у глав одного вызова одно время обнаружения
+func (m *Memory) CreateChapters(mangaID int, chapters []types.Chapter) ([]types.Chapter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var newChapters []types.Chapter
+
+	for _, ch := range chapters {
+		if created := m.createChapter(mangaID, ch, now); created != nil {
+			newChapters = append(newChapters, *created)
+		}
+	}
+
+	return newChapters, nil
+}
+
+// createChapter сохраняет главу, если её URL у манги ещё нет (вызывать под m.mu)
+func (m *Memory) createChapter(mangaID int, chapter types.Chapter, discoveredAt time.Time) *types.Chapter {
+	for _, ch := range m.chapters {
+		if ch.MangaID == mangaID && ch.URL == chapter.URL {
+			return nil
+		}
+	}
+
+	ch := types.Chapter{
+		ID:           m.id(),
+		MangaID:      mangaID,
+		URL:          chapter.URL,
+		Title:        chapter.Title,
+		Number:       chapter.Number,
+		Translator:   chapter.Translator,
+		DiscoveredAt: discoveredAt,
+	}
+	m.chapters[ch.ID] = ch
+	return &ch
+}
+
+func (m *Memory) IngestChapters(mangaID, workID int, chapters []types.Chapter) ([]types.Chapter, error) {
+	var filtered []types.Chapter
+	for _, ch := range chapters {
+		if ch.URL != "" {
+			filtered = append(filtered, ch)
+		}
+	}
+
+	newChapters, err := m.CreateChapters(mangaID, filtered)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(newChapters) > 0 {
+		err = m.UpdateMangaLastChapter(mangaID, newChapters[0].URL, newChapters[0].Title)
+	} else {
+		err = m.UpdateMangaLastCheck(mangaID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if workID != 0 && len(newChapters) > 0 {
+		if err := m.enqueueChapterNotifications(workID, newChapters); err != nil {
+			return nil, err
+		}
+	}
+
+	return newChapters, nil
+}
+
+func (m *Memory) GetChapterByURL(url string) (*types.Chapter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var found *types.Chapter
+	for _, ch := range m.chapters {
+		if ch.URL != url {
+			continue
+		}
+		if found == nil || ch.DiscoveredAt.Before(found.DiscoveredAt) || (ch.DiscoveredAt.Equal(found.DiscoveredAt) && ch.ID < found.ID) {
+			ch := ch
+			found = &ch
+		}
+	}
+	return found, nil
+}
+
+func (m *Memory) ChapterExists(mangaID int, url string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, ch := range m.chapters {
+		if ch.MangaID == mangaID && ch.URL == url {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Memory) SetChapterNumber(chapterID int, number string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ch, ok := m.chapters[chapterID]; ok {
+		ch.Number = number
+		m.chapters[chapterID] = ch
+	}
+	return nil
+}
+
+func (m *Memory) GetMangaTranslators(mangaID int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var translators []string
+	for _, ch := range m.chapters {
+		if ch.MangaID == mangaID && ch.Translator != "" && !seen[ch.Translator] {
+			seen[ch.Translator] = true
+			translators = append(translators, ch.Translator)
+		}
+	}
+	sort.Strings(translators)
+	return translators, nil
+}
+
+// ---- Пользователи ----
+
+func (m *Memory) GetOrCreateUser(id int64, username, firstName, lastName string) (*types.TelegramUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	user, ok := m.users[id]
+	if !ok {
+		user = types.TelegramUser{ID: id, IsActive: true, CreatedAt: now}
+	}
+
+	user.Username = username
+	user.FirstName = firstName
+	user.LastName = lastName
+	user.IsActive = true
+	user.UpdatedAt = now
+	m.users[id] = user
+	delete(m.userInactiveSince, id)
+
+	return &user, nil
+}
+
+func (m *Memory) GetUserByID(id int64) (*types.TelegramUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+// SetUserActive отмечает пользователя активным или неактивным и запоминает, с какого момента он неактивен
+func (m *Memory) SetUserActive(id int64, active bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return nil
+	}
+
+	user.IsActive = active
+	user.UpdatedAt = time.Now()
+	m.users[id] = user
+
+	if active {
+		delete(m.userInactiveSince, id)
+	} else if _, ok := m.userInactiveSince[id]; !ok {
+		m.userInactiveSince[id] = time.Now()
+	}
+	return nil
+}
+
+// ---- Подписки ----
+
+// sortedSubscriptions подписки в порядке создания (вызывать под m.mu)
+func (m *Memory) sortedSubscriptions() []types.UserSubscription {
+	subs := make([]types.UserSubscription, 0, len(m.subscriptions))
+	for _, sub := range m.subscriptions {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	return subs
+}
+
+func (m *Memory) CreateSubscription(userID int64, mangaID int) (*types.UserSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, fmt.Errorf("ошибка создания подписки: пользователь %d не найден", userID)
+	}
+	if _, ok := m.manga[mangaID]; !ok {
+		return nil, fmt.Errorf("ошибка создания подписки: манга %d не найдена", mangaID)
+	}
+
+	key := subscriptionKey{userID: userID, mangaID: mangaID}
+
+	sub, ok := m.subscriptions[key]
+	if !ok {
+		sub = types.UserSubscription{
+			ID:             m.id(),
+			TelegramUserID: userID,
+			MangaID:        mangaID,
+			TeamMode:       types.TeamModeAll,
+			CreatedAt:      time.Now(),
+		}
+	}
+	sub.Notify = true
+	sub.MutedAt = nil
+	sub.MutedUntil = nil
+	m.subscriptions[key] = sub
+
+	delete(m.orphanedSince, mangaID)
+	delete(m.frozen, mangaID)
+
+	return &sub, nil
+}
+
+func (m *Memory) GetSubscription(userID int64, mangaID int) (*types.UserSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.subscriptions[subscriptionKey{userID: userID, mangaID: mangaID}]
+	if !ok {
+		return nil, nil
+	}
+	return &sub, nil
+}
+
+func (m *Memory) DeleteSubscription(userID int64, mangaID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.subscriptions, subscriptionKey{userID: userID, mangaID: mangaID})
+	return nil
+}
+
+func (m *Memory) UpdateSubscriptionTeam(userID int64, mangaID int, mode types.TeamMode, team string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := subscriptionKey{userID: userID, mangaID: mangaID}
+	if sub, ok := m.subscriptions[key]; ok {
+		sub.TeamMode = mode
+		sub.PreferredTeam = team
+		m.subscriptions[key] = sub
+	}
+	return nil
+}
+
+func (m *Memory) GetUserSubscriptions(userID int64) ([]types.SubscribedManga, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var mangaList []types.SubscribedManga
+	for _, sub := range m.subscriptions {
+		if sub.TelegramUserID != userID {
+			continue
+		}
+
+		manga, ok := m.manga[sub.MangaID]
+		if !ok {
+			continue
+		}
+		source := m.sources[manga.SourceID]
+
+		mangaList = append(mangaList, types.SubscribedManga{
+			MangaWithSource: types.MangaWithSource{
+				Manga:         manga,
+				SourceName:    string(source.ParserName),
+				SourceBaseURL: source.BaseURL,
+			},
+			Subscription: sub,
+		})
+	}
+
+	sort.Slice(mangaList, func(i, j int) bool {
+		if mangaList[i].SourceName != mangaList[j].SourceName {
+			return mangaList[i].SourceName < mangaList[j].SourceName
+		}
+		return mangaList[i].Title < mangaList[j].Title
+	})
+	return mangaList, nil
+}
+
+func (m *Memory) MuteSubscription(userID int64, mangaID int, until *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := subscriptionKey{userID: userID, mangaID: mangaID}
+	if sub, ok := m.subscriptions[key]; ok {
+		if sub.MutedAt == nil {
+			now := m.now()
+			sub.MutedAt = &now
+		}
+		sub.Notify = false
+		sub.MutedUntil = until
+		m.subscriptions[key] = sub
+	}
+	return nil
+}
+
+func (m *Memory) UnmuteSubscription(userID int64, mangaID int) ([]types.Chapter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := subscriptionKey{userID: userID, mangaID: mangaID}
+	sub, ok := m.subscriptions[key]
+	if !ok || sub.Notify {
+		return nil, nil
+	}
+
+	missed := m.missedChapters(sub)
+	if m.users[userID].IsActive && len(missed) > 0 {
+		m.insertNotification(userID, mangaID, missed, time.Now())
+	}
+
+	m.unmute(key)
+	return missed, nil
+}
+
+func (m *Memory) ResumeExpiredMutes(now time.Time) ([]types.UserSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var resumed []types.UserSubscription
+	for _, sub := range m.sortedSubscriptions() {
+		if sub.Notify || sub.MutedUntil == nil || sub.MutedUntil.After(now) {
+			continue
+		}
+
+		missed := m.missedChapters(sub)
+		if m.users[sub.TelegramUserID].IsActive && len(missed) > 0 {
+			m.insertNotification(sub.TelegramUserID, sub.MangaID, missed, now)
+		}
+
+		m.unmute(subscriptionKey{userID: sub.TelegramUserID, mangaID: sub.MangaID})
+		resumed = append(resumed, sub)
+	}
+	return resumed, nil
+}
+
+// missedChapters главы манги, найденные после приглушения подписки (свежие первыми)
+// missedChapters повторяет db: главы всех манги произведения подписки, свежие первыми (вызывать под m.mu)
+func (m *Memory) missedChapters(sub types.UserSubscription) []types.Chapter {
+	workID := m.manga[sub.MangaID].WorkID
+
+	var chapters []types.Chapter
+	for _, ch := range m.chapters {
+		if ch.MangaID == sub.MangaID || (workID != 0 && m.manga[ch.MangaID].WorkID == workID) {
+			chapters = append(chapters, ch)
+		}
+	}
+
+	sort.Slice(chapters, func(i, j int) bool {
+		if !chapters[i].DiscoveredAt.Equal(chapters[j].DiscoveredAt) {
+			return chapters[i].DiscoveredAt.After(chapters[j].DiscoveredAt)
+		}
+		return chapters[i].ID < chapters[j].ID
+	})
+	return sub.MissedChapters(chapters)
+}
+
+func (m *Memory) unmute(key subscriptionKey) {
+	sub := m.subscriptions[key]
+	sub.Notify = true
+	sub.MutedAt = nil
+	sub.MutedUntil = nil
+	m.subscriptions[key] = sub
+}
+
+// ---- Произведения ----
+
+func (m *Memory) GetWorkByID(id int) (*types.Work, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	work, ok := m.works[id]
+	if !ok {
+		return nil, nil
+	}
+	return &work, nil
+}
+
+func (m *Memory) GetWorkByTitle(title string) (*types.Work, error) {
+	normalized := utils.NormalizeTitle(title)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	known, ok := m.workTitles[normalized]
+	if !ok {
+		return nil, nil
+	}
+	work := m.works[known.workID]
+	return &work, nil
+}
+
+func (m *Memory) CreateWork(title string) (*types.Work, error) {
+	m.mu.Lock()
+
+	now := time.Now()
+	work := types.Work{ID: m.id(), Title: title, CreatedAt: now, UpdatedAt: now}
+	m.works[work.ID] = work
+
+	m.mu.Unlock()
+
+	if err := m.AddWorkTitle(work.ID, title); err != nil {
+		return nil, err
+	}
+	return &work, nil
+}
+
+func (m *Memory) AddWorkTitle(workID int, title string) error {
+	normalized := utils.NormalizeTitle(title)
+	if normalized == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.works[workID]; !ok {
+		return fmt.Errorf("ошибка добавления названия произведения: произведение %d не найдено", workID)
+	}
+	if _, taken := m.workTitles[normalized]; !taken {
+		m.workTitles[normalized] = workTitle{workID: workID, title: title}
+	}
+	return nil
+}
+
+func (m *Memory) SetMangaWork(mangaID, workID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if manga, ok := m.manga[mangaID]; ok {
+		manga.WorkID = workID
+		manga.UpdatedAt = time.Now()
+		m.manga[mangaID] = manga
+	}
+	return nil
+}
+
+func (m *Memory) ClaimChapterAnnouncements(workID int, chapters []types.Chapter) ([]types.Chapter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var claimed []types.Chapter
+
+	for _, ch := range chapters {
+		if ch.Number == "" {
+			ch.IsFirstRelease = true
+			claimed = append(claimed, ch)
+			continue
+		}
+
+		announced := false
+		for key := range m.announcements {
+			if key.workID == workID && key.number == ch.Number {
+				announced = true
+				break
+			}
+		}
+
+		key := announcementKey{workID: workID, number: ch.Number, translator: ch.Translator}
+		if _, exists := m.announcements[key]; exists {
+			continue
+		}
+		m.announcements[key] = ch.ID
+
+		ch.IsFirstRelease = !announced
+		claimed = append(claimed, ch)
+	}
+
+	return claimed, nil
+}
+
+func (m *Memory) GetWorkSubscribers(workID int) ([]types.WorkSubscriber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := make(map[int64]bool)
+
+	var subscribers []types.WorkSubscriber
+	for _, sub := range m.sortedSubscriptions() {
+		if !sub.Notify || seen[sub.TelegramUserID] {
+			continue
+		}
+		if manga, ok := m.manga[sub.MangaID]; !ok || manga.WorkID != workID {
+			continue
+		}
+		user, ok := m.users[sub.TelegramUserID]
+		if !ok || !user.IsActive {
+			continue
+		}
+
+		seen[user.ID] = true
+		subscribers = append(subscribers, types.WorkSubscriber{TelegramUser: user, Subscription: sub})
+	}
+
+	return subscribers, nil
+}
+
+func (m *Memory) MergeWorks(targetID, sourceID int) error {
+	if targetID == sourceID {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, manga := range m.manga {
+		if manga.WorkID == sourceID {
+			manga.WorkID = targetID
+			manga.UpdatedAt = time.Now()
+			m.manga[id] = manga
+		}
+	}
+
+	for normalized, title := range m.workTitles {
+		if title.workID == sourceID {
+			title.workID = targetID
+			m.workTitles[normalized] = title
+		}
+	}
+
+	for key, chapterID := range m.announcements {
+		if key.workID != sourceID {
+			continue
+		}
+		delete(m.announcements, key)
+
+		key.workID = targetID
+		if _, exists := m.announcements[key]; !exists {
+			m.announcements[key] = chapterID
+		}
+	}
+
+	delete(m.works, sourceID)
+	return nil
+}
+
+// ---- История проверок ----
+
+func (m *Memory) RecordCrawl(record types.CrawlRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record.ID = m.id()
+	record.CreatedAt = time.Now()
+	m.crawls = append(m.crawls, record)
+	return nil
+}
+
+func (m *Memory) GetMangaCrawlHistory(mangaID int, limit int) ([]types.CrawlRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var history []types.CrawlRecord
+	for i := len(m.crawls) - 1; i >= 0 && len(history) < limit; i-- {
+		if m.crawls[i].MangaID == mangaID {
+			history = append(history, m.crawls[i])
+		}
+	}
+	return history, nil
+}
+
+// ---- Уведомления ----
+
+// enqueueChapterNotifications повторяет db: анонс глав и уведомление каждому подписчику произведения
+// о манге его подписки
+func (m *Memory) enqueueChapterNotifications(workID int, newChapters []types.Chapter) error {
+	announced, err := m.ClaimChapterAnnouncements(workID, newChapters)
+	if err != nil || len(announced) == 0 {
+		return err
+	}
+
+	subscribers, err := m.GetWorkSubscribers(workID)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, subscriber := range subscribers {
+		wanted := subscriber.Subscription.WantedChapters(announced)
+		if len(wanted) == 0 {
+			continue
+		}
+		m.insertNotification(subscriber.ID, subscriber.Subscription.MangaID, wanted, now)
+	}
+	return nil
+}
+
+// insertNotification ставит уведомление в очередь (вызывать под m.mu)
+func (m *Memory) insertNotification(userID int64, mangaID int, chapters []types.Chapter, now time.Time) {
+	m.notifications = append(m.notifications, types.Notification{
+		ID:            m.id(),
+		UserID:        userID,
+		MangaID:       mangaID,
+		Chapters:      chapters,
+		Status:        types.NotificationPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+}
+
+func (m *Memory) GetDueNotifications(now time.Time, limit int) ([]types.Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var due []types.Notification
+	for _, n := range m.notifications {
+		if len(due) >= limit {
+			break
+		}
+		if n.Status == types.NotificationPending && !n.NextAttemptAt.After(now) {
+			due = append(due, n)
+		}
+	}
+	return due, nil
+}
+
+// updateNotification применяет изменение к уведомлению по ID
+func (m *Memory) updateNotification(id int, update func(n *types.Notification)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.notifications {
+		if m.notifications[i].ID == id {
+			update(&m.notifications[i])
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *Memory) MarkNotificationSent(id int) error {
+	return m.updateNotification(id, func(n *types.Notification) {
+		now := time.Now()
+		n.Status = types.NotificationSent
+		n.Attempts++
+		n.LastError = ""
+		n.SentAt = &now
+	})
+}
+
+func (m *Memory) MarkNotificationRetry(id int, lastError string, nextAttemptAt time.Time) error {
+	return m.updateNotification(id, func(n *types.Notification) {
+		n.Attempts++
+		n.LastError = lastError
+		n.NextAttemptAt = nextAttemptAt
+	})
+}
+
+func (m *Memory) MarkNotificationFailed(id int, lastError string) error {
+	return m.updateNotification(id, func(n *types.Notification) {
+		n.Status = types.NotificationFailed
+		n.Attempts++
+		n.LastError = lastError
+	})
+}
+
+// ---- Журнал событий ----
+
+func (m *Memory) RecordEvent(event types.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Как в db: подробности хранятся в JSON, при чтении числа становятся float64
+	if len(event.Payload) > 0 {
+		payload, err := json.Marshal(event.Payload)
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации события: %w", err)
+		}
+		event.Payload = nil
+		if err := json.Unmarshal(payload, &event.Payload); err != nil {
+			return fmt.Errorf("ошибка сериализации события: %w", err)
+		}
+	}
+
+	event.ID = int64(m.id())
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *Memory) GetEvents(filter types.EventFilter) ([]types.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var events []types.Event
+	for _, e := range m.events {
+		switch {
+		case filter.Type != "" && e.Type != filter.Type,
+			filter.ActorID != 0 && e.ActorID != filter.ActorID,
+			filter.UserID != 0 && e.UserID != filter.UserID,
+			filter.MangaID != 0 && e.MangaID != filter.MangaID,
+			filter.SourceID != 0 && e.SourceID != filter.SourceID,
+			!filter.Since.IsZero() && e.CreatedAt.Before(filter.Since),
+			!filter.Until.IsZero() && !e.CreatedAt.Before(filter.Until):
+			continue
+		}
+		events = append(events, e)
+	}
+
+	// Как в db: новые первыми, при равном времени — добавленные позже
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.After(events[j].CreatedAt)
+		}
+		return events[i].ID > events[j].ID
+	})
+
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// ---- Обслуживание ----
+
+// RunMaintenance повторяет db: при dryRun только считает, ничего не меняя
+func (m *Memory) RunMaintenance(policy types.RetentionPolicy, now time.Time, dryRun bool) (types.MaintenanceReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := types.MaintenanceReport{DryRun: dryRun}
+
+	if policy.ChaptersPerManga > 0 {
+		byManga := make(map[int][]types.Chapter)
+		for _, ch := range m.chapters {
+			byManga[ch.MangaID] = append(byManga[ch.MangaID], ch)
+		}
+
+		for _, chapters := range byManga {
+			if len(chapters) <= policy.ChaptersPerManga {
+				continue
+			}
+			sort.Slice(chapters, func(i, j int) bool {
+				if !chapters[i].DiscoveredAt.Equal(chapters[j].DiscoveredAt) {
+					return chapters[i].DiscoveredAt.After(chapters[j].DiscoveredAt)
+				}
+				// Как в db.Store: главы одной проверки сохранены в порядке фида, свежие первыми
+				return chapters[i].ID < chapters[j].ID
+			})
+
+			for _, ch := range chapters[policy.ChaptersPerManga:] {
+				report.ChaptersDeleted++
+				if policy.ArchiveChapters {
+					report.ChaptersArchived++
+				}
+				if !dryRun {
+					if policy.ArchiveChapters {
+						m.chaptersArchive[ch.ID] = ch
+					}
+					m.deleteChapter(ch.ID)
+				}
+			}
+		}
+	}
+
+	if policy.OrphanGrace > 0 {
+		subscribed := make(map[int]bool)
+		for key := range m.subscriptions {
+			subscribed[key.mangaID] = true
+		}
+
+		cutoff := now.Add(-policy.OrphanGrace)
+
+		for id := range m.manga {
+			since, orphaned := m.orphanedSince[id]
+			if subscribed[id] {
+				if orphaned && !dryRun {
+					delete(m.orphanedSince, id)
+					delete(m.frozen, id)
+				}
+				continue
+			}
+
+			if !orphaned {
+				report.MangaOrphaned++
+				since = now
+				if !dryRun {
+					m.orphanedSince[id] = now
+				}
+			}
+
+			if since.After(cutoff) {
+				continue
+			}
+
+			if policy.OrphanAction == types.OrphanDelete {
+				report.MangaDeleted++
+				if !dryRun {
+					m.deleteManga(id)
+				}
+			} else if _, frozen := m.frozen[id]; !frozen {
+				report.MangaFrozen++
+				if !dryRun {
+					m.frozen[id] = now
+				}
+			}
+		}
+	}
+
+	if policy.InactiveUserGrace > 0 {
+		cutoff := now.Add(-policy.InactiveUserGrace)
+		for id, since := range m.userInactiveSince {
+			if user, ok := m.users[id]; !ok || user.IsActive || since.After(cutoff) {
+				continue
+			}
+			report.UsersDeleted++
+			if !dryRun {
+				m.deleteUser(id)
+			}
+		}
+	}
+
+	if policy.NotificationsTTL > 0 {
+		cutoff := now.Add(-policy.NotificationsTTL)
+		var kept []types.Notification
+		for _, n := range m.notifications {
+			if n.Status != types.NotificationPending && !n.CreatedAt.After(cutoff) {
+				report.NotificationsDeleted++
+				continue
+			}
+			kept = append(kept, n)
+		}
+		if !dryRun {
+			m.notifications = kept
+		}
+	}
+
+	if policy.CrawlHistoryTTL > 0 {
+		cutoff := now.Add(-policy.CrawlHistoryTTL)
+		var kept []types.CrawlRecord
+		for _, record := range m.crawls {
+			if !record.CreatedAt.After(cutoff) {
+				report.CrawlHistoryDeleted++
+				continue
+			}
+			kept = append(kept, record)
+		}
+		if !dryRun {
+			m.crawls = kept
+		}
+	}
+
+	if policy.EventsTTL > 0 {
+		cutoff := now.Add(-policy.EventsTTL)
+		var kept []types.Event
+		for _, event := range m.events {
+			if !event.CreatedAt.After(cutoff) {
+				report.EventsDeleted++
+				continue
+			}
+			kept = append(kept, event)
+		}
+		if !dryRun {
+			m.events = kept
+		}
+	}
+
+	return report, nil
+}
+
+// deleteChapter удаляет главу; анонсы остаются без ссылки на главу (как ON DELETE SET NULL)
+func (m *Memory) deleteChapter(id int) {
+	delete(m.chapters, id)
+	for key, chapterID := range m.announcements {
+		if chapterID == id {
+			m.announcements[key] = 0
+		}
+	}
+}
+
+// deleteManga удаляет мангу со всем зависимым (как ON DELETE CASCADE)
+func (m *Memory) deleteManga(id int) {
+	delete(m.manga, id)
+	delete(m.orphanedSince, id)
+	delete(m.frozen, id)
+
+	for chapterID, ch := range m.chapters {
+		if ch.MangaID == id {
+			m.deleteChapter(chapterID)
+		}
+	}
+	for chapterID, ch := range m.chaptersArchive {
+		if ch.MangaID == id {
+			delete(m.chaptersArchive, chapterID)
+		}
+	}
+	for key := range m.subscriptions {
+		if key.mangaID == id {
+			delete(m.subscriptions, key)
+		}
+	}
+
+	var crawls []types.CrawlRecord
+	for _, record := range m.crawls {
+		if record.MangaID != id {
+			crawls = append(crawls, record)
+		}
+	}
+	m.crawls = crawls
+
+	var notifications []types.Notification
+	for _, n := range m.notifications {
+		if n.MangaID != id {
+			notifications = append(notifications, n)
+		}
+	}
+	m.notifications = notifications
+}
+
+// deleteUser удаляет пользователя с подписками и уведомлениями
+func (m *Memory) deleteUser(id int64) {
+	delete(m.users, id)
+	delete(m.userInactiveSince, id)
+
+	for key := range m.subscriptions {
+		if key.userID == id {
+			delete(m.subscriptions, key)
+		}
+	}
+
+	var notifications []types.Notification
+	for _, n := range m.notifications {
+		if n.UserID != id {
+			notifications = append(notifications, n)
+		}
+	}
+	m.notifications = notifications
+}
@@ -0,0 +1,18 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store/storetest"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+func TestMemoryContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		m := store.NewMemory()
+		m.AddSource(types.SourceReadmanga, "https://a.zazaza.me")
+		m.AddSource(types.SourceMintmanga, "https://1.seimanga.me")
+		return m
+	})
+}
@@ -0,0 +1,241 @@
+// Package store описывает хранилище бота: интерфейсы репозиториев и общую логику поверх них.
+// Реализации: db.Store (PostgreSQL или SQLite) и Memory (в памяти, для тестов и локальных прогонов).
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/utils"
+)
+
+// SourceRepository источники манги
+type SourceRepository interface {
+	GetActiveSources() ([]types.Source, error)
+	GetSourceByID(id int) (*types.Source, error)
+	GetSourceByName(parserName types.SourceName) (*types.Source, error)
+	// GetSourceByBaseURL ищет активный источник по точному совпадению или вхождению хоста
+	GetSourceByBaseURL(baseURL string) (*types.Source, error)
+	SetSourceActive(id int, active bool) error
+}
+
+// MangaRepository отслеживаемая манга
+type MangaRepository interface {
+	GetMangaBySourceID(sourceID int) ([]types.Manga, error)
+	GetMangaByID(id int) (*types.Manga, error)
+	GetMangaBySourceAndURL(sourceID int, url string) (*types.Manga, error)
+	CreateManga(sourceID int, url, title string) (*types.Manga, error)
+	UpdateMangaLastChapter(mangaID int, chapterURL, chapterTitle string) error
+	UpdateMangaLastCheck(mangaID int) error
+	GetMangaWithSubscribers(mangaID int) (*types.Manga, error)
+	GetMangaSubscribers(mangaID int) ([]types.TelegramUser, error)
+	// SearchManga ищет мангу по названию и альтернативным названиям произведения с учётом опечаток
+	// и транслитерации (см. utils.SearchKey). При userID != 0 — только среди подписок пользователя.
+	// Результаты отсортированы по убыванию похожести.
+	SearchManga(text string, userID int64, limit int) ([]types.MangaSearchResult, error)
+}
+
+// ChapterRepository главы манги
+type ChapterRepository interface {
+	GetChaptersByMangaID(mangaID int) ([]types.Chapter, error)
+	// CreateChapter возвращает nil, если глава с таким URL у манги уже есть
+	CreateChapter(mangaID int, url, title, number, translator string) (*types.Chapter, error)
+	// CreateChapters сохраняет главы и возвращает только новые
+	CreateChapters(mangaID int, chapters []types.Chapter) ([]types.Chapter, error)
+	// IngestChapters атомарно сохраняет главы проверки, обновляет последнюю главу и время проверки манги.
+	// Если задан workID, в той же транзакции анонсирует новые главы в рамках произведения
+	// и ставит в очередь уведомления подписчикам (см. NotificationRepository).
+	// Возвращает только новые главы в порядке входного списка.
+	IngestChapters(mangaID, workID int, chapters []types.Chapter) ([]types.Chapter, error)
+	GetChapterByURL(url string) (*types.Chapter, error)
+	ChapterExists(mangaID int, url string) (bool, error)
+	SetChapterNumber(chapterID int, number string) error
+	GetMangaTranslators(mangaID int) ([]string, error)
+}
+
+// UserRepository пользователи Telegram
+type UserRepository interface {
+	GetOrCreateUser(id int64, username, firstName, lastName string) (*types.TelegramUser, error)
+	GetUserByID(id int64) (*types.TelegramUser, error)
+	// SetUserActive отмечает пользователя активным или неактивным (например, заблокировал бота)
+	SetUserActive(id int64, active bool) error
+}
+
+// SubscriptionRepository подписки пользователей на мангу
+type SubscriptionRepository interface {
+	CreateSubscription(userID int64, mangaID int) (*types.UserSubscription, error)
+	GetSubscription(userID int64, mangaID int) (*types.UserSubscription, error)
+	DeleteSubscription(userID int64, mangaID int) error
+	UpdateSubscriptionTeam(userID int64, mangaID int, mode types.TeamMode, team string) error
+	// GetUserSubscriptions возвращает все подписки пользователя, включая приглушённые
+	GetUserSubscriptions(userID int64) ([]types.SubscribedManga, error)
+	// MuteSubscription приглушает уведомления по подписке до until (nil — бессрочно).
+	// Манга продолжает проверяться, пропущенные главы считаются от первого приглушения.
+	MuteSubscription(userID int64, mangaID int, until *time.Time) error
+	// UnmuteSubscription возобновляет уведомления, ставит в очередь уведомление о пропущенных главах
+	// и возвращает их (свежие первыми)
+	UnmuteSubscription(userID int64, mangaID int) ([]types.Chapter, error)
+	// ResumeExpiredMutes возобновляет подписки с истёкшим сроком приглушения, ставит в очередь
+	// уведомления о пропущенных главах и возвращает возобновлённые подписки
+	ResumeExpiredMutes(now time.Time) ([]types.UserSubscription, error)
+}
+
+// WorkRepository произведения — группы манги с разных источников
+type WorkRepository interface {
+	GetWorkByID(id int) (*types.Work, error)
+	GetWorkByTitle(title string) (*types.Work, error)
+	CreateWork(title string) (*types.Work, error)
+	AddWorkTitle(workID int, title string) error
+	SetMangaWork(mangaID, workID int) error
+	// ClaimChapterAnnouncements отмечает главы анонсированными и возвращает ещё не объявленные
+	ClaimChapterAnnouncements(workID int, chapters []types.Chapter) ([]types.Chapter, error)
+	GetWorkSubscribers(workID int) ([]types.WorkSubscriber, error)
+	MergeWorks(targetID, sourceID int) error
+}
+
+// CrawlRepository история проверок
+type CrawlRepository interface {
+	RecordCrawl(record types.CrawlRecord) error
+	GetMangaCrawlHistory(mangaID int, limit int) ([]types.CrawlRecord, error)
+}
+
+// NotificationRepository исходящие уведомления (outbox).
+// Уведомления создаются в IngestChapters и доставляются диспетчером: минимум один раз,
+// без потерь при перезапуске.
+type NotificationRepository interface {
+	// GetDueNotifications возвращает ожидающие уведомления, срок попытки которых наступил, в порядке создания
+	GetDueNotifications(now time.Time, limit int) ([]types.Notification, error)
+	MarkNotificationSent(id int) error
+	// MarkNotificationRetry учитывает неудачную попытку и откладывает следующую до nextAttemptAt
+	MarkNotificationRetry(id int, lastError string, nextAttemptAt time.Time) error
+	// MarkNotificationFailed учитывает неудачную попытку и прекращает доставку
+	MarkNotificationFailed(id int, lastError string) error
+}
+
+// EventRepository журнал действий пользователей и администраторов (только добавление)
+type EventRepository interface {
+	RecordEvent(event types.Event) error
+	// GetEvents возвращает события по фильтру, новые первыми
+	GetEvents(filter types.EventFilter) ([]types.Event, error)
+}
+
+// MaintenanceRepository обслуживание хранилища
+type MaintenanceRepository interface {
+	// RunMaintenance применяет политику хранения одной транзакцией. При dryRun изменения откатываются,
+	// а отчёт показывает, что было бы сделано.
+	RunMaintenance(policy types.RetentionPolicy, now time.Time, dryRun bool) (types.MaintenanceReport, error)
+}
+
+// Store всё хранилище бота
+type Store interface {
+	SourceRepository
+	MangaRepository
+	ChapterRepository
+	UserRepository
+	SubscriptionRepository
+	WorkRepository
+	CrawlRepository
+	NotificationRepository
+	EventRepository
+	MaintenanceRepository
+}
+
+// EnsureMangaWork возвращает произведение манги, при необходимости создавая его.
+// Непривязанная манга сопоставляется с существующим произведением по названию,
+// а уже известные главы помечаются анонсированными, чтобы не объявлять их повторно.
+func EnsureMangaWork(s Store, manga *types.Manga) (int, error) {
+	if manga.WorkID != 0 {
+		return manga.WorkID, nil
+	}
+
+	work, err := s.GetWorkByTitle(manga.Title)
+	if err != nil {
+		return 0, err
+	}
+
+	if work == nil {
+		work, err = s.CreateWork(manga.Title)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := s.SetMangaWork(manga.ID, work.ID); err != nil {
+		return 0, err
+	}
+
+	if err := SeedWorkAnnouncements(s, work.ID, manga.ID); err != nil {
+		return 0, err
+	}
+
+	manga.WorkID = work.ID
+	return work.ID, nil
+}
+
+// SeedWorkAnnouncements проставляет номера уже сохранённым главам манги
+// и отмечает их анонсированными в рамках произведения
+func SeedWorkAnnouncements(s Store, workID, mangaID int) error {
+	chapters, err := s.GetChaptersByMangaID(mangaID)
+	if err != nil {
+		return err
+	}
+
+	for i := range chapters {
+		if chapters[i].Number != "" {
+			continue
+		}
+
+		chapters[i].Number = utils.ParseChapterNumber(chapters[i].Title, chapters[i].URL)
+		if chapters[i].Number == "" {
+			continue
+		}
+
+		if err := s.SetChapterNumber(chapters[i].ID, chapters[i].Number); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.ClaimChapterAnnouncements(workID, chapters)
+	return err
+}
+
+// SearchThreshold минимальная похожесть результата поиска
+// (как pg_trgm.word_similarity_threshold по умолчанию)
+const SearchThreshold = 0.6
+
+// RankSearchResults оценивает кандидатов поиска, где нет pg_trgm: для каждой манги оставляет
+// лучше всего совпавшее название, отбрасывает непохожие и сортирует по убыванию похожести
+func RankSearchResults(key string, candidates []types.MangaSearchResult, limit int) []types.MangaSearchResult {
+	best := make(map[int]types.MangaSearchResult)
+
+	for _, candidate := range candidates {
+		candidate.Score = utils.WordSimilarity(key, utils.SearchKey(candidate.MatchedTitle))
+		if candidate.Score < SearchThreshold {
+			continue
+		}
+		if current, ok := best[candidate.ID]; !ok || candidate.Score > current.Score {
+			best[candidate.ID] = candidate
+		}
+	}
+
+	results := make([]types.MangaSearchResult, 0, len(best))
+	for _, result := range best {
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Title != results[j].Title {
+			return results[i].Title < results[j].Title
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
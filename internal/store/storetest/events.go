@@ -0,0 +1,119 @@
+package storetest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+func testEvents(t *testing.T, s store.Store) {
+	berserk := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+	vagabond := createManga(t, s, types.SourceReadmanga, "vagabond", "Бродяга")
+	source, err := s.GetSourceByName(types.SourceMintmanga)
+	if err != nil || source == nil {
+		t.Fatalf("источник %s: %v", types.SourceMintmanga, err)
+	}
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	payload := map[string]any{
+		"team":   "Команда А",
+		"count":  3,
+		"muted":  true,
+		"nested": map[string]any{"mode": "team", "previous": nil},
+	}
+
+	// Время событий задано явно и идёт не в порядке добавления
+	events := []types.Event{
+		{Type: types.EventSubscribe, ActorID: 1001, UserID: 1001, MangaID: berserk.ID, Payload: payload, CreatedAt: at(1)},
+		{Type: types.EventUnsubscribe, ActorID: 1001, UserID: 1001, MangaID: berserk.ID, CreatedAt: at(3)},
+		{Type: types.EventSettings, ActorID: 1001, UserID: 1001, MangaID: vagabond.ID, CreatedAt: at(2)},
+		{Type: types.EventSourceToggle, ActorID: 42, SourceID: source.ID, CreatedAt: at(4)},
+		{Type: types.EventSubscribe, ActorID: 1002, UserID: 1002, MangaID: berserk.ID, CreatedAt: at(5)},
+		{Type: types.EventUserBlocked, UserID: 1001, CreatedAt: at(3)}, // То же время, что у отписки, но добавлено позже
+	}
+	for _, event := range events {
+		if err := s.RecordEvent(event); err != nil {
+			t.Fatalf("RecordEvent: %v", err)
+		}
+	}
+
+	// Изменение исходных подробностей после записи не меняет журнал
+	payload["team"] = "Команда Б"
+
+	tests := []struct {
+		name   string
+		filter types.EventFilter
+		want   string
+	}{
+		{"все", types.EventFilter{}, "subscribe@5 source_toggle@4 user_blocked@3 unsubscribe@3 settings@2 subscribe@1"},
+		{"пользователь", types.EventFilter{UserID: 1001}, "user_blocked@3 unsubscribe@3 settings@2 subscribe@1"},
+		{"пользователь и манга", types.EventFilter{UserID: 1001, MangaID: berserk.ID}, "unsubscribe@3 subscribe@1"},
+		{"тип", types.EventFilter{Type: types.EventSubscribe}, "subscribe@5 subscribe@1"},
+		{"инициатор и тип", types.EventFilter{ActorID: 1001, Type: types.EventSettings}, "settings@2"},
+		{"система", types.EventFilter{Type: types.EventUserBlocked}, "user_blocked@3"},
+		{"источник", types.EventFilter{SourceID: source.ID}, "source_toggle@4"},
+		{"период", types.EventFilter{Since: at(2), Until: at(4)}, "user_blocked@3 unsubscribe@3 settings@2"},
+		{"лимит", types.EventFilter{Limit: 2}, "subscribe@5 source_toggle@4"},
+		{"лимит с фильтром", types.EventFilter{UserID: 1001, Limit: 1}, "user_blocked@3"},
+		{"ничего", types.EventFilter{UserID: 1001, SourceID: source.ID}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.GetEvents(tt.filter)
+			if err != nil {
+				t.Fatalf("GetEvents: %v", err)
+			}
+			if desc := describeEvents(base, got); desc != tt.want {
+				t.Errorf("события %q, ожидалось %q", desc, tt.want)
+			}
+		})
+	}
+
+	got, err := s.GetEvents(types.EventFilter{Type: types.EventSubscribe, UserID: 1001})
+	if err != nil || len(got) != 1 {
+		t.Fatalf("подписка пользователя: %d событий (ошибка: %v)", len(got), err)
+	}
+
+	// Подробности хранятся в JSON: числа возвращаются как float64
+	event := got[0]
+	want := map[string]any{
+		"team":   "Команда А",
+		"count":  float64(3),
+		"muted":  true,
+		"nested": map[string]any{"mode": "team", "previous": nil},
+	}
+	if !reflect.DeepEqual(event.Payload, want) {
+		t.Errorf("подробности %#v, ожидалось %#v", event.Payload, want)
+	}
+	if event.ID == 0 || event.ActorID != 1001 || event.MangaID != berserk.ID || event.SourceID != 0 {
+		t.Errorf("событие %+v", event)
+	}
+	if !event.CreatedAt.Equal(at(1)) {
+		t.Errorf("время события %s, ожидалось %s", event.CreatedAt, at(1))
+	}
+
+	toggle, err := s.GetEvents(types.EventFilter{SourceID: source.ID})
+	if err != nil || len(toggle) != 1 {
+		t.Fatalf("события источника: %d (ошибка: %v)", len(toggle), err)
+	}
+	if len(toggle[0].Payload) != 0 || toggle[0].UserID != 0 || toggle[0].MangaID != 0 {
+		t.Errorf("событие без подробностей и пользователя: %+v", toggle[0])
+	}
+}
+
+// describeEvents список событий вида "тип@минута от base"
+func describeEvents(base time.Time, events []types.Event) string {
+	parts := make([]string, len(events))
+	for i, e := range events {
+		parts[i] = fmt.Sprintf("%s@%d", e.Type, int(e.CreatedAt.Sub(base).Minutes()))
+	}
+	return strings.Join(parts, " ")
+}
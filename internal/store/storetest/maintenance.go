@@ -0,0 +1,317 @@
+package storetest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// Записи создаются с текущим временем, поэтому сроки хранения проверяются сдвигом now,
+// который передаётся в RunMaintenance, а не ожиданием
+const retentionGrace = 24 * time.Hour
+
+func testOrphanFreeze(t *testing.T, s store.Store) {
+	kept := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+	orphan := createManga(t, s, types.SourceReadmanga, "vagabond", "Бродяга")
+	user := createUser(t, s, 1001)
+	subscribe(t, s, user.ID, kept.ID)
+
+	policy := types.RetentionPolicy{OrphanGrace: retentionGrace, OrphanAction: types.OrphanFreeze}
+	now := time.Now()
+
+	// Отсрочка начинается с первого обслуживания, заметившего мангу без подписчиков
+	report := runMaintenance(t, s, policy, now, false)
+	if report.MangaOrphaned != 1 || report.MangaFrozen != 0 {
+		t.Errorf("первое обслуживание: без подписчиков %d, заморожено %d, ожидалось 1 и 0", report.MangaOrphaned, report.MangaFrozen)
+	}
+	assertCrawled(t, s, "в отсрочке", "berserk", "vagabond")
+
+	report = runMaintenance(t, s, policy, now.Add(retentionGrace+time.Hour), false)
+	if report.MangaOrphaned != 0 || report.MangaFrozen != 1 {
+		t.Errorf("после отсрочки: без подписчиков %d, заморожено %d, ожидалось 0 и 1", report.MangaOrphaned, report.MangaFrozen)
+	}
+	assertCrawled(t, s, "после заморозки", "berserk")
+
+	if report = runMaintenance(t, s, policy, now.Add(retentionGrace+2*time.Hour), false); report.MangaFrozen != 0 {
+		t.Errorf("замороженная манга заморожена повторно: %d", report.MangaFrozen)
+	}
+
+	// Новая подписка размораживает мангу сразу, не дожидаясь обслуживания
+	subscribe(t, s, user.ID, orphan.ID)
+	assertCrawled(t, s, "после подписки", "berserk", "vagabond")
+
+	if report = runMaintenance(t, s, policy, now.Add(retentionGrace+3*time.Hour), false); report.MangaOrphaned != 0 || report.MangaFrozen != 0 {
+		t.Errorf("манга с подписчиком: без подписчиков %d, заморожено %d", report.MangaOrphaned, report.MangaFrozen)
+	}
+
+	// После отписки отсрочка отсчитывается заново
+	if err := s.DeleteSubscription(user.ID, orphan.ID); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+	report = runMaintenance(t, s, policy, now.Add(retentionGrace+4*time.Hour), false)
+	if report.MangaOrphaned != 1 || report.MangaFrozen != 0 {
+		t.Errorf("после отписки: без подписчиков %d, заморожено %d, ожидалось 1 и 0", report.MangaOrphaned, report.MangaFrozen)
+	}
+	assertCrawled(t, s, "после отписки", "berserk", "vagabond")
+}
+
+func testOrphanDelete(t *testing.T, s store.Store) {
+	kept := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+	orphan := createManga(t, s, types.SourceReadmanga, "vagabond", "Бродяга")
+	user := createUser(t, s, 1001)
+	subscribe(t, s, user.ID, kept.ID)
+
+	if _, err := s.IngestChapters(orphan.ID, 0, []types.Chapter{chapter("vagabond/vol1/1", "1", "")}); err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+
+	policy := types.RetentionPolicy{OrphanGrace: retentionGrace, OrphanAction: types.OrphanDelete}
+	now := time.Now()
+
+	if report := runMaintenance(t, s, policy, now, false); report.MangaOrphaned != 1 || report.MangaDeleted != 0 {
+		t.Errorf("первое обслуживание: без подписчиков %d, удалено %d, ожидалось 1 и 0", report.MangaOrphaned, report.MangaDeleted)
+	}
+
+	if report := runMaintenance(t, s, policy, now.Add(retentionGrace+time.Hour), false); report.MangaDeleted != 1 {
+		t.Errorf("после отсрочки удалено манги: %d, ожидалось 1", report.MangaDeleted)
+	}
+
+	if manga, err := s.GetMangaByID(orphan.ID); err != nil || manga != nil {
+		t.Errorf("манга без подписчиков осталась: %+v (ошибка: %v)", manga, err)
+	}
+	if chapters, err := s.GetChaptersByMangaID(orphan.ID); err != nil || len(chapters) != 0 {
+		t.Errorf("у удалённой манги осталось глав: %d (ошибка: %v)", len(chapters), err)
+	}
+	if manga, err := s.GetMangaByID(kept.ID); err != nil || manga == nil {
+		t.Errorf("удалена манга с подписчиком (ошибка: %v)", err)
+	}
+}
+
+func testInactiveUsers(t *testing.T, s store.Store) {
+	manga := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+	active := createUser(t, s, 1001)
+	blocked := createUser(t, s, 1002)
+	subscribe(t, s, active.ID, manga.ID)
+	subscribe(t, s, blocked.ID, manga.ID)
+
+	if err := s.SetUserActive(blocked.ID, false); err != nil {
+		t.Fatalf("SetUserActive: %v", err)
+	}
+
+	policy := types.RetentionPolicy{InactiveUserGrace: retentionGrace}
+	now := time.Now()
+
+	if report := runMaintenance(t, s, policy, now, false); report.UsersDeleted != 0 {
+		t.Errorf("удалено пользователей до истечения срока: %d", report.UsersDeleted)
+	}
+
+	if report := runMaintenance(t, s, policy, now.Add(retentionGrace+time.Hour), false); report.UsersDeleted != 1 {
+		t.Errorf("удалено пользователей: %d, ожидался 1", report.UsersDeleted)
+	}
+
+	if user, err := s.GetUserByID(blocked.ID); err != nil || user != nil {
+		t.Errorf("неактивный пользователь остался: %+v (ошибка: %v)", user, err)
+	}
+	if sub, err := s.GetSubscription(blocked.ID, manga.ID); err != nil || sub != nil {
+		t.Errorf("подписка удалённого пользователя осталась: %+v (ошибка: %v)", sub, err)
+	}
+
+	if user, err := s.GetUserByID(active.ID); err != nil || user == nil {
+		t.Errorf("удалён активный пользователь (ошибка: %v)", err)
+	}
+	if sub, err := s.GetSubscription(active.ID, manga.ID); err != nil || sub == nil {
+		t.Errorf("удалена подписка активного пользователя (ошибка: %v)", err)
+	}
+}
+
+func testMaintenanceDryRun(t *testing.T, s store.Store) {
+	kept := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+	createManga(t, s, types.SourceReadmanga, "vagabond", "Бродяга")
+	active := createUser(t, s, 1001)
+	blocked := createUser(t, s, 1002)
+	subscribe(t, s, active.ID, kept.ID)
+
+	if err := s.SetUserActive(blocked.ID, false); err != nil {
+		t.Fatalf("SetUserActive: %v", err)
+	}
+	if _, err := s.IngestChapters(kept.ID, 0, []types.Chapter{
+		chapter("berserk/vol1/3", "3", ""),
+		chapter("berserk/vol1/2", "2", ""),
+		chapter("berserk/vol1/1", "1", ""),
+	}); err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+	if err := s.RecordCrawl(types.CrawlRecord{SourceID: kept.SourceID, MangaID: kept.ID, Status: types.CrawlOK}); err != nil {
+		t.Fatalf("RecordCrawl: %v", err)
+	}
+	if err := s.RecordEvent(types.Event{Type: types.EventSubscribe, ActorID: active.ID, UserID: active.ID, MangaID: kept.ID}); err != nil {
+		t.Fatalf("RecordEvent: %v", err)
+	}
+
+	policy := types.RetentionPolicy{
+		ChaptersPerManga:  1,
+		ArchiveChapters:   true,
+		OrphanGrace:       retentionGrace,
+		OrphanAction:      types.OrphanFreeze,
+		InactiveUserGrace: retentionGrace,
+		CrawlHistoryTTL:   retentionGrace,
+		EventsTTL:         retentionGrace,
+	}
+	now := time.Now()
+
+	// Отсрочка для манги без подписчиков начинается с реального обслуживания
+	runMaintenance(t, s, types.RetentionPolicy{OrphanGrace: retentionGrace}, now, false)
+
+	later := now.Add(retentionGrace + time.Hour)
+	dry := runMaintenance(t, s, policy, later, true)
+
+	want := types.MaintenanceReport{
+		DryRun:              true,
+		ChaptersArchived:    2,
+		ChaptersDeleted:     2,
+		MangaFrozen:         1,
+		UsersDeleted:        1,
+		CrawlHistoryDeleted: 1,
+		EventsDeleted:       1,
+	}
+	if dry != want {
+		t.Errorf("отчёт пробного прогона %+v, ожидалось %+v", dry, want)
+	}
+
+	// Пробный прогон ничего не меняет
+	if chapters, err := s.GetChaptersByMangaID(kept.ID); err != nil || len(chapters) != 3 {
+		t.Errorf("после пробного прогона глав %d, ожидалось 3 (ошибка: %v)", len(chapters), err)
+	}
+	assertCrawled(t, s, "после пробного прогона", "berserk", "vagabond")
+	if user, err := s.GetUserByID(blocked.ID); err != nil || user == nil {
+		t.Errorf("пробный прогон удалил пользователя (ошибка: %v)", err)
+	}
+	if history, err := s.GetMangaCrawlHistory(kept.ID, 10); err != nil || len(history) != 1 {
+		t.Errorf("после пробного прогона записей истории %d, ожидалась 1 (ошибка: %v)", len(history), err)
+	}
+	if events, err := s.GetEvents(types.EventFilter{}); err != nil || len(events) != 1 {
+		t.Errorf("после пробного прогона событий %d, ожидалось 1 (ошибка: %v)", len(events), err)
+	}
+
+	// Настоящий прогон делает ровно то, что показал пробный
+	applied := runMaintenance(t, s, policy, later, false)
+	want.DryRun = false
+	if applied != want {
+		t.Errorf("отчёт обслуживания %+v, ожидалось %+v", applied, want)
+	}
+	if chapters, err := s.GetChaptersByMangaID(kept.ID); err != nil || len(chapters) != 1 {
+		t.Errorf("после обслуживания глав %d, ожидалась 1 (ошибка: %v)", len(chapters), err)
+	}
+	assertCrawled(t, s, "после обслуживания", "berserk")
+}
+
+func testRetentionTTL(t *testing.T, s store.Store) {
+	berserk := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+	vagabond := createManga(t, s, types.SourceReadmanga, "vagabond", "Бродяга")
+	berserkWork := bindWork(t, s, berserk)
+	vagabondWork := bindWork(t, s, vagabond)
+	user := createUser(t, s, 1001)
+	subscribe(t, s, user.ID, berserk.ID)
+	subscribe(t, s, user.ID, vagabond.ID)
+
+	// Два уведомления: доставленное и ожидающее
+	if _, err := s.IngestChapters(berserk.ID, berserkWork, []types.Chapter{chapter("berserk/vol1/1", "1", "")}); err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+	if _, err := s.IngestChapters(vagabond.ID, vagabondWork, []types.Chapter{chapter("vagabond/vol1/1", "1", "")}); err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+	due, err := s.GetDueNotifications(time.Now().Add(time.Minute), 10)
+	if err != nil || len(due) != 2 {
+		t.Fatalf("уведомлений %d, ожидалось 2 (ошибка: %v)", len(due), err)
+	}
+	if err := s.MarkNotificationSent(due[0].ID); err != nil {
+		t.Fatalf("MarkNotificationSent: %v", err)
+	}
+
+	for _, manga := range []*types.Manga{berserk, vagabond} {
+		if err := s.RecordCrawl(types.CrawlRecord{SourceID: manga.SourceID, MangaID: manga.ID, Status: types.CrawlOK, NewChapters: 1}); err != nil {
+			t.Fatalf("RecordCrawl: %v", err)
+		}
+	}
+	if err := s.RecordEvent(types.Event{Type: types.EventSubscribe, ActorID: user.ID, UserID: user.ID, MangaID: berserk.ID}); err != nil {
+		t.Fatalf("RecordEvent: %v", err)
+	}
+
+	policy := types.RetentionPolicy{
+		NotificationsTTL: retentionGrace,
+		CrawlHistoryTTL:  retentionGrace,
+		EventsTTL:        retentionGrace,
+	}
+	now := time.Now()
+
+	if report := runMaintenance(t, s, policy, now, false); report != (types.MaintenanceReport{}) {
+		t.Errorf("свежие записи удалены: %+v", report)
+	}
+
+	report := runMaintenance(t, s, policy, now.Add(retentionGrace+time.Hour), false)
+	want := types.MaintenanceReport{NotificationsDeleted: 1, CrawlHistoryDeleted: 2, EventsDeleted: 1}
+	if report != want {
+		t.Errorf("отчёт %+v, ожидалось %+v", report, want)
+	}
+
+	// Ожидающее уведомление не удаляется, сколько бы ни ждало
+	due, err = s.GetDueNotifications(now.Add(retentionGrace+2*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("GetDueNotifications: %v", err)
+	}
+	if len(due) != 1 || due[0].MangaID != vagabond.ID {
+		t.Errorf("ожидающие уведомления %+v, ожидалось одно о манге %d", due, vagabond.ID)
+	}
+
+	if history, err := s.GetMangaCrawlHistory(berserk.ID, 10); err != nil || len(history) != 0 {
+		t.Errorf("осталось записей истории: %d (ошибка: %v)", len(history), err)
+	}
+	if events, err := s.GetEvents(types.EventFilter{}); err != nil || len(events) != 0 {
+		t.Errorf("осталось событий: %d (ошибка: %v)", len(events), err)
+	}
+}
+
+func runMaintenance(t *testing.T, s store.Store, policy types.RetentionPolicy, now time.Time, dryRun bool) types.MaintenanceReport {
+	t.Helper()
+
+	report, err := s.RunMaintenance(policy, now, dryRun)
+	if err != nil {
+		t.Fatalf("RunMaintenance: %v", err)
+	}
+	return report
+}
+
+func subscribe(t *testing.T, s store.Store, userID int64, mangaID int) {
+	t.Helper()
+
+	if _, err := s.CreateSubscription(userID, mangaID); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+}
+
+// assertCrawled сравнивает URL проверяемой (незамороженной) манги readmanga с ожидаемыми
+func assertCrawled(t *testing.T, s store.Store, what string, want ...string) {
+	t.Helper()
+
+	source, err := s.GetSourceByName(types.SourceReadmanga)
+	if err != nil || source == nil {
+		t.Fatalf("источник %s: %v", types.SourceReadmanga, err)
+	}
+
+	mangaList, err := s.GetMangaBySourceID(source.ID)
+	if err != nil {
+		t.Fatalf("GetMangaBySourceID: %v", err)
+	}
+
+	got := make([]string, len(mangaList))
+	for i, manga := range mangaList {
+		got[i] = manga.URL
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("%s проверяется манга %v, ожидалось %v", what, got, want)
+	}
+}
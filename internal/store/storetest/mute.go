@@ -0,0 +1,154 @@
+package storetest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+func testResumeExpiredMutes(t *testing.T, s store.Store) {
+	manga := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := useClock(t, s, base)
+
+	// 1001 и 1002 приглушены на час, 1003 — бессрочно, 1004 — на три часа
+	mutes := []struct {
+		userID int64
+		until  *time.Time
+	}{
+		{1001, ptr(base.Add(time.Hour))},
+		{1002, ptr(base.Add(time.Hour))},
+		{1003, nil},
+		{1004, ptr(base.Add(3 * time.Hour))},
+	}
+	for _, mute := range mutes {
+		createUser(t, s, mute.userID)
+		subscribe(t, s, mute.userID, manga.ID)
+	}
+
+	if _, err := s.IngestChapters(manga.ID, 0, []types.Chapter{chapter("berserk/vol1/1", "1", "")}); err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+	clock.Advance(time.Minute)
+
+	for _, mute := range mutes {
+		if err := s.MuteSubscription(mute.userID, manga.ID, mute.until); err != nil {
+			t.Fatalf("MuteSubscription: %v", err)
+		}
+	}
+
+	// Пользователь заблокировал бота, пока подписка была приглушена
+	if err := s.SetUserActive(1002, false); err != nil {
+		t.Fatalf("SetUserActive: %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	if _, err := s.IngestChapters(manga.ID, 0, []types.Chapter{chapter("berserk/vol1/3", "3", ""), chapter("berserk/vol1/2", "2", "")}); err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+
+	now := base.Add(2 * time.Hour)
+	resumed, err := s.ResumeExpiredMutes(now)
+	if err != nil {
+		t.Fatalf("ResumeExpiredMutes: %v", err)
+	}
+
+	// Возвращаются подписки в том виде, в каком они были приглушены
+	if desc := describeSubscriptions(resumed); desc != "1001:muted 1002:muted" {
+		t.Errorf("возобновлены %q, ожидалось 1001 и 1002", desc)
+	}
+
+	// Уведомление о пропущенных главах — только активному пользователю
+	notifications, err := s.GetDueNotifications(now, 10)
+	if err != nil {
+		t.Fatalf("GetDueNotifications: %v", err)
+	}
+	if len(notifications) != 1 {
+		t.Fatalf("уведомлений %d, ожидалось 1", len(notifications))
+	}
+	if n := notifications[0]; n.UserID != 1001 || n.MangaID != manga.ID || !n.CreatedAt.Equal(now) {
+		t.Errorf("уведомление пользователю %d о манге %d от %s, ожидалось 1001 о манге %d от %s", n.UserID, n.MangaID, n.CreatedAt, manga.ID, now)
+	}
+	assertURLs(t, "уведомление о пропущенных", notifications[0].Chapters, "berserk/vol1/3", "berserk/vol1/2")
+
+	for _, mute := range mutes {
+		sub, err := s.GetSubscription(mute.userID, manga.ID)
+		if err != nil || sub == nil {
+			t.Fatalf("подписка %d: %v", mute.userID, err)
+		}
+
+		wantNotify := mute.userID == 1001 || mute.userID == 1002
+		if sub.Notify != wantNotify || (sub.MutedAt == nil) != wantNotify {
+			t.Errorf("подписка %d: notify=%v, muted_at=%v, ожидалось notify=%v", mute.userID, sub.Notify, sub.MutedAt, wantNotify)
+		}
+	}
+
+	// Повторный вызов в то же время ничего не делает
+	again, err := s.ResumeExpiredMutes(now)
+	if err != nil {
+		t.Fatalf("ResumeExpiredMutes: %v", err)
+	}
+	if len(again) != 0 {
+		t.Errorf("повторно возобновлены %q", describeSubscriptions(again))
+	}
+	if notifications, _ := s.GetDueNotifications(now, 10); len(notifications) != 1 {
+		t.Errorf("после повторного вызова уведомлений %d, ожидался 1", len(notifications))
+	}
+}
+
+// describeSubscriptions список подписок вида "пользователь:состояние"
+func describeSubscriptions(subs []types.UserSubscription) string {
+	var desc string
+	for i, sub := range subs {
+		state := "notify"
+		if !sub.Notify && sub.MutedAt != nil && sub.MutedUntil != nil {
+			state = "muted"
+		}
+		if i > 0 {
+			desc += " "
+		}
+		desc += fmt.Sprintf("%d:%s", sub.TelegramUserID, state)
+	}
+	return desc
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// testClock часы хранилища, которые проверка двигает сама: порядок записей задаётся
+// без ожидания, в том числе в SQLite, где время хранится с точностью до секунды
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// useClock подменяет часы хранилища (db.Store и Memory реализуют SetClock)
+func useClock(t *testing.T, s store.Store, start time.Time) *testClock {
+	t.Helper()
+
+	setter, ok := s.(interface{ SetClock(now func() time.Time) })
+	if !ok {
+		t.Fatalf("у хранилища %T нельзя подменить часы", s)
+	}
+
+	clock := &testClock{now: start}
+	setter.SetClock(clock.Now)
+	return clock
+}
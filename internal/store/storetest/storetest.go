@@ -0,0 +1,386 @@
+// Package storetest общие проверки поведения store.Store. Один и тот же набор запускается
+// для хранилища в памяти и для db.Store, чтобы реализации не расходились.
+package storetest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// Opener создаёт пустое хранилище с источниками readmanga и mintmanga (как после миграций)
+type Opener func(t *testing.T) store.Store
+
+// Run запускает все проверки; каждая получает новое хранилище
+func Run(t *testing.T, open Opener) {
+	t.Run("IngestChapters", func(t *testing.T) { testIngestChapters(t, open(t)) })
+	t.Run("SearchManga", func(t *testing.T) { testSearchManga(t, open(t)) })
+	t.Run("MuteUnmute", func(t *testing.T) { testMuteUnmute(t, open(t)) })
+	t.Run("ResumeExpiredMutes", func(t *testing.T) { testResumeExpiredMutes(t, open(t)) })
+	t.Run("PruneChapters", func(t *testing.T) { testPruneChapters(t, open(t)) })
+	t.Run("WorkNotifications", func(t *testing.T) { testWorkNotifications(t, open(t)) })
+	t.Run("OrphanFreeze", func(t *testing.T) { testOrphanFreeze(t, open(t)) })
+	t.Run("OrphanDelete", func(t *testing.T) { testOrphanDelete(t, open(t)) })
+	t.Run("InactiveUsers", func(t *testing.T) { testInactiveUsers(t, open(t)) })
+	t.Run("MaintenanceDryRun", func(t *testing.T) { testMaintenanceDryRun(t, open(t)) })
+	t.Run("RetentionTTL", func(t *testing.T) { testRetentionTTL(t, open(t)) })
+	t.Run("Events", func(t *testing.T) { testEvents(t, open(t)) })
+}
+
+func testIngestChapters(t *testing.T, s store.Store) {
+	manga := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+
+	first, err := s.IngestChapters(manga.ID, 0, []types.Chapter{
+		chapter("berserk/vol1/3", "3", ""),
+		chapter("berserk/vol1/2", "2", ""),
+		{Title: "Без ссылки"},
+		chapter("berserk/vol1/2", "2", ""), // Повтор в том же фиде
+		chapter("berserk/vol1/1", "1", ""),
+	})
+	if err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+	assertURLs(t, "первая проверка", first, "berserk/vol1/3", "berserk/vol1/2", "berserk/vol1/1")
+
+	second, err := s.IngestChapters(manga.ID, 0, []types.Chapter{
+		chapter("berserk/vol1/5", "5", ""),
+		chapter("berserk/vol1/4", "4", ""),
+		chapter("berserk/vol1/3", "3", ""),
+		chapter("berserk/vol1/2", "2", ""),
+	})
+	if err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+	assertURLs(t, "вторая проверка", second, "berserk/vol1/5", "berserk/vol1/4")
+
+	third, err := s.IngestChapters(manga.ID, 0, []types.Chapter{chapter("berserk/vol1/5", "5", "")})
+	if err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+	assertURLs(t, "проверка без новых глав", third)
+
+	saved, err := s.GetMangaByID(manga.ID)
+	if err != nil {
+		t.Fatalf("GetMangaByID: %v", err)
+	}
+	if saved.LastChapterURL != "berserk/vol1/5" {
+		t.Errorf("последняя глава %q, ожидалась berserk/vol1/5", saved.LastChapterURL)
+	}
+	if saved.LastCheckAt == nil {
+		t.Error("время проверки не сохранено")
+	}
+
+	chapters, err := s.GetChaptersByMangaID(manga.ID)
+	if err != nil {
+		t.Fatalf("GetChaptersByMangaID: %v", err)
+	}
+	if len(chapters) != 5 {
+		t.Errorf("сохранено глав: %d, ожидалось 5", len(chapters))
+	}
+}
+
+func testSearchManga(t *testing.T, s store.Store) {
+	berserk := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+	gluttony := createManga(t, s, types.SourceReadmanga, "berserk_of_gluttony", "Berserk of Gluttony")
+	typo := createManga(t, s, types.SourceMintmanga, "berserg", "Берсерг")
+	createManga(t, s, types.SourceReadmanga, "vagabond", "Бродяга")
+
+	// Находится только по альтернативному названию произведения
+	kenpuu := createManga(t, s, types.SourceMintmanga, "kenpuu_denki", "Kenpuu Denki")
+	if err := s.AddWorkTitle(bindWork(t, s, kenpuu), "Berserk"); err != nil {
+		t.Fatalf("AddWorkTitle: %v", err)
+	}
+
+	results, err := s.SearchManga("берсерк", 0, 10)
+	if err != nil {
+		t.Fatalf("SearchManga: %v", err)
+	}
+
+	// Полные совпадения по убыванию похожести, при равной — по названию; опечатка последней
+	want := []struct {
+		id      int
+		matched string
+	}{
+		{gluttony.ID, "Berserk of Gluttony"},
+		{kenpuu.ID, "Berserk"},
+		{berserk.ID, "Берсерк"},
+		{typo.ID, "Берсерг"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("найдено %s, ожидалось %d", describeResults(results), len(want))
+	}
+	for i, w := range want {
+		if results[i].ID != w.id || results[i].MatchedTitle != w.matched {
+			t.Errorf("результат %d: %s, ожидалось %d [%s]", i, describeResults(results[i:i+1]), w.id, w.matched)
+		}
+		if i > 0 && results[i].Score > results[i-1].Score {
+			t.Errorf("результат %d похожее предыдущего: %.2f > %.2f", i, results[i].Score, results[i-1].Score)
+		}
+	}
+
+	limited, err := s.SearchManga("берсерк", 0, 2)
+	if err != nil {
+		t.Fatalf("SearchManga: %v", err)
+	}
+	if len(limited) != 2 || limited[0].ID != gluttony.ID || limited[1].ID != kenpuu.ID {
+		t.Errorf("с лимитом 2 найдено %s", describeResults(limited))
+	}
+
+	// Поиск по подпискам пользователя
+	user := createUser(t, s, 1001)
+	if _, err := s.CreateSubscription(user.ID, typo.ID); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	own, err := s.SearchManga("берсерк", user.ID, 10)
+	if err != nil {
+		t.Fatalf("SearchManga: %v", err)
+	}
+	if len(own) != 1 || own[0].ID != typo.ID {
+		t.Errorf("среди подписок найдено %s, ожидалась только манга %d", describeResults(own), typo.ID)
+	}
+
+	none, err := s.SearchManga("наруто", 0, 10)
+	if err != nil {
+		t.Fatalf("SearchManga: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("по непохожему запросу найдено %s", describeResults(none))
+	}
+}
+
+func testMuteUnmute(t *testing.T, s store.Store) {
+	// Пользователь подписан на readmanga, та же манга есть на mintmanga
+	manga := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+	mirror := createManga(t, s, types.SourceMintmanga, "berserk", "Берсерк")
+	workID := bindWork(t, s, manga, mirror)
+	user := createUser(t, s, 1001)
+	clock := useClock(t, s, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	if _, err := s.CreateSubscription(user.ID, manga.ID); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	if err := s.UpdateSubscriptionTeam(user.ID, manga.ID, types.TeamModeTeam, "Команда А"); err != nil {
+		t.Fatalf("UpdateSubscriptionTeam: %v", err)
+	}
+
+	if _, err := s.IngestChapters(manga.ID, workID, []types.Chapter{chapter("berserk/vol1/1", "1", "Команда А")}); err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+
+	// Глава, найденная в момент приглушения, считалась бы пропущенной
+	clock.Advance(time.Minute)
+
+	if err := s.MuteSubscription(user.ID, manga.ID, nil); err != nil {
+		t.Fatalf("MuteSubscription: %v", err)
+	}
+
+	sub, err := s.GetSubscription(user.ID, manga.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if sub.Notify || sub.MutedAt == nil || sub.MutedUntil != nil {
+		t.Fatalf("после приглушения notify=%v, muted_at=%v, muted_until=%v", sub.Notify, sub.MutedAt, sub.MutedUntil)
+	}
+
+	_, err = s.IngestChapters(manga.ID, workID, []types.Chapter{
+		chapter("berserk/vol1/4", "4", "Команда А"),
+		chapter("berserk/vol1/3", "3", "Команда А"),
+		chapter("berserk/vol1/2", "2", "Команда Б"),
+	})
+	if err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+
+	// Вторая проверка позже первой: её глава свежее
+	clock.Advance(time.Minute)
+
+	_, err = s.IngestChapters(mirror.ID, workID, []types.Chapter{
+		chapter("mirror/vol1/5", "5", "Команда А"),
+		chapter("mirror/vol1/4", "4", "Команда А"),
+		chapter("mirror/vol1/1", "1", "Команда Б"),
+	})
+	if err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+
+	// Глава до приглушения и главы чужой команды не пропущены; глава с обоих источников
+	// приходит один раз — из манги подписки
+	missed, err := s.UnmuteSubscription(user.ID, manga.ID)
+	if err != nil {
+		t.Fatalf("UnmuteSubscription: %v", err)
+	}
+	assertURLs(t, "пропущенные главы", missed, "mirror/vol1/5", "berserk/vol1/4", "berserk/vol1/3")
+
+	// Пропущенные главы поставлены в очередь вместе с возобновлением; первое уведомление —
+	// о главе, найденной до приглушения
+	notifications, err := s.GetDueNotifications(time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("GetDueNotifications: %v", err)
+	}
+	if len(notifications) != 2 {
+		t.Fatalf("уведомлений %d, ожидалось 2", len(notifications))
+	}
+	if n := notifications[1]; n.UserID != user.ID || n.MangaID != manga.ID {
+		t.Errorf("уведомление пользователю %d о манге %d, ожидалось %d о манге %d", n.UserID, n.MangaID, user.ID, manga.ID)
+	}
+	assertURLs(t, "уведомление о пропущенных", notifications[1].Chapters, "mirror/vol1/5", "berserk/vol1/4", "berserk/vol1/3")
+
+	sub, err = s.GetSubscription(user.ID, manga.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	if !sub.Notify || sub.MutedAt != nil || sub.MutedUntil != nil {
+		t.Errorf("после возобновления notify=%v, muted_at=%v, muted_until=%v", sub.Notify, sub.MutedAt, sub.MutedUntil)
+	}
+
+	again, err := s.UnmuteSubscription(user.ID, manga.ID)
+	if err != nil {
+		t.Fatalf("UnmuteSubscription: %v", err)
+	}
+	if len(again) != 0 {
+		t.Errorf("повторное возобновление вернуло %d глав", len(again))
+	}
+	if notifications, _ := s.GetDueNotifications(time.Now().Add(time.Minute), 10); len(notifications) != 2 {
+		t.Errorf("после повторного возобновления уведомлений %d, ожидалось 2", len(notifications))
+	}
+}
+
+func testPruneChapters(t *testing.T, s store.Store) {
+	manga := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+
+	// Все главы одной проверки найдены в одно время: вытесняются последние в фиде, а не первые
+	feed := []types.Chapter{
+		chapter("berserk/vol1/5", "5", ""),
+		chapter("berserk/vol1/4", "4", ""),
+		chapter("berserk/vol1/3", "3", ""),
+		chapter("berserk/vol1/2", "2", ""),
+		chapter("berserk/vol1/1", "1", ""),
+	}
+	if _, err := s.IngestChapters(manga.ID, 0, feed); err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+
+	report, err := s.RunMaintenance(types.RetentionPolicy{ChaptersPerManga: 3, ArchiveChapters: true}, time.Now(), false)
+	if err != nil {
+		t.Fatalf("RunMaintenance: %v", err)
+	}
+	if report.ChaptersDeleted != 2 || report.ChaptersArchived != 2 {
+		t.Errorf("удалено %d, в архиве %d, ожидалось 2 и 2", report.ChaptersDeleted, report.ChaptersArchived)
+	}
+
+	for _, url := range []string{"berserk/vol1/5", "berserk/vol1/4", "berserk/vol1/3"} {
+		if exists, err := s.ChapterExists(manga.ID, url); err != nil || !exists {
+			t.Errorf("глава %s удалена (ошибка: %v)", url, err)
+		}
+	}
+
+	// Тот же фид на следующей проверке не приносит новых глав
+	again, err := s.IngestChapters(manga.ID, 0, feed[:3])
+	if err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+	assertURLs(t, "повторная проверка", again)
+}
+
+func testWorkNotifications(t *testing.T, s store.Store) {
+	// Одно произведение на двух источниках, пользователь подписан на mintmanga
+	subscribed := createManga(t, s, types.SourceMintmanga, "berserk", "Берсерк")
+	publishing := createManga(t, s, types.SourceReadmanga, "berserk", "Берсерк")
+	workID := bindWork(t, s, subscribed, publishing)
+
+	user := createUser(t, s, 1001)
+	if _, err := s.CreateSubscription(user.ID, subscribed.ID); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+
+	if _, err := s.IngestChapters(publishing.ID, workID, []types.Chapter{chapter("https://readmanga.example/berserk/vol1/1", "1", "")}); err != nil {
+		t.Fatalf("IngestChapters: %v", err)
+	}
+
+	notifications, err := s.GetDueNotifications(time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("GetDueNotifications: %v", err)
+	}
+	if len(notifications) != 1 {
+		t.Fatalf("уведомлений %d, ожидалось 1", len(notifications))
+	}
+
+	// Кнопки уведомления действуют на мангу из него — это должна быть манга подписки
+	n := notifications[0]
+	if n.UserID != user.ID || n.MangaID != subscribed.ID {
+		t.Errorf("уведомление пользователю %d о манге %d, ожидалось %d о манге %d", n.UserID, n.MangaID, user.ID, subscribed.ID)
+	}
+	assertURLs(t, "главы уведомления", n.Chapters, "https://readmanga.example/berserk/vol1/1")
+}
+
+// bindWork объединяет мангу в одно произведение и возвращает его ID
+func bindWork(t *testing.T, s store.Store, mangaList ...*types.Manga) int {
+	t.Helper()
+
+	work, err := s.CreateWork(mangaList[0].Title)
+	if err != nil {
+		t.Fatalf("CreateWork: %v", err)
+	}
+	for _, manga := range mangaList {
+		if err := s.SetMangaWork(manga.ID, work.ID); err != nil {
+			t.Fatalf("SetMangaWork: %v", err)
+		}
+		manga.WorkID = work.ID
+	}
+	return work.ID
+}
+
+// createManga заводит мангу на источнике из миграций
+func createManga(t *testing.T, s store.Store, sourceName types.SourceName, url, title string) *types.Manga {
+	t.Helper()
+
+	source, err := s.GetSourceByName(sourceName)
+	if err != nil || source == nil {
+		t.Fatalf("источник %s: %v", sourceName, err)
+	}
+
+	manga, err := s.CreateManga(source.ID, url, title)
+	if err != nil {
+		t.Fatalf("CreateManga: %v", err)
+	}
+	return manga
+}
+
+func createUser(t *testing.T, s store.Store, id int64) *types.TelegramUser {
+	t.Helper()
+
+	user, err := s.GetOrCreateUser(id, "reader", "Читатель", "")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser: %v", err)
+	}
+	return user
+}
+
+func chapter(url, number, translator string) types.Chapter {
+	return types.Chapter{URL: url, Title: "Глава " + number, Number: number, Translator: translator}
+}
+
+// assertURLs сравнивает главы с ожидаемыми URL с учётом порядка
+func assertURLs(t *testing.T, what string, chapters []types.Chapter, want ...string) {
+	t.Helper()
+
+	got := make([]string, len(chapters))
+	for i, ch := range chapters {
+		got[i] = ch.URL
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("%s: %v, ожидалось %v", what, got, want)
+	}
+}
+
+func describeResults(results []types.MangaSearchResult) string {
+	parts := make([]string, len(results))
+	for i, r := range results {
+		parts[i] = fmt.Sprintf("%d [%s] %.2f", r.ID, r.MatchedTitle, r.Score)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
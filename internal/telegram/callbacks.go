@@ -0,0 +1,298 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// Данные кнопки — короткий код действия и числовые аргументы в base36 через ":",
+// например "nm:1a" (заглушить мангу 46). Telegram ограничивает данные 64 байтами,
+// так в них помещается несколько ID любого размера.
+const (
+	callbackRead        = "nr" // Прочитано: убрать кнопки уведомления (манга)
+	callbackMute        = "nm" // Заглушить мангу из уведомления (манга)
+	callbackUnmute      = "nu" // Вернуть уведомления (манга)
+	callbackUnsubscribe = "ns" // Отписаться из уведомления (манга)
+	callbackResubscribe = "nz" // Отменить отписку (манга)
+
+	callbackRemovePage    = "rp" // /remove: страница выбора (страница)
+	callbackRemoveAsk     = "ra" // /remove: подтверждение (манга, страница)
+	callbackRemoveConfirm = "ry" // /remove: отписаться (манга, страница)
+	callbackRemoveClose   = "rx" // /remove: закрыть выбор
+)
+
+// noticeTitleLimit длина названия во всплывающей подсказке (Telegram принимает до 200 символов)
+const noticeTitleLimit = 60
+
+// callbackHandler обрабатывает нажатие кнопки и возвращает всплывающую подсказку ("" — без подсказки)
+type callbackHandler func(bot *TelegramBot, st store.Store, query *CallbackQuery, args []int64) string
+
+// callbackHandlers действия кнопок по коду
+var callbackHandlers map[string]callbackHandler
+
+func init() {
+	callbackHandlers = map[string]callbackHandler{
+		callbackRead:        handleReadCallback,
+		callbackMute:        handleMuteCallback,
+		callbackUnmute:      handleUnmuteCallback,
+		callbackUnsubscribe: handleUnsubscribeCallback,
+		callbackResubscribe: handleResubscribeCallback,
+
+		callbackRemovePage:    handleRemovePageCallback,
+		callbackRemoveAsk:     handleRemoveAskCallback,
+		callbackRemoveConfirm: handleRemoveConfirmCallback,
+		callbackRemoveClose:   handleRemoveCloseCallback,
+	}
+}
+
+// callbackData собирает данные кнопки из кода действия и аргументов
+func callbackData(action string, args ...int64) string {
+	var sb strings.Builder
+	sb.WriteString(action)
+	for _, arg := range args {
+		sb.WriteByte(':')
+		sb.WriteString(strconv.FormatInt(arg, 36))
+	}
+	return sb.String()
+}
+
+// parseCallbackData разбирает данные кнопки на код действия и аргументы
+func parseCallbackData(data string) (string, []int64, error) {
+	parts := strings.Split(data, ":")
+
+	args := make([]int64, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		arg, err := strconv.ParseInt(part, 36, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("некорректные данные кнопки %q", data)
+		}
+		args = append(args, arg)
+	}
+
+	return parts[0], args, nil
+}
+
+// HandleCallback обрабатывает нажатие кнопки под сообщением бота.
+// Telegram ждёт ответа на каждое нажатие, поэтому answerCallbackQuery вызывается всегда.
+func HandleCallback(bot *TelegramBot, st store.Store, query *CallbackQuery) {
+	notice := "Кнопка устарела"
+
+	action, args, err := parseCallbackData(query.Data)
+	if handler, ok := callbackHandlers[action]; ok && err == nil && query.From != nil && query.Message != nil && query.Message.Chat != nil {
+		notice = handler(bot, st, query, args)
+	}
+
+	if err := answerCallback(bot, query.ID, notice); err != nil {
+		log.Printf("Ошибка ответа на нажатие кнопки: %v", err)
+	}
+}
+
+// notificationKeyboard кнопки под уведомлением о новых главах
+func notificationKeyboard(mangaID int, muted bool) *InlineKeyboardMarkup {
+	id := int64(mangaID)
+
+	mute := InlineKeyboardButton{Text: "🔕 Заглушить", CallbackData: callbackData(callbackMute, id)}
+	if muted {
+		mute = InlineKeyboardButton{Text: "🔔 Включить", CallbackData: callbackData(callbackUnmute, id)}
+	}
+
+	return &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{
+		{Text: "✅ Прочитано", CallbackData: callbackData(callbackRead, id)},
+		mute,
+		{Text: "❌ Отписаться", CallbackData: callbackData(callbackUnsubscribe, id)},
+	}}}
+}
+
+// callbackManga манга из первого аргумента кнопки и подписка нажавшего на неё
+func callbackManga(st store.Store, query *CallbackQuery, args []int64) (*types.Manga, *types.UserSubscription, string) {
+	if len(args) < 1 {
+		return nil, nil, "Кнопка устарела"
+	}
+
+	manga, err := st.GetMangaByID(int(args[0]))
+	if err != nil {
+		log.Printf("Ошибка получения манги: %v", err)
+		return nil, nil, "Ошибка получения манги"
+	}
+	if manga == nil {
+		return nil, nil, "Манга больше не отслеживается"
+	}
+
+	subscription, err := st.GetSubscription(query.From.ID, manga.ID)
+	if err != nil {
+		log.Printf("Ошибка проверки подписки: %v", err)
+		return nil, nil, "Ошибка проверки подписки"
+	}
+
+	return manga, subscription, ""
+}
+
+// setNotificationKeyboard меняет кнопки под уведомлением
+func setNotificationKeyboard(bot *TelegramBot, query *CallbackQuery, keyboard *InlineKeyboardMarkup) {
+	if err := editReplyMarkup(bot, query.Message.Chat.ID, query.Message.MessageID, keyboard); err != nil {
+		log.Printf("Ошибка изменения кнопок сообщения: %v", err)
+	}
+}
+
+// handleReadCallback "Прочитано": убирает кнопки уведомления
+func handleReadCallback(bot *TelegramBot, st store.Store, query *CallbackQuery, args []int64) string {
+	setNotificationKeyboard(bot, query, nil)
+	return "Отмечено как прочитанное"
+}
+
+// handleMuteCallback "Заглушить": приостанавливает уведомления по подписке
+func handleMuteCallback(bot *TelegramBot, st store.Store, query *CallbackQuery, args []int64) string {
+	return setMuted(bot, st, query, args, true)
+}
+
+// handleUnmuteCallback "Включить": возвращает уведомления по подписке
+func handleUnmuteCallback(bot *TelegramBot, st store.Store, query *CallbackQuery, args []int64) string {
+	return setMuted(bot, st, query, args, false)
+}
+
+// setMuted переключает уведомления по подписке из кнопки уведомления
+func setMuted(bot *TelegramBot, st store.Store, query *CallbackQuery, args []int64, muted bool) string {
+	manga, subscription, notice := callbackManga(st, query, args)
+	if manga == nil {
+		return notice
+	}
+	if subscription == nil {
+		setNotificationKeyboard(bot, query, nil)
+		return "Вы не подписаны на эту мангу"
+	}
+
+	// Уже в нужном состоянии (например, повторное нажатие) — только обновляем кнопки
+	if subscription.Notify != muted {
+		setNotificationKeyboard(bot, query, notificationKeyboard(manga.ID, muted))
+		return ""
+	}
+
+	if muted {
+		if err := muteSubscription(st, query.From.ID, manga, nil, "button"); err != nil {
+			return "Ошибка при сохранении"
+		}
+		setNotificationKeyboard(bot, query, notificationKeyboard(manga.ID, true))
+		return fmt.Sprintf("Уведомления о «%s» приостановлены", truncateRunes(manga.Title, noticeTitleLimit))
+	}
+
+	missed, err := unmuteSubscription(st, query.From.ID, manga, "button")
+	if err != nil {
+		return "Ошибка при сохранении"
+	}
+	setNotificationKeyboard(bot, query, notificationKeyboard(manga.ID, false))
+
+	if len(missed) > 0 {
+		return fmt.Sprintf("Уведомления о «%s» включены, пропущено глав: %d", truncateRunes(manga.Title, noticeTitleLimit), len(missed))
+	}
+	return fmt.Sprintf("Уведомления о «%s» включены", truncateRunes(manga.Title, noticeTitleLimit))
+}
+
+// handleUnsubscribeCallback "Отписаться": удаляет подписку, под уведомлением остаётся кнопка отмены
+func handleUnsubscribeCallback(bot *TelegramBot, st store.Store, query *CallbackQuery, args []int64) string {
+	manga, _, notice := callbackManga(st, query, args)
+	if manga == nil {
+		return notice
+	}
+
+	removed, err := unsubscribe(st, query.From.ID, manga, "button")
+	if err != nil {
+		return "Ошибка при удалении подписки"
+	}
+
+	setNotificationKeyboard(bot, query, &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{
+		{Text: "↩️ Вернуть подписку", CallbackData: callbackData(callbackResubscribe, int64(manga.ID))},
+	}}})
+
+	if !removed {
+		return "Вы уже отписались"
+	}
+	return fmt.Sprintf("Вы отписались от «%s»", truncateRunes(manga.Title, noticeTitleLimit))
+}
+
+// handleResubscribeCallback "Вернуть подписку" после отписки из уведомления
+func handleResubscribeCallback(bot *TelegramBot, st store.Store, query *CallbackQuery, args []int64) string {
+	manga, subscription, notice := callbackManga(st, query, args)
+	if manga == nil {
+		return notice
+	}
+
+	muted := subscription != nil && !subscription.Notify
+
+	if subscription == nil {
+		var err error
+		if muted, err = resubscribe(st, query.From.ID, manga); err != nil {
+			return "Ошибка при создании подписки"
+		}
+	}
+
+	setNotificationKeyboard(bot, query, notificationKeyboard(manga.ID, muted))
+	return fmt.Sprintf("Подписка на «%s» возвращена", truncateRunes(manga.Title, noticeTitleLimit))
+}
+
+// resubscribe создаёт подписку заново с настройками из последнего события отписки
+// (фильтр команд и приглушение, если его срок не истёк). Возвращает, приглушена ли подписка.
+func resubscribe(st store.Store, userID int64, manga *types.Manga) (bool, error) {
+	if _, err := st.CreateSubscription(userID, manga.ID); err != nil {
+		log.Printf("Ошибка создания подписки: %v", err)
+		return false, err
+	}
+
+	payload := map[string]any{"via": "undo"}
+	muted := false
+
+	events, err := st.GetEvents(types.EventFilter{Type: types.EventUnsubscribe, UserID: userID, MangaID: manga.ID, Limit: 1})
+	if err != nil {
+		log.Printf("Ошибка получения настроек прежней подписки: %v", err)
+	}
+
+	if len(events) > 0 {
+		settings := events[0].Payload
+
+		mode, _ := settings["team_mode"].(string)
+		team, _ := settings["preferred_team"].(string)
+		if mode != "" && types.TeamMode(mode) != types.TeamModeAll {
+			if err := st.UpdateSubscriptionTeam(userID, manga.ID, types.TeamMode(mode), team); err != nil {
+				log.Printf("Ошибка восстановления фильтра команд: %v", err)
+			} else {
+				payload["team_mode"] = mode
+				if team != "" {
+					payload["preferred_team"] = team
+				}
+			}
+		}
+
+		if wasMuted, _ := settings["muted"].(bool); wasMuted {
+			var until *time.Time
+			if raw, ok := settings["muted_until"].(string); ok {
+				if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+					until = &parsed
+				}
+			}
+
+			// Срок приглушения истёк, пока подписки не было, — возвращаем с уведомлениями
+			if until == nil || until.After(time.Now()) {
+				if err := st.MuteSubscription(userID, manga.ID, until); err != nil {
+					log.Printf("Ошибка восстановления приглушения: %v", err)
+				} else {
+					muted = true
+					payload["muted"] = true
+					if until != nil {
+						payload["muted_until"] = until.UTC().Format(time.RFC3339)
+					}
+				}
+			}
+		}
+	}
+
+	recordEvent(st, types.Event{
+		Type: types.EventSubscribe, ActorID: userID, UserID: userID, MangaID: manga.ID, SourceID: manga.SourceID,
+		Payload: payload,
+	})
+	return muted, nil
+}
@@ -0,0 +1,207 @@
+package telegram
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+func TestCallbackDataRoundTrip(t *testing.T) {
+	tests := []struct {
+		action string
+		args   []int64
+	}{
+		{callbackRemoveClose, nil},
+		{callbackMute, []int64{46}},
+		{callbackRemovePage, []int64{0}},
+		{callbackRemoveAsk, []int64{1234567, 3}},
+		{callbackRemoveConfirm, []int64{math.MaxInt64, math.MaxInt64}},
+	}
+
+	for _, tt := range tests {
+		data := callbackData(tt.action, tt.args...)
+
+		action, args, err := parseCallbackData(data)
+		if err != nil {
+			t.Errorf("parseCallbackData(%q): %v", data, err)
+			continue
+		}
+		if action != tt.action || fmt.Sprint(args) != fmt.Sprint(append([]int64{}, tt.args...)) {
+			t.Errorf("parseCallbackData(%q) = %s %v, ожидалось %s %v", data, action, args, tt.action, tt.args)
+		}
+	}
+}
+
+func TestCallbackDataLimit(t *testing.T) {
+	// Самые длинные данные: код действия и два ID максимального размера
+	for action := range callbackHandlers {
+		data := callbackData(action, math.MaxInt64, math.MaxInt64)
+		if len(data) > 64 {
+			t.Errorf("данные кнопки %q длиной %d байт больше 64", data, len(data))
+		}
+	}
+}
+
+func TestParseCallbackDataMalformed(t *testing.T) {
+	for _, data := range []string{
+		"nm:",
+		"nm::1",
+		"nm:1:",
+		"nm:1a!",
+		"nm:" + strings.Repeat("z", 14), // Больше int64
+		"ry:1,2",
+	} {
+		if action, args, err := parseCallbackData(data); err == nil {
+			t.Errorf("parseCallbackData(%q) = %s %v, ожидалась ошибка", data, action, args)
+		}
+	}
+}
+
+// pageSubscriptions подписки с ID от 1 до n
+func pageSubscriptions(n int) []types.SubscribedManga {
+	subscriptions := make([]types.SubscribedManga, n)
+	for i := range subscriptions {
+		subscriptions[i].ID = i + 1
+		subscriptions[i].Title = fmt.Sprintf("Манга %d", i+1)
+		subscriptions[i].SourceName = string(types.SourceReadmanga)
+	}
+	return subscriptions
+}
+
+func TestRemovePage(t *testing.T) {
+	tests := []struct {
+		name          string
+		subscriptions int
+		page          int
+		wantPage      int   // Страница, которая будет показана
+		wantIDs       []int // Манга на кнопках
+		wantPrev      bool
+		wantNext      bool
+	}{
+		{"одна подписка", 1, 0, 0, []int{1}, false, false},
+		{"ровно страница", removePageSize, 0, 0, []int{1, 2, 3, 4, 5, 6, 7, 8}, false, false},
+		{"первая из трёх", 17, 0, 0, []int{1, 2, 3, 4, 5, 6, 7, 8}, false, true},
+		{"средняя", 17, 1, 1, []int{9, 10, 11, 12, 13, 14, 15, 16}, true, true},
+		{"последняя неполная", 17, 2, 2, []int{17}, true, false},
+		{"за последней", 17, 5, 2, []int{17}, true, false},
+		{"отрицательная", 17, -1, 0, []int{1, 2, 3, 4, 5, 6, 7, 8}, false, true},
+		{"страница исчезла после отписки", 16, 2, 1, []int{9, 10, 11, 12, 13, 14, 15, 16}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, keyboard := removePage(pageSubscriptions(tt.subscriptions), tt.page)
+
+			var ids []int
+			var prev, next bool
+			for _, row := range keyboard.InlineKeyboard {
+				for _, button := range row {
+					action, args, err := parseCallbackData(button.CallbackData)
+					if err != nil {
+						t.Fatalf("кнопка %q: %v", button.CallbackData, err)
+					}
+					switch action {
+					case callbackRemoveAsk:
+						ids = append(ids, int(args[0]))
+						if int(args[1]) != tt.wantPage {
+							t.Errorf("кнопка манги %d ведёт на страницу %d, ожидалась %d", args[0], args[1], tt.wantPage)
+						}
+					case callbackRemovePage:
+						switch int(args[0]) {
+						case tt.wantPage - 1:
+							prev = true
+						case tt.wantPage + 1:
+							next = true
+						default:
+							t.Errorf("навигация на страницу %d со страницы %d", args[0], tt.wantPage)
+						}
+					}
+				}
+			}
+
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("манга на странице %v, ожидалось %v", ids, tt.wantIDs)
+			}
+			if prev != tt.wantPrev || next != tt.wantNext {
+				t.Errorf("навигация назад %v, вперёд %v, ожидалось %v и %v", prev, next, tt.wantPrev, tt.wantNext)
+			}
+
+			pages := (tt.subscriptions + removePageSize - 1) / removePageSize
+			if wantCounter := fmt.Sprintf("страница %d из %d", tt.wantPage+1, pages); pages > 1 && !strings.Contains(text, wantCounter) {
+				t.Errorf("текст %q без %q", text, wantCounter)
+			}
+		})
+	}
+}
+
+func TestResubscribeRestoresSettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		mutedFor   time.Duration // 0 — не приглушена, <0 — срок истёк до возврата подписки
+		wantNotify bool
+	}{
+		{"без приглушения", 0, true},
+		{"приглушена на сутки", 24 * time.Hour, false},
+		{"срок приглушения истёк", -time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := store.NewMemory()
+			source := st.AddSource(types.SourceReadmanga, "https://readmanga.example")
+			manga, err := st.CreateManga(source.ID, "berserk", "Берсерк")
+			if err != nil {
+				t.Fatalf("CreateManga: %v", err)
+			}
+			user, err := st.GetOrCreateUser(1001, "reader", "Читатель", "")
+			if err != nil {
+				t.Fatalf("GetOrCreateUser: %v", err)
+			}
+
+			if _, err := st.CreateSubscription(user.ID, manga.ID); err != nil {
+				t.Fatalf("CreateSubscription: %v", err)
+			}
+			if err := st.UpdateSubscriptionTeam(user.ID, manga.ID, types.TeamModeTeam, "Команда А"); err != nil {
+				t.Fatalf("UpdateSubscriptionTeam: %v", err)
+			}
+
+			var until time.Time
+			if tt.mutedFor != 0 {
+				until = time.Now().Add(tt.mutedFor).Truncate(time.Second)
+				if err := st.MuteSubscription(user.ID, manga.ID, &until); err != nil {
+					t.Fatalf("MuteSubscription: %v", err)
+				}
+			}
+
+			bot := &TelegramBot{}
+			query := &CallbackQuery{ID: "1", From: &TgUser{ID: user.ID}, Message: &TgMessage{MessageID: 7, Chat: &TgChat{ID: user.ID}}}
+			args := []int64{int64(manga.ID)}
+
+			handleUnsubscribeCallback(bot, st, query, args)
+			if sub, _ := st.GetSubscription(user.ID, manga.ID); sub != nil {
+				t.Fatal("подписка не удалена")
+			}
+
+			handleResubscribeCallback(bot, st, query, args)
+
+			sub, err := st.GetSubscription(user.ID, manga.ID)
+			if err != nil || sub == nil {
+				t.Fatalf("подписка не возвращена (ошибка: %v)", err)
+			}
+			if sub.TeamMode != types.TeamModeTeam || sub.PreferredTeam != "Команда А" {
+				t.Errorf("фильтр команд %s %q, ожидалось team «Команда А»", sub.TeamMode, sub.PreferredTeam)
+			}
+			if sub.Notify != tt.wantNotify {
+				t.Errorf("notify=%v, ожидалось %v", sub.Notify, tt.wantNotify)
+			}
+			if !tt.wantNotify && (sub.MutedUntil == nil || !sub.MutedUntil.Equal(until)) {
+				t.Errorf("приглушена до %v, ожидалось %v", sub.MutedUntil, until)
+			}
+		})
+	}
+}
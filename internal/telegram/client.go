@@ -10,6 +10,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
 	"github.com/joho/godotenv"
 )
 
@@ -84,6 +85,12 @@ func SetBotCommands(bot *TelegramBot) error {
 			{Command: "sources", Description: "Список источников"},
 			{Command: "add", Description: "Добавить мангу по URL"},
 			{Command: "list", Description: "Мои подписки"},
+			{Command: "find", Description: "Поиск по названию"},
+			{Command: "remove", Description: "Отписаться от манги"},
+			{Command: "team", Description: "Фильтр по командам перевода"},
+			{Command: "mute", Description: "Приостановить уведомления"},
+			{Command: "unmute", Description: "Возобновить уведомления"},
+			{Command: "download", Description: "Скачать главу в CBZ"},
 			{Command: "help", Description: "Справка"},
 		},
 	}
@@ -122,7 +129,7 @@ func SetBotCommands(bot *TelegramBot) error {
 }
 
 // StartPolling запускает long polling для получения обновлений
-func StartPolling(bot *TelegramBot) {
+func StartPolling(bot *TelegramBot, st store.Store) {
 	if !bot.Enabled {
 		log.Println("Polling не запущен: бот отключен")
 		return
@@ -144,7 +151,10 @@ func StartPolling(bot *TelegramBot) {
 			offset = update.UpdateID + 1
 
 			if update.Message != nil {
-				HandleMessage(bot, update.Message)
+				HandleMessage(bot, st, update.Message)
+			}
+			if update.CallbackQuery != nil {
+				HandleCallback(bot, st, update.CallbackQuery)
 			}
 		}
 
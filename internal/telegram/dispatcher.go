@@ -0,0 +1,202 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// DispatcherConfig настройки доставки уведомлений из outbox
+type DispatcherConfig struct {
+	Interval      time.Duration // Пауза между проходами по очереди
+	BatchSize     int           // Сколько уведомлений брать за проход
+	MaxAttempts   int           // После стольких неудачных попыток уведомление помечается failed
+	RetryDelay    time.Duration // Пауза перед первым повтором, далее удваивается
+	MaxRetryDelay time.Duration // Максимальная пауза между повторами
+}
+
+// LoadDispatcherConfig читает настройки из NOTIFY_DISPATCH_INTERVAL, NOTIFY_BATCH_SIZE,
+// NOTIFY_MAX_ATTEMPTS, NOTIFY_RETRY_DELAY и NOTIFY_MAX_RETRY_DELAY
+func LoadDispatcherConfig() DispatcherConfig {
+	return DispatcherConfig{
+		Interval:      envDuration("NOTIFY_DISPATCH_INTERVAL", 5*time.Second),
+		BatchSize:     max(1, envInt("NOTIFY_BATCH_SIZE", 50)),
+		MaxAttempts:   max(1, envInt("NOTIFY_MAX_ATTEMPTS", 8)),
+		RetryDelay:    envDuration("NOTIFY_RETRY_DELAY", 30*time.Second),
+		MaxRetryDelay: envDuration("NOTIFY_MAX_RETRY_DELAY", time.Hour),
+	}
+}
+
+// retryDelay пауза перед повтором после попытки attempt (с 1)
+func (c DispatcherConfig) retryDelay(attempt int) time.Duration {
+	delay := c.RetryDelay << (attempt - 1)
+	if delay > c.MaxRetryDelay || delay <= 0 {
+		delay = c.MaxRetryDelay
+	}
+	return delay
+}
+
+// StartDispatcher доставляет уведомления из outbox, пока работает бот.
+// Уведомление отмечается отправленным только после ответа Telegram, поэтому при падении
+// между отправкой и отметкой оно уйдёт повторно (доставка минимум один раз).
+func StartDispatcher(bot *TelegramBot, st store.Store) {
+	if !bot.Enabled {
+		log.Println("Доставка уведомлений не запущена: бот отключен")
+		return
+	}
+
+	cfg := LoadDispatcherConfig()
+	log.Printf("Запуск доставки уведомлений (каждые %v)", cfg.Interval)
+
+	for {
+		resumeExpiredMutes(st)
+
+		// Полная пачка — в очереди могут быть ещё уведомления, берём следующую без паузы
+		if DispatchNotifications(bot, st, cfg) < cfg.BatchSize {
+			time.Sleep(cfg.Interval)
+		}
+	}
+}
+
+// DispatchNotifications отправляет одну пачку уведомлений, срок которых наступил.
+// Возвращает, сколько уведомлений было обработано.
+func DispatchNotifications(bot *TelegramBot, st store.Store, cfg DispatcherConfig) int {
+	notifications, err := st.GetDueNotifications(time.Now(), cfg.BatchSize)
+	if err != nil {
+		log.Printf("Ошибка получения уведомлений: %v", err)
+		return 0
+	}
+
+	mangaCache := make(map[int]*types.Manga)
+	sourceCache := make(map[int]*types.Source)
+
+	for _, n := range notifications {
+		manga, source, err := notificationTarget(st, n.MangaID, mangaCache, sourceCache)
+		if err == nil {
+			err = SendMangaUpdateToUser(bot, n.UserID, source.BaseURL, *manga, n.Chapters)
+		}
+
+		if err == nil {
+			if err := st.MarkNotificationSent(n.ID); err != nil {
+				log.Printf("Ошибка отметки уведомления %d: %v", n.ID, err)
+			}
+			continue
+		}
+
+		attempt := n.Attempts + 1
+
+		var apiErr *APIError
+		isAPIErr := errors.As(err, &apiErr)
+
+		if (isAPIErr && apiErr.Permanent()) || attempt >= cfg.MaxAttempts {
+			log.Printf("Уведомление %d пользователю %d не доставлено (попытка %d): %v", n.ID, n.UserID, attempt, err)
+			if err := st.MarkNotificationFailed(n.ID, err.Error()); err != nil {
+				log.Printf("Ошибка отметки уведомления %d: %v", n.ID, err)
+			}
+			// Пользователь заблокировал бота — отмечаем неактивным, его удалит обслуживание
+			if isAPIErr && apiErr.Code == 403 {
+				markUserBlocked(st, n.UserID)
+			}
+			continue
+		}
+
+		delay := cfg.retryDelay(attempt)
+		if isAPIErr && apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+
+		log.Printf("Ошибка отправки уведомления %d пользователю %d (попытка %d/%d): %v. Повтор через %v",
+			n.ID, n.UserID, attempt, cfg.MaxAttempts, err, delay)
+		if err := st.MarkNotificationRetry(n.ID, err.Error(), time.Now().Add(delay)); err != nil {
+			log.Printf("Ошибка отметки уведомления %d: %v", n.ID, err)
+		}
+	}
+
+	return len(notifications)
+}
+
+// markUserBlocked отмечает пользователя неактивным и пишет событие один раз,
+// даже если в пачке несколько его уведомлений
+func markUserBlocked(st store.Store, userID int64) {
+	user, err := st.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Ошибка получения пользователя %d: %v", userID, err)
+		return
+	}
+	if user == nil || !user.IsActive {
+		return
+	}
+
+	if err := st.SetUserActive(userID, false); err != nil {
+		log.Printf("Ошибка отметки пользователя %d: %v", userID, err)
+		return
+	}
+	recordEvent(st, types.Event{Type: types.EventUserBlocked, UserID: userID})
+}
+
+// notificationTarget возвращает мангу и источник уведомления (с кэшем в пределах пачки)
+func notificationTarget(st store.Store, mangaID int, mangaCache map[int]*types.Manga, sourceCache map[int]*types.Source) (*types.Manga, *types.Source, error) {
+	manga, ok := mangaCache[mangaID]
+	if !ok {
+		var err error
+		manga, err = st.GetMangaByID(mangaID)
+		if err != nil {
+			return nil, nil, err
+		}
+		mangaCache[mangaID] = manga
+	}
+	if manga == nil {
+		return nil, nil, fmt.Errorf("манга %d не найдена", mangaID)
+	}
+
+	source, ok := sourceCache[manga.SourceID]
+	if !ok {
+		var err error
+		source, err = st.GetSourceByID(manga.SourceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		sourceCache[manga.SourceID] = source
+	}
+	if source == nil {
+		return nil, nil, fmt.Errorf("источник %d не найден", manga.SourceID)
+	}
+
+	return manga, source, nil
+}
+
+// envInt возвращает целочисленную переменную окружения или значение по умолчанию
+func envInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// envDuration возвращает переменную-длительность ("30s", "5m") или значение по умолчанию
+func envDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
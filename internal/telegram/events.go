@@ -0,0 +1,147 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+const (
+	// eventsLimit сколько событий показывает /events
+	eventsLimit = 20
+	// eventPayloadLimit сколько символов подробностей показывать на событие: строка без подробностей
+	// занимает до ~135 символов, и 20 событий должны уместиться в сообщение Telegram (4096 символов)
+	eventPayloadLimit = 60
+)
+
+// recordEvent пишет событие в журнал. Ошибка журнала не отменяет уже выполненное действие.
+func recordEvent(st store.Store, event types.Event) {
+	if err := st.RecordEvent(event); err != nil {
+		log.Printf("Ошибка записи события %s: %v", event.Type, err)
+	}
+}
+
+// handleEvents обработка админской команды /events [user ID|manga URL|source имя|type тип]:
+// последние события журнала действий
+func handleEvents(bot *TelegramBot, st store.Store, chatID int64, text string) {
+	filter := types.EventFilter{Limit: eventsLimit}
+
+	args := strings.Fields(strings.TrimPrefix(text, "/events"))
+	if len(args) > 0 {
+		if len(args) != 2 {
+			sendMessageToChat(bot, chatID, eventsUsage)
+			return
+		}
+
+		switch args[0] {
+		case "user":
+			id, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				sendMessageToChat(bot, chatID, eventsUsage)
+				return
+			}
+			filter.UserID = id
+		case "actor":
+			id, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				sendMessageToChat(bot, chatID, eventsUsage)
+				return
+			}
+			filter.ActorID = id
+		case "manga":
+			manga, err := findMangaByURL(st, args[1])
+			if err != nil {
+				sendMessageToChat(bot, chatID, fmt.Sprintf("❌ %v", err))
+				return
+			}
+			filter.MangaID = manga.ID
+		case "source":
+			source, err := st.GetSourceByName(types.SourceName(args[1]))
+			if err != nil {
+				log.Printf("Ошибка поиска источника: %v", err)
+				sendMessageToChat(bot, chatID, "❌ Ошибка при поиске источника")
+				return
+			}
+			if source == nil {
+				sendMessageToChat(bot, chatID, fmt.Sprintf("❌ Источник <b>%s</b> не найден", escapeHTML(args[1])))
+				return
+			}
+			filter.SourceID = source.ID
+		case "type":
+			filter.Type = types.EventType(args[1])
+		default:
+			sendMessageToChat(bot, chatID, eventsUsage)
+			return
+		}
+	}
+
+	events, err := st.GetEvents(filter)
+	if err != nil {
+		log.Printf("Ошибка получения событий: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка получения журнала")
+		return
+	}
+
+	if len(events) == 0 {
+		sendMessageToChat(bot, chatID, "📭 Событий не найдено")
+		return
+	}
+
+	sendMessageToChat(bot, chatID, formatEvents(events))
+}
+
+// formatEvents сообщение со списком событий
+func formatEvents(events []types.Event) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🗂 <b>Журнал действий (последние %d):</b>\n\n", len(events)))
+	for _, event := range events {
+		sb.WriteString(formatEvent(event))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+const eventsUsage = `❓ Использование:
+/events — последние события
+/events user &lt;ID&gt; — события пользователя
+/events actor &lt;ID&gt; — действия пользователя или администратора
+/events manga &lt;URL&gt; — события манги
+/events source &lt;имя&gt; — события источника
+/events type &lt;тип&gt; — события типа (subscribe, unsubscribe, mute, unmute, settings, source_toggle, work_merge, work_alias, user_blocked)`
+
+// formatEvent строка журнала: время, тип, участники и подробности
+func formatEvent(event types.Event) string {
+	var parts []string
+	if event.ActorID != 0 {
+		parts = append(parts, fmt.Sprintf("инициатор %d", event.ActorID))
+	} else {
+		parts = append(parts, "система")
+	}
+	if event.UserID != 0 && event.UserID != event.ActorID {
+		parts = append(parts, fmt.Sprintf("пользователь %d", event.UserID))
+	}
+	if event.MangaID != 0 {
+		parts = append(parts, fmt.Sprintf("манга %d", event.MangaID))
+	}
+	if event.SourceID != 0 {
+		parts = append(parts, fmt.Sprintf("источник %d", event.SourceID))
+	}
+
+	line := fmt.Sprintf("<code>%s</code> <b>%s</b> %s",
+		event.CreatedAt.Format("02.01.2006 15:04"), escapeHTML(string(event.Type)), strings.Join(parts, ", "))
+
+	// Подробности обрезаются до экранирования, чтобы не разрезать HTML-сущность
+	if len(event.Payload) > 0 {
+		payload, err := json.Marshal(event.Payload)
+		if err == nil {
+			line += fmt.Sprintf(" <code>%s</code>", escapeHTML(truncateRunes(string(payload), eventPayloadLimit)))
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,47 @@
+package telegram
+
+import (
+	"html"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+var htmlTag = regexp.MustCompile(`<[^>]+>`)
+
+// visibleLength длина сообщения так, как её считает Telegram: без разметки, в UTF-16
+func visibleLength(message string) int {
+	text := html.UnescapeString(htmlTag.ReplaceAllString(message, ""))
+	return len(utf16.Encode([]rune(text)))
+}
+
+func TestFormatEventsFitsMessage(t *testing.T) {
+	events := make([]types.Event, eventsLimit)
+	for i := range events {
+		events[i] = types.Event{
+			Type:      types.EventSourceToggle,
+			ActorID:   math.MaxInt64,
+			UserID:    math.MaxInt64 - 1,
+			MangaID:   math.MaxInt32,
+			SourceID:  math.MaxInt32,
+			Payload:   map[string]any{"title": strings.Repeat("Очень длинное название <b>&", 50)},
+			CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		}
+	}
+
+	message := formatEvents(events)
+	if length := visibleLength(message); length > 4096 {
+		t.Errorf("сообщение /events длиной %d символов не помещается в 4096", length)
+	}
+	if strings.Contains(message, "<b>&") {
+		t.Error("подробности события не экранированы")
+	}
+	if !strings.Contains(message, "…") {
+		t.Error("длинные подробности не обрезаны")
+	}
+}
@@ -1,16 +1,21 @@
 package telegram
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
-	"github.com/SemenovDmitry/manga-crawler-backend/db"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/download"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/fetcher"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/utils"
 )
 
 // HandleMessage обрабатывает входящее сообщение
-func HandleMessage(bot *TelegramBot, msg *TgMessage) {
+func HandleMessage(bot *TelegramBot, st store.Store, msg *TgMessage) {
 	if msg == nil || msg.From == nil {
 		return
 	}
@@ -19,7 +24,7 @@ func HandleMessage(bot *TelegramBot, msg *TgMessage) {
 	chatID := msg.Chat.ID
 
 	// Регистрируем/обновляем пользователя
-	_, err := db.GetOrCreateUser(
+	_, err := st.GetOrCreateUser(
 		msg.From.ID,
 		msg.From.Username,
 		msg.From.FirstName,
@@ -36,14 +41,37 @@ func HandleMessage(bot *TelegramBot, msg *TgMessage) {
 	case text == "/help":
 		handleHelp(bot, chatID)
 	case text == "/sources":
-		handleSources(bot, chatID)
+		handleSources(bot, st, chatID)
 	case text == "/list":
-		handleList(bot, chatID, msg.From.ID)
+		handleList(bot, st, chatID, msg.From.ID)
+	case strings.HasPrefix(text, "/remove"):
+		handleRemove(bot, st, chatID, msg.From.ID, text)
+	case strings.HasPrefix(text, "/find"):
+		handleFind(bot, st, chatID, msg.From.ID, text)
 	case strings.HasPrefix(text, "/add"):
-		handleAdd(bot, chatID, msg.From.ID, text)
+		handleAdd(bot, st, chatID, msg.From.ID, text)
+	case strings.HasPrefix(text, "/download"):
+		// Скачивание может занять минуты — не блокируем обработку остальных сообщений
+		go handleDownload(bot, st, chatID, text)
+	case strings.HasPrefix(text, "/team"):
+		handleTeam(bot, st, chatID, msg.From.ID, text)
+	case strings.HasPrefix(text, "/mute"):
+		handleMute(bot, st, chatID, msg.From.ID, text)
+	case strings.HasPrefix(text, "/unmute"):
+		handleUnmute(bot, st, chatID, msg.From.ID, text)
+	case text == "/stats" && isAdmin(bot, chatID):
+		handleStats(bot, chatID)
+	case strings.HasPrefix(text, "/link") && isAdmin(bot, chatID):
+		handleLink(bot, st, chatID, msg.From.ID, text)
+	case strings.HasPrefix(text, "/alias") && isAdmin(bot, chatID):
+		handleAlias(bot, st, chatID, msg.From.ID, text)
+	case strings.HasPrefix(text, "/source ") && isAdmin(bot, chatID):
+		handleSourceToggle(bot, st, chatID, msg.From.ID, text)
+	case strings.HasPrefix(text, "/events") && isAdmin(bot, chatID):
+		handleEvents(bot, st, chatID, text)
 	case strings.HasPrefix(text, "http://") || strings.HasPrefix(text, "https://"):
 		// Если пользователь просто отправил URL
-		handleAddManga(bot, chatID, msg.From.ID, text)
+		handleAddManga(bot, st, chatID, msg.From.ID, text)
 	default:
 		// Неизвестная команда
 		sendMessageToChat(bot, chatID, "Неизвестная команда. Используйте /help для справки.")
@@ -66,6 +94,12 @@ func handleStart(bot *TelegramBot, chatID int64) {
 /sources — список источников
 /add — добавить мангу
 /list — мои подписки
+/find — поиск по названию
+/remove — отписаться от манги
+/team — фильтр по командам перевода
+/mute — приостановить уведомления по манге
+/unmute — возобновить уведомления
+/download — скачать главу в CBZ
 /help — справка`
 
 	sendMessageToChat(bot, chatID, message)
@@ -84,14 +118,26 @@ func handleHelp(bot *TelegramBot, chatID int64) {
 /sources — список поддерживаемых источников
 /add — добавить мангу (ожидает URL)
 /list — список отслеживаемых манг
+/find название — поиск среди подписок и уже отслеживаемых ботом манг (можно с опечатками, латиницей или кириллицей)
+/remove — выбрать подписку для отписки
+/remove URL — отписаться от манги по ссылке
+/team URL — команды перевода манги и текущий фильтр
+/team URL команда — уведомлять только о релизах этой команды
+/team URL first — только о первом релизе каждой главы
+/team URL all — о релизах всех команд
+/mute URL — приостановить уведомления о манге до /unmute (манга продолжает проверяться)
+/mute URL 7d — приостановить на срок: 12h, 7d, 2w или число дней
+/unmute — список приглушённых подписок
+/unmute URL — возобновить уведомления и получить пропущенные главы
+/download URL главы — скачать главу архивом CBZ
 /help — эта справка`
 
 	sendMessageToChat(bot, chatID, message)
 }
 
 // handleSources обработка команды /sources
-func handleSources(bot *TelegramBot, chatID int64) {
-	sources, err := db.GetActiveSources()
+func handleSources(bot *TelegramBot, st store.Store, chatID int64) {
+	sources, err := st.GetActiveSources()
 	if err != nil {
 		log.Printf("Ошибка получения источников: %v", err)
 		sendMessageToChat(bot, chatID, "❌ Ошибка получения списка источников")
@@ -119,8 +165,8 @@ func handleSources(bot *TelegramBot, chatID int64) {
 }
 
 // handleList обработка команды /list
-func handleList(bot *TelegramBot, chatID int64, userID int64) {
-	mangaList, err := db.GetUserSubscriptions(userID)
+func handleList(bot *TelegramBot, st store.Store, chatID int64, userID int64) {
+	mangaList, err := st.GetUserSubscriptions(userID)
 	if err != nil {
 		log.Printf("Ошибка получения подписок: %v", err)
 		sendMessageToChat(bot, chatID, "❌ Ошибка получения списка манги")
@@ -136,7 +182,7 @@ func handleList(bot *TelegramBot, chatID int64, userID int64) {
 	sb.WriteString(fmt.Sprintf("📚 <b>Ваши манги (%d):</b>\n", len(mangaList)))
 
 	// Группируем манги по источнику
-	grouped := make(map[string][]db.MangaWithSource)
+	grouped := make(map[string][]types.SubscribedManga)
 	var sourceOrder []string
 
 	for _, manga := range mangaList {
@@ -152,15 +198,94 @@ func handleList(bot *TelegramBot, chatID int64, userID int64) {
 		sb.WriteString(fmt.Sprintf("\n🌐 <b>%s</b> (%d):\n", escapeHTML(sourceName), len(mangas)))
 		for i, manga := range mangas {
 			mangaURL := fmt.Sprintf("%s/%s", manga.SourceBaseURL, manga.URL)
-			sb.WriteString(fmt.Sprintf("%d. <a href=\"%s\">%s</a>\n", i+1, mangaURL, escapeHTML(manga.Title)))
+			sb.WriteString(fmt.Sprintf("%d. <a href=\"%s\">%s</a>", i+1, mangaURL, escapeHTML(manga.Title)))
+			if !manga.Subscription.Notify {
+				sb.WriteString(" 🔕 " + mutedUntilText(manga.Subscription.MutedUntil))
+			}
+			sb.WriteString("\n")
 		}
 	}
 
 	sendMessageToChat(bot, chatID, sb.String())
 }
 
+// findLimit сколько результатов /find показывать в каждом разделе
+const findLimit = 10
+
+// handleFind обработка команды /find <текст>: нечёткий поиск среди подписок пользователя
+// и по всему каталогу уже отслеживаемой манги
+func handleFind(bot *TelegramBot, st store.Store, chatID int64, userID int64, text string) {
+	query := strings.TrimSpace(strings.TrimPrefix(text, "/find"))
+	if query == "" {
+		sendMessageToChat(bot, chatID, "❓ Использование: /find &lt;название&gt;\n\nНапример: <code>/find берсерк</code> или <code>/find berserk</code>")
+		return
+	}
+
+	own, err := st.SearchManga(query, userID, findLimit)
+	if err != nil {
+		log.Printf("Ошибка поиска манги: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка поиска")
+		return
+	}
+
+	// Каталог без уже найденных подписок
+	subscribed := make(map[int]bool, len(own))
+	for _, result := range own {
+		subscribed[result.ID] = true
+	}
+
+	catalogue, err := st.SearchManga(query, 0, findLimit+len(own))
+	if err != nil {
+		log.Printf("Ошибка поиска манги: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка поиска")
+		return
+	}
+
+	var others []types.MangaSearchResult
+	for _, result := range catalogue {
+		if !subscribed[result.ID] && len(others) < findLimit {
+			others = append(others, result)
+		}
+	}
+
+	if len(own) == 0 && len(others) == 0 {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("🔎 По запросу «%s» ничего не найдено.", escapeHTML(query)))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔎 <b>Поиск: %s</b>\n", escapeHTML(query)))
+
+	if len(own) > 0 {
+		sb.WriteString(fmt.Sprintf("\n📚 <b>Ваши подписки (%d):</b>\n", len(own)))
+		writeSearchResults(&sb, own)
+	}
+
+	if len(others) > 0 {
+		sb.WriteString(fmt.Sprintf("\n🌐 <b>Уже отслеживаются ботом (%d):</b>\n", len(others)))
+		writeSearchResults(&sb, others)
+		sb.WriteString("\nЧтобы подписаться, отправьте ссылку на мангу.")
+	}
+
+	sendMessageToChat(bot, chatID, sb.String())
+}
+
+// writeSearchResults выводит найденную мангу списком ссылок
+func writeSearchResults(sb *strings.Builder, results []types.MangaSearchResult) {
+	for i, result := range results {
+		mangaURL := fmt.Sprintf("%s/%s", result.SourceBaseURL, result.URL)
+		sb.WriteString(fmt.Sprintf("%d. <a href=\"%s\">%s</a> — %s", i+1, mangaURL, escapeHTML(result.Title), escapeHTML(result.SourceName)))
+
+		// Совпало альтернативное название — показываем, почему манга найдена
+		if utils.NormalizeTitle(result.MatchedTitle) != utils.NormalizeTitle(result.Title) {
+			sb.WriteString(fmt.Sprintf(" (<i>%s</i>)", escapeHTML(result.MatchedTitle)))
+		}
+		sb.WriteString("\n")
+	}
+}
+
 // handleAdd обработка команды /add
-func handleAdd(bot *TelegramBot, chatID int64, userID int64, text string) {
+func handleAdd(bot *TelegramBot, st store.Store, chatID int64, userID int64, text string) {
 	// Убираем /add и пробелы
 	url := strings.TrimSpace(strings.TrimPrefix(text, "/add"))
 
@@ -169,11 +294,11 @@ func handleAdd(bot *TelegramBot, chatID int64, userID int64, text string) {
 		return
 	}
 
-	handleAddManga(bot, chatID, userID, url)
+	handleAddManga(bot, st, chatID, userID, url)
 }
 
 // handleAddManga обработка добавления манги по URL
-func handleAddManga(bot *TelegramBot, chatID int64, userID int64, rawURL string) {
+func handleAddManga(bot *TelegramBot, st store.Store, chatID int64, userID int64, rawURL string) {
 	// Парсим URL
 	parsed, err := utils.ParseMangaURL(rawURL)
 	if err != nil {
@@ -182,7 +307,7 @@ func handleAddManga(bot *TelegramBot, chatID int64, userID int64, rawURL string)
 	}
 
 	// Ищем источник по базовому URL
-	source, err := db.GetSourceByBaseURL(parsed.BaseURL)
+	source, err := st.GetSourceByBaseURL(parsed.BaseURL)
 	if err != nil {
 		log.Printf("Ошибка поиска источника: %v", err)
 		sendMessageToChat(bot, chatID, "❌ Ошибка при поиске источника")
@@ -191,7 +316,7 @@ func handleAddManga(bot *TelegramBot, chatID int64, userID int64, rawURL string)
 
 	if source == nil {
 		// Пробуем поиск по хосту
-		source, err = db.GetSourceByBaseURL(parsed.Host)
+		source, err = st.GetSourceByBaseURL(parsed.Host)
 		if err != nil {
 			log.Printf("Ошибка поиска источника по хосту: %v", err)
 			sendMessageToChat(bot, chatID, "❌ Ошибка при поиске источника")
@@ -205,7 +330,7 @@ func handleAddManga(bot *TelegramBot, chatID int64, userID int64, rawURL string)
 	}
 
 	// Проверяем, есть ли уже такая манга в БД
-	existingManga, err := db.GetMangaBySourceAndURL(source.ID, parsed.MangaPath)
+	existingManga, err := st.GetMangaBySourceAndURL(source.ID, parsed.MangaPath)
 	if err != nil {
 		log.Printf("Ошибка проверки манги: %v", err)
 		sendMessageToChat(bot, chatID, "❌ Ошибка при проверке манги")
@@ -214,14 +339,14 @@ func handleAddManga(bot *TelegramBot, chatID int64, userID int64, rawURL string)
 
 	if existingManga != nil {
 		// Манга уже существует — проверяем подписку
-		subscription, err := db.GetSubscription(userID, existingManga.ID)
+		subscription, err := st.GetSubscription(userID, existingManga.ID)
 		if err != nil {
 			log.Printf("Ошибка проверки подписки: %v", err)
 		}
 
 		if subscription != nil {
 			// Уже подписан
-			chapters, _ := db.GetChaptersByMangaID(existingManga.ID)
+			chapters, _ := st.GetChaptersByMangaID(existingManga.ID)
 			var sb strings.Builder
 			sb.WriteString(fmt.Sprintf("ℹ️ Вы уже отслеживаете <b>%s</b>\n\n", escapeHTML(existingManga.Title)))
 
@@ -236,14 +361,16 @@ func handleAddManga(bot *TelegramBot, chatID int64, userID int64, rawURL string)
 		}
 
 		// Не подписан — создаём подписку
-		_, err = db.CreateSubscription(userID, existingManga.ID)
+		_, err = st.CreateSubscription(userID, existingManga.ID)
 		if err != nil {
 			log.Printf("Ошибка создания подписки: %v", err)
 			sendMessageToChat(bot, chatID, "❌ Ошибка при создании подписки")
 			return
 		}
 
-		chapters, _ := db.GetChaptersByMangaID(existingManga.ID)
+		recordEvent(st, types.Event{Type: types.EventSubscribe, ActorID: userID, UserID: userID, MangaID: existingManga.ID, SourceID: source.ID})
+
+		chapters, _ := st.GetChaptersByMangaID(existingManga.ID)
 		var sb strings.Builder
 		sb.WriteString(fmt.Sprintf("✅ Вы подписались на <b>%s</b>\n\n", escapeHTML(existingManga.Title)))
 
@@ -262,14 +389,14 @@ func handleAddManga(bot *TelegramBot, chatID int64, userID int64, rawURL string)
 	sendMessageToChat(bot, chatID, "🔍 Ищу мангу...")
 
 	// Пробуем найти RSS и получить информацию о манге
-	rssURL, err := utils.FindRSSLink(source.BaseURL, parsed.MangaPath)
+	rssURL, err := utils.FindRSSLink(fetcher.Default(), *source, parsed.MangaPath)
 	if err != nil {
 		log.Printf("Ошибка поиска RSS: %v", err)
 		sendMessageToChat(bot, chatID, fmt.Sprintf("❌ Не удалось найти мангу по адресу:\n%s\n\nПроверьте URL и попробуйте снова.", rawURL))
 		return
 	}
 
-	feed, err := utils.GetRSSFeed(rssURL)
+	feed, err := utils.GetRSSFeed(fetcher.Default(), *source, rssURL)
 	if err != nil {
 		log.Printf("Ошибка получения RSS: %v", err)
 		sendMessageToChat(bot, chatID, "❌ Не удалось получить данные о манге")
@@ -290,33 +417,37 @@ func handleAddManga(bot *TelegramBot, chatID int64, userID int64, rawURL string)
 	}
 
 	// Создаём мангу в БД
-	newManga, err := db.CreateManga(source.ID, parsed.MangaPath, transformedFeed.Title)
+	newManga, err := st.CreateManga(source.ID, parsed.MangaPath, transformedFeed.Title)
 	if err != nil {
 		log.Printf("Ошибка создания манги: %v", err)
 		sendMessageToChat(bot, chatID, "❌ Ошибка при сохранении манги")
 		return
 	}
 
-	// Сохраняем главы
-	_, err = db.CreateChapters(newManga.ID, transformedFeed.Chapters)
+	// Сохраняем главы вместе с последней главой (без произведения: о старых главах не уведомляем)
+	_, err = st.IngestChapters(newManga.ID, 0, transformedFeed.Chapters)
 	if err != nil {
 		log.Printf("Ошибка сохранения глав: %v", err)
 	}
 
-	// Обновляем последнюю главу
-	if len(transformedFeed.Chapters) > 0 {
-		lastChapter := transformedFeed.Chapters[0]
-		db.UpdateMangaLastChapter(newManga.ID, lastChapter.URL, lastChapter.Title)
+	// Привязываем к произведению (та же манга может уже отслеживаться на другом источнике)
+	if _, err := store.EnsureMangaWork(st, newManga); err != nil {
+		log.Printf("Ошибка привязки манги к произведению: %v", err)
 	}
 
 	// Создаём подписку
-	_, err = db.CreateSubscription(userID, newManga.ID)
+	_, err = st.CreateSubscription(userID, newManga.ID)
 	if err != nil {
 		log.Printf("Ошибка создания подписки: %v", err)
 		sendMessageToChat(bot, chatID, "❌ Ошибка при создании подписки")
 		return
 	}
 
+	recordEvent(st, types.Event{
+		Type: types.EventSubscribe, ActorID: userID, UserID: userID, MangaID: newManga.ID, SourceID: source.ID,
+		Payload: map[string]any{"new_manga": true},
+	})
+
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("✅ Манга <b>%s</b> добавлена!\n\n", escapeHTML(transformedFeed.Title)))
 	sb.WriteString(fmt.Sprintf("📚 Найдено глав: %d\n", len(transformedFeed.Chapters)))
@@ -330,6 +461,375 @@ func handleAddManga(bot *TelegramBot, chatID int64, userID int64, rawURL string)
 	sendMessageToChat(bot, chatID, sb.String())
 }
 
+// handleTeam обработка команды /team <url> [команда|first|all]
+func handleTeam(bot *TelegramBot, st store.Store, chatID int64, userID int64, text string) {
+	rawURL, team, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(text, "/team")), " ")
+	team = strings.TrimSpace(team)
+
+	if rawURL == "" {
+		sendMessageToChat(bot, chatID, "❓ Использование: /team &lt;URL манги&gt; [команда|first|all]")
+		return
+	}
+
+	manga, err := findMangaByURL(st, rawURL)
+	if err != nil {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("❌ %v", err))
+		return
+	}
+
+	subscription, err := st.GetSubscription(userID, manga.ID)
+	if err != nil {
+		log.Printf("Ошибка проверки подписки: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при проверке подписки")
+		return
+	}
+
+	if subscription == nil {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("ℹ️ Вы не подписаны на <b>%s</b>", escapeHTML(manga.Title)))
+		return
+	}
+
+	translators, err := st.GetMangaTranslators(manga.ID)
+	if err != nil {
+		log.Printf("Ошибка получения команд переводчиков: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка получения списка команд")
+		return
+	}
+
+	// Без аргумента показываем известные команды и текущий фильтр
+	if team == "" {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("👥 <b>%s</b>\n\n", escapeHTML(manga.Title)))
+		sb.WriteString(fmt.Sprintf("Текущий фильтр: %s\n\n", teamModeDescription(*subscription)))
+
+		if len(translators) == 0 {
+			sb.WriteString("Источник не сообщает команды переводчиков для этой манги.")
+		} else {
+			sb.WriteString("Команды перевода:\n")
+			for _, translator := range translators {
+				sb.WriteString(fmt.Sprintf("• <code>%s</code>\n", escapeHTML(translator)))
+			}
+		}
+
+		sendMessageToChat(bot, chatID, sb.String())
+		return
+	}
+
+	mode := types.TeamModeTeam
+	switch strings.ToLower(team) {
+	case string(types.TeamModeAll):
+		mode, team = types.TeamModeAll, ""
+	case string(types.TeamModeFirst):
+		mode, team = types.TeamModeFirst, ""
+	default:
+		known := false
+		for _, translator := range translators {
+			if strings.EqualFold(translator, team) {
+				team, known = translator, true
+				break
+			}
+		}
+		if !known {
+			sendMessageToChat(bot, chatID, fmt.Sprintf("❌ Команда <b>%s</b> не выпускала главы этой манги. Список команд: /team %s", escapeHTML(team), escapeHTML(rawURL)))
+			return
+		}
+	}
+
+	if err := st.UpdateSubscriptionTeam(userID, manga.ID, mode, team); err != nil {
+		log.Printf("Ошибка обновления фильтра команд: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при сохранении фильтра")
+		return
+	}
+
+	recordEvent(st, types.Event{
+		Type: types.EventSettings, ActorID: userID, UserID: userID, MangaID: manga.ID, SourceID: manga.SourceID,
+		Payload: map[string]any{
+			"setting":       "team",
+			"mode":          mode,
+			"team":          team,
+			"previous_mode": subscription.TeamMode,
+			"previous_team": subscription.PreferredTeam,
+		},
+	})
+
+	subscription.TeamMode = mode
+	subscription.PreferredTeam = team
+	sendMessageToChat(bot, chatID, fmt.Sprintf("✅ <b>%s</b>: %s", escapeHTML(manga.Title), teamModeDescription(*subscription)))
+}
+
+// handleDownload обработка команды /download <url главы>: отправляет главу архивом CBZ
+func handleDownload(bot *TelegramBot, st store.Store, chatID int64, text string) {
+	chapterURL := strings.TrimSpace(strings.TrimPrefix(text, "/download"))
+	if chapterURL == "" {
+		sendMessageToChat(bot, chatID, "❓ Использование: /download &lt;URL главы&gt;")
+		return
+	}
+
+	chapter, err := st.GetChapterByURL(chapterURL)
+	if err != nil {
+		log.Printf("Ошибка поиска главы: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при поиске главы")
+		return
+	}
+	if chapter == nil {
+		sendMessageToChat(bot, chatID, "❌ Глава не найдена. Скачать можно только главы отслеживаемой манги — ссылки из уведомлений.")
+		return
+	}
+
+	manga, err := st.GetMangaByID(chapter.MangaID)
+	if err != nil || manga == nil {
+		log.Printf("Ошибка получения манги главы: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при получении манги")
+		return
+	}
+
+	source, err := st.GetSourceByID(manga.SourceID)
+	if err != nil || source == nil {
+		log.Printf("Ошибка получения источника главы: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при получении источника")
+		return
+	}
+
+	sendMessageToChat(bot, chatID, "⏳ Скачиваю главу...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	archive, err := download.NewDownloader().DownloadChapter(ctx, *source, *manga, *chapter)
+	if err != nil {
+		log.Printf("Ошибка скачивания главы %s: %v", chapter.URL, err)
+		sendMessageToChat(bot, chatID, fmt.Sprintf("❌ Не удалось скачать главу: %s", escapeHTML(err.Error())))
+		return
+	}
+
+	caption := fmt.Sprintf("%s\n%s", escapeHTML(manga.Title), chapterTitleHTML(*chapter))
+	if err := sendDocumentToUser(bot, chatID, download.ArchiveName(*manga, *chapter), archive, caption); err != nil {
+		log.Printf("Ошибка отправки архива: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Не удалось отправить архив")
+	}
+}
+
+// teamModeDescription описание фильтра по командам перевода
+func teamModeDescription(subscription types.UserSubscription) string {
+	switch subscription.TeamMode {
+	case types.TeamModeTeam:
+		return fmt.Sprintf("только релизы команды <b>%s</b>", escapeHTML(subscription.PreferredTeam))
+	case types.TeamModeFirst:
+		return "только первый релиз каждой главы"
+	}
+	return "релизы всех команд"
+}
+
+// handleLink обработка админской команды /link <url> <url>: объединяет две манги в одно произведение
+func handleLink(bot *TelegramBot, st store.Store, chatID int64, actorID int64, text string) {
+	args := strings.Fields(strings.TrimPrefix(text, "/link"))
+	if len(args) != 2 {
+		sendMessageToChat(bot, chatID, "❓ Использование: /link &lt;URL манги&gt; &lt;URL той же манги на другом источнике&gt;")
+		return
+	}
+
+	var workIDs [2]int
+	var mangaIDs [2]int
+	for i, rawURL := range args {
+		manga, err := findMangaByURL(st, rawURL)
+		if err != nil {
+			sendMessageToChat(bot, chatID, fmt.Sprintf("❌ %v", err))
+			return
+		}
+
+		workIDs[i], err = store.EnsureMangaWork(st, manga)
+		if err != nil {
+			log.Printf("Ошибка привязки манги к произведению: %v", err)
+			sendMessageToChat(bot, chatID, "❌ Ошибка при привязке манги к произведению")
+			return
+		}
+		mangaIDs[i] = manga.ID
+	}
+
+	if err := st.MergeWorks(workIDs[0], workIDs[1]); err != nil {
+		log.Printf("Ошибка объединения произведений: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при объединении произведений")
+		return
+	}
+
+	recordEvent(st, types.Event{
+		Type: types.EventWorkMerge, ActorID: actorID, MangaID: mangaIDs[0],
+		Payload: map[string]any{"work_id": workIDs[0], "merged_work_id": workIDs[1], "merged_manga_id": mangaIDs[1]},
+	})
+
+	sendMessageToChat(bot, chatID, "✅ Манги объединены в одно произведение. Главы будут анонсироваться один раз.")
+}
+
+// handleAlias обработка админской команды /alias <url> <название>: добавляет альтернативное название произведению
+func handleAlias(bot *TelegramBot, st store.Store, chatID int64, actorID int64, text string) {
+	rawURL, title, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(text, "/alias")), " ")
+	title = strings.TrimSpace(title)
+
+	if rawURL == "" || title == "" {
+		sendMessageToChat(bot, chatID, "❓ Использование: /alias &lt;URL манги&gt; &lt;альтернативное название&gt;")
+		return
+	}
+
+	manga, err := findMangaByURL(st, rawURL)
+	if err != nil {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("❌ %v", err))
+		return
+	}
+
+	workID, err := store.EnsureMangaWork(st, manga)
+	if err != nil {
+		log.Printf("Ошибка привязки манги к произведению: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при привязке манги к произведению")
+		return
+	}
+
+	existing, err := st.GetWorkByTitle(title)
+	if err != nil {
+		log.Printf("Ошибка поиска произведения: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при поиске произведения")
+		return
+	}
+
+	if existing != nil && existing.ID != workID {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("❌ Название уже принадлежит произведению <b>%s</b>. Используйте /link.", escapeHTML(existing.Title)))
+		return
+	}
+
+	if err := st.AddWorkTitle(workID, title); err != nil {
+		log.Printf("Ошибка добавления названия: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при добавлении названия")
+		return
+	}
+
+	recordEvent(st, types.Event{
+		Type: types.EventWorkAlias, ActorID: actorID, MangaID: manga.ID, SourceID: manga.SourceID,
+		Payload: map[string]any{"work_id": workID, "title": title},
+	})
+
+	sendMessageToChat(bot, chatID, fmt.Sprintf("✅ Название <b>%s</b> добавлено к <b>%s</b>", escapeHTML(title), escapeHTML(manga.Title)))
+}
+
+// handleSourceToggle обработка админской команды /source <имя> on|off: включает или выключает проверку источника
+func handleSourceToggle(bot *TelegramBot, st store.Store, chatID int64, actorID int64, text string) {
+	args := strings.Fields(strings.TrimPrefix(text, "/source"))
+	if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+		sendMessageToChat(bot, chatID, "❓ Использование: /source &lt;имя источника&gt; on|off")
+		return
+	}
+
+	source, err := st.GetSourceByName(types.SourceName(args[0]))
+	if err != nil {
+		log.Printf("Ошибка поиска источника: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при поиске источника")
+		return
+	}
+	if source == nil {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("❌ Источник <b>%s</b> не найден", escapeHTML(args[0])))
+		return
+	}
+
+	active := args[1] == "on"
+	if source.IsActive == active {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("ℹ️ Источник <b>%s</b> уже %s", escapeHTML(args[0]), sourceState(active)))
+		return
+	}
+
+	if err := st.SetSourceActive(source.ID, active); err != nil {
+		log.Printf("Ошибка обновления источника: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при обновлении источника")
+		return
+	}
+
+	recordEvent(st, types.Event{
+		Type: types.EventSourceToggle, ActorID: actorID, SourceID: source.ID,
+		Payload: map[string]any{"active": active},
+	})
+
+	sendMessageToChat(bot, chatID, fmt.Sprintf("✅ Источник <b>%s</b> %s", escapeHTML(args[0]), sourceState(active)))
+}
+
+// sourceState описание состояния источника
+func sourceState(active bool) string {
+	if active {
+		return "включён"
+	}
+	return "выключен"
+}
+
+// handleStats обработка админской команды /stats: статистика запросов к источникам по хостам
+func handleStats(bot *TelegramBot, chatID int64) {
+	stats := fetcher.Default().Stats()
+	if len(stats) == 0 {
+		sendMessageToChat(bot, chatID, "📭 Запросов к источникам ещё не было")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📊 <b>Запросы к источникам:</b>\n")
+
+	for _, s := range stats {
+		sb.WriteString(fmt.Sprintf("\n🌐 <b>%s</b>\n", escapeHTML(s.Host)))
+		sb.WriteString(fmt.Sprintf("Запросов: %d, ошибок: %d, повторов: %d\n", s.Requests, s.Errors, s.Retries))
+		sb.WriteString(fmt.Sprintf("Трафик: %d КБ, среднее время: %v\n", s.Bytes/1024, s.AvgDuration().Round(time.Millisecond)))
+		sb.WriteString(fmt.Sprintf("Последний статус: %d (%s)\n", s.LastStatus, s.LastRequestAt.Format("02.01 15:04")))
+		if s.LastError != "" {
+			sb.WriteString(fmt.Sprintf("Последняя ошибка: %s\n", escapeHTML(s.LastError)))
+		}
+	}
+
+	if proxies := fetcher.Default().ProxyStatuses(); len(proxies) > 0 {
+		sb.WriteString("\n🛡 <b>Прокси:</b>\n")
+		for _, p := range proxies {
+			state := "✅"
+			switch {
+			case time.Now().Before(p.QuarantinedUntil):
+				state = fmt.Sprintf("⛔ карантин до %s", p.QuarantinedUntil.Format("15:04"))
+			case !p.Healthy:
+				state = "❌ недоступен"
+			}
+			sb.WriteString(fmt.Sprintf("%s %s\n", escapeHTML(p.URL), state))
+		}
+	}
+
+	sendMessageToChat(bot, chatID, sb.String())
+}
+
+// findMangaByURL ищет уже отслеживаемую мангу по её URL
+func findMangaByURL(st store.Store, rawURL string) (*types.Manga, error) {
+	parsed, err := utils.ParseMangaURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный URL: %w", err)
+	}
+
+	source, err := st.GetSourceByBaseURL(parsed.BaseURL)
+	if err == nil && source == nil {
+		source, err = st.GetSourceByBaseURL(parsed.Host)
+	}
+	if err != nil {
+		log.Printf("Ошибка поиска источника: %v", err)
+		return nil, fmt.Errorf("ошибка при поиске источника")
+	}
+	if source == nil {
+		return nil, fmt.Errorf("источник %s не поддерживается", escapeHTML(parsed.Host))
+	}
+
+	manga, err := st.GetMangaBySourceAndURL(source.ID, parsed.MangaPath)
+	if err != nil {
+		log.Printf("Ошибка поиска манги: %v", err)
+		return nil, fmt.Errorf("ошибка при поиске манги")
+	}
+	if manga == nil {
+		return nil, fmt.Errorf("манга %s не отслеживается", escapeHTML(parsed.MangaPath))
+	}
+
+	return manga, nil
+}
+
+// isAdmin проверяет, что команда пришла из админского чата (TELEGRAM_CHAT_ID)
+func isAdmin(bot *TelegramBot, chatID int64) bool {
+	return bot.ChatID == fmt.Sprintf("%d", chatID)
+}
+
 // sendMessageToChat отправляет сообщение в указанный чат
 func sendMessageToChat(bot *TelegramBot, chatID int64, text string) error {
 	return sendMessageToUser(bot, chatID, text)
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"mime/multipart"
 	"strings"
+	"time"
 
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/fetcher"
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
 )
 
@@ -125,6 +128,10 @@ func sendMessageWithDisabledPreview(bot *TelegramBot, text string) error {
 	return nil
 }
 
+// updateChaptersLimit сколько глав перечислять в уведомлении: после долгого приглушения
+// их может накопиться больше, чем помещается в сообщение Telegram (4096 символов)
+const updateChaptersLimit = 30
+
 // SendMangaUpdateToUser отправка уведомления конкретному пользователю
 func SendMangaUpdateToUser(bot *TelegramBot, chatID int64, sourceUrl string, manga types.Manga, newChapters []types.Chapter) error {
 	if !bot.Enabled || len(newChapters) == 0 {
@@ -139,21 +146,29 @@ func SendMangaUpdateToUser(bot *TelegramBot, chatID int64, sourceUrl string, man
 	if len(newChapters) > 1 {
 		messageText.WriteString(fmt.Sprintf("<a href=\"%s\">%s</a>\n", mangaFullURL, escapeHTML(manga.Title)))
 		messageText.WriteString(fmt.Sprintf("<b>Новые главы: %d</b>\n\n", len(newChapters)))
-		for _, ch := range newChapters {
-			messageText.WriteString(fmt.Sprintf("• <a href=\"%s\">%s</a>\n", ch.URL, escapeHTML(ch.Title)))
+		for _, ch := range newChapters[:min(len(newChapters), updateChaptersLimit)] {
+			messageText.WriteString(fmt.Sprintf("• <a href=\"%s\">%s</a>\n", ch.URL, chapterTitleHTML(ch)))
+		}
+		if len(newChapters) > updateChaptersLimit {
+			messageText.WriteString(fmt.Sprintf("…и ещё %d\n", len(newChapters)-updateChaptersLimit))
 		}
 	} else {
 		chapterURL := newChapters[0].URL
 		messageText.WriteString(fmt.Sprintf("<a href=\"%s\">%s</a>\n", mangaFullURL, escapeHTML(manga.Title)))
-		messageText.WriteString(fmt.Sprintf("Новая глава: <a href=\"%s\">%s</a>", chapterURL, escapeHTML(newChapters[0].Title)))
+		messageText.WriteString(fmt.Sprintf("Новая глава: <a href=\"%s\">%s</a>", chapterURL, chapterTitleHTML(newChapters[0])))
 	}
 
-	// Отправляем сообщение конкретному пользователю
-	return sendMessageToUser(bot, chatID, messageText.String())
+	// Отправляем сообщение конкретному пользователю с кнопками быстрых действий
+	return sendKeyboardToUser(bot, chatID, messageText.String(), notificationKeyboard(manga.ID, false))
 }
 
 // sendMessageToUser отправка сообщения конкретному пользователю
 func sendMessageToUser(bot *TelegramBot, chatID int64, text string) error {
+	return sendKeyboardToUser(bot, chatID, text, nil)
+}
+
+// sendKeyboardToUser отправка сообщения с кнопками (keyboard == nil — без кнопок)
+func sendKeyboardToUser(bot *TelegramBot, chatID int64, text string, keyboard *InlineKeyboardMarkup) error {
 	if !bot.Enabled {
 		return nil
 	}
@@ -163,15 +178,56 @@ func sendMessageToUser(bot *TelegramBot, chatID int64, text string) error {
 		Text:                  text,
 		ParseMode:             "HTML",
 		DisableWebPagePreview: true,
+		ReplyMarkup:           keyboard,
 	}
 
-	jsonData, err := json.Marshal(message)
+	if err := callMethod(bot, "sendMessage", message); err != nil {
+		return err
+	}
+
+	log.Printf("Telegram сообщение отправлено пользователю %d", chatID)
+	return nil
+}
+
+// editMessage заменяет текст и кнопки отправленного сообщения (keyboard == nil — убрать кнопки)
+func editMessage(bot *TelegramBot, chatID, messageID int64, text string, keyboard *InlineKeyboardMarkup) error {
+	return callMethod(bot, "editMessageText", EditMessageText{
+		ChatID:                fmt.Sprintf("%d", chatID),
+		MessageID:             messageID,
+		Text:                  text,
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+		ReplyMarkup:           keyboard,
+	})
+}
+
+// editReplyMarkup заменяет кнопки отправленного сообщения (keyboard == nil — убрать кнопки)
+func editReplyMarkup(bot *TelegramBot, chatID, messageID int64, keyboard *InlineKeyboardMarkup) error {
+	return callMethod(bot, "editMessageReplyMarkup", EditMessageReplyMarkup{
+		ChatID:      fmt.Sprintf("%d", chatID),
+		MessageID:   messageID,
+		ReplyMarkup: keyboard,
+	})
+}
+
+// answerCallback подтверждает нажатие кнопки; text показывается всплывающей подсказкой
+func answerCallback(bot *TelegramBot, callbackID, text string) error {
+	return callMethod(bot, "answerCallbackQuery", AnswerCallbackQuery{CallbackQueryID: callbackID, Text: text})
+}
+
+// callMethod вызывает метод Telegram Bot API с JSON-параметрами
+func callMethod(bot *TelegramBot, method string, payload any) error {
+	if !bot.Enabled {
+		return nil
+	}
+
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("ошибка маршалинга JSON: %v", err)
 	}
 
 	resp, err := bot.Client.Post(
-		fmt.Sprintf("%s/sendMessage", bot.BotURL),
+		fmt.Sprintf("%s/%s", bot.BotURL, method),
 		"application/json",
 		bytes.NewBuffer(jsonData),
 	)
@@ -190,11 +246,93 @@ func sendMessageToUser(bot *TelegramBot, chatID int64, text string) error {
 		return fmt.Errorf("ошибка парсинга ответа: %v", err)
 	}
 
+	if !apiResp.OK {
+		return newAPIError(apiResp)
+	}
+
+	return nil
+}
+
+// APIError ошибка, которую вернул Telegram API
+type APIError struct {
+	Code        int
+	Description string
+	RetryAfter  time.Duration // Пауза, которую просит Telegram (при 429)
+}
+
+func newAPIError(resp APIResponse) *APIError {
+	err := &APIError{Code: resp.ErrorCode, Description: resp.Description}
+	if resp.Parameters != nil {
+		err.RetryAfter = time.Duration(resp.Parameters.RetryAfter) * time.Second
+	}
+	return err
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ошибка Telegram API: %s", e.Description)
+}
+
+// Permanent повтор не поможет: бот заблокирован пользователем, чат не найден, некорректный запрос
+func (e *APIError) Permanent() bool {
+	return e.Code == 400 || e.Code == 403
+}
+
+// sendDocumentToUser отправка файла конкретному пользователю (multipart/form-data)
+func sendDocumentToUser(bot *TelegramBot, chatID int64, filename string, data []byte, caption string) error {
+	if !bot.Enabled {
+		return nil
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	fields := map[string]string{
+		"chat_id":    fmt.Sprintf("%d", chatID),
+		"caption":    caption,
+		"parse_mode": "HTML",
+	}
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return fmt.Errorf("ошибка формирования запроса: %v", err)
+		}
+	}
+
+	file, err := form.CreateFormFile("document", filename)
+	if err != nil {
+		return fmt.Errorf("ошибка формирования запроса: %v", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("ошибка формирования запроса: %v", err)
+	}
+	if err := form.Close(); err != nil {
+		return fmt.Errorf("ошибка формирования запроса: %v", err)
+	}
+
+	resp, err := bot.Client.Post(
+		fmt.Sprintf("%s/sendDocument", bot.BotURL),
+		form.FormDataContentType(),
+		&body,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка отправки запроса: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения ответа: %v", err)
+	}
+
+	var apiResp APIResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return fmt.Errorf("ошибка парсинга ответа: %v", err)
+	}
+
 	if !apiResp.OK {
 		return fmt.Errorf("ошибка Telegram API: %s", apiResp.Description)
 	}
 
-	log.Printf("Telegram сообщение отправлено пользователю %d", chatID)
+	log.Printf("Telegram документ %s отправлен пользователю %d", filename, chatID)
 	return nil
 }
 
@@ -230,13 +368,68 @@ func SendStartupNotification(bot *TelegramBot) error {
 	return SendMessage(bot, message)
 }
 
-// SendErrorNotification уведомление об ошибке
-func SendErrorNotification(bot *TelegramBot, mangaName string) error {
+// chapterTitleHTML название главы с командой переводчиков (если известна)
+func chapterTitleHTML(ch types.Chapter) string {
+	if ch.Translator == "" {
+		return escapeHTML(ch.Title)
+	}
+	return fmt.Sprintf("%s [%s]", escapeHTML(ch.Title), escapeHTML(ch.Translator))
+}
+
+// SendSourceDownNotification уведомление о том, что обход источника приостановлен после серии ошибок
+func SendSourceDownNotification(bot *TelegramBot, sourceName types.SourceName, failures int, err error, nextProbe time.Time) error {
 	if !bot.Enabled {
 		return nil
 	}
 
-	message := fmt.Sprintf("🚨 <b>Ошибка парсинга RSS ленты - %s</b>\n", mangaName)
+	// Блокировку стоит назвать явно: её лечит не ожидание, а прокси или cookie
+	var reason string
+	switch fetcher.Classify(err) {
+	case fetcher.ErrorBlocked:
+		reason = "⛔ Источник отклоняет запросы (бан по IP?)\n\n"
+	case fetcher.ErrorChallenge:
+		reason = "⛔ Источник показывает страницу проверки (Cloudflare / DDoS-Guard / капча)\n\n"
+	}
+
+	message := fmt.Sprintf(
+		"🔌 <b>Источник %s приостановлен</b>\n\n%s%d ошибок подряд, последняя:\n<code>%s</code>\n\n<i>Пробный запрос в %s, дальше — с растущим интервалом</i>",
+		escapeHTML(string(sourceName)),
+		reason,
+		failures,
+		escapeHTML(err.Error()),
+		nextProbe.Format("15:04"),
+	)
+
+	return SendMessage(bot, message)
+}
+
+// SendSourceRecoveredNotification уведомление о том, что источник снова отвечает и обход возобновлён
+func SendSourceRecoveredNotification(bot *TelegramBot, sourceName types.SourceName, downtime time.Duration) error {
+	if !bot.Enabled {
+		return nil
+	}
+
+	message := fmt.Sprintf(
+		"✅ <b>Источник %s снова доступен</b>\n\nОбход возобновлён, простой: %s",
+		escapeHTML(string(sourceName)),
+		downtime.Round(time.Minute),
+	)
+
+	return SendMessage(bot, message)
+}
+
+// SendLayoutChangedNotification уведомление о том, что страница получена, но разобрать её не удалось
+func SendLayoutChangedNotification(bot *TelegramBot, sourceName types.SourceName, mangaName string, err error) error {
+	if !bot.Enabled {
+		return nil
+	}
+
+	message := fmt.Sprintf(
+		"🧩 <b>Изменилась вёрстка? %s — %s</b>\n\n<code>%s</code>",
+		escapeHTML(string(sourceName)),
+		escapeHTML(mangaName),
+		escapeHTML(err.Error()),
+	)
 
 	return SendMessage(bot, message)
 }
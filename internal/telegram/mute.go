@@ -0,0 +1,236 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+// maxMuteDuration самый долгий срок приглушения; дольше — бессрочно, до /unmute
+const maxMuteDuration = 365 * 24 * time.Hour
+
+var errMuteTooLong = errors.New("срок не больше года; без срока уведомления приостанавливаются до /unmute")
+
+// handleMute обработка команды /mute <url> [срок]: приостанавливает уведомления по подписке.
+// Манга продолжает проверяться, а после /unmute или окончания срока приходят пропущенные главы.
+func handleMute(bot *TelegramBot, st store.Store, chatID int64, userID int64, text string) {
+	rawURL, rawDuration, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(text, "/mute")), " ")
+	rawDuration = strings.TrimSpace(rawDuration)
+
+	if rawURL == "" {
+		sendMessageToChat(bot, chatID, "❓ Использование: /mute &lt;URL манги&gt; [срок]\n\n"+
+			"Срок: <code>12h</code>, <code>7d</code>, <code>2w</code> или число дней. Без срока — до /unmute.")
+		return
+	}
+
+	var until *time.Time
+	if rawDuration != "" {
+		duration, err := parseMuteDuration(rawDuration)
+		if err != nil {
+			sendMessageToChat(bot, chatID, fmt.Sprintf("❌ %v", err))
+			return
+		}
+		t := time.Now().Add(duration)
+		until = &t
+	}
+
+	manga, err := findMangaByURL(st, rawURL)
+	if err != nil {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("❌ %v", err))
+		return
+	}
+
+	subscription, err := st.GetSubscription(userID, manga.ID)
+	if err != nil {
+		log.Printf("Ошибка проверки подписки: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при проверке подписки")
+		return
+	}
+	if subscription == nil {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("ℹ️ Вы не подписаны на <b>%s</b>", escapeHTML(manga.Title)))
+		return
+	}
+
+	if err := muteSubscription(st, userID, manga, until, "command"); err != nil {
+		sendMessageToChat(bot, chatID, "❌ Ошибка при сохранении")
+		return
+	}
+
+	sendMessageToChat(bot, chatID, fmt.Sprintf("🔕 Уведомления о <b>%s</b> приостановлены %s.\n\n"+
+		"Манга продолжает проверяться: пропущенные главы придут после /unmute или окончания срока.",
+		escapeHTML(manga.Title), mutedUntilText(until)))
+}
+
+// handleUnmute обработка команды /unmute [url]: без аргумента показывает приглушённые подписки,
+// со ссылкой — возобновляет уведомления и присылает пропущенные главы
+func handleUnmute(bot *TelegramBot, st store.Store, chatID int64, userID int64, text string) {
+	rawURL := strings.TrimSpace(strings.TrimPrefix(text, "/unmute"))
+	if rawURL == "" {
+		listMuted(bot, st, chatID, userID)
+		return
+	}
+
+	manga, err := findMangaByURL(st, rawURL)
+	if err != nil {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("❌ %v", err))
+		return
+	}
+
+	subscription, err := st.GetSubscription(userID, manga.ID)
+	if err != nil {
+		log.Printf("Ошибка проверки подписки: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка при проверке подписки")
+		return
+	}
+	if subscription == nil {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("ℹ️ Вы не подписаны на <b>%s</b>", escapeHTML(manga.Title)))
+		return
+	}
+	if subscription.Notify {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("ℹ️ Уведомления о <b>%s</b> уже включены", escapeHTML(manga.Title)))
+		return
+	}
+
+	missed, err := unmuteSubscription(st, userID, manga, "command")
+	if err != nil {
+		sendMessageToChat(bot, chatID, "❌ Ошибка при сохранении")
+		return
+	}
+
+	if len(missed) == 0 {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("🔔 Уведомления о <b>%s</b> включены. Новых глав за это время не было.", escapeHTML(manga.Title)))
+		return
+	}
+
+	// Сами главы уже в outbox: их доставит StartDispatcher с повторами, как обычные уведомления
+	sendMessageToChat(bot, chatID, fmt.Sprintf("🔔 Уведомления о <b>%s</b> включены. Пропущено глав: %d — они придут отдельным сообщением.",
+		escapeHTML(manga.Title), len(missed)))
+}
+
+// listMuted показывает приглушённые подписки пользователя
+func listMuted(bot *TelegramBot, st store.Store, chatID int64, userID int64) {
+	subscriptions, err := st.GetUserSubscriptions(userID)
+	if err != nil {
+		log.Printf("Ошибка получения подписок: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка получения списка манги")
+		return
+	}
+
+	var sb strings.Builder
+	count := 0
+	for _, manga := range subscriptions {
+		if manga.Subscription.Notify {
+			continue
+		}
+		count++
+		mangaURL := fmt.Sprintf("%s/%s", manga.SourceBaseURL, manga.URL)
+		sb.WriteString(fmt.Sprintf("%d. <a href=\"%s\">%s</a> — %s\n   <code>/unmute %s</code>\n",
+			count, mangaURL, escapeHTML(manga.Title), mutedUntilText(manga.Subscription.MutedUntil), escapeHTML(mangaURL)))
+	}
+
+	if count == 0 {
+		sendMessageToChat(bot, chatID, "🔔 Приглушённых подписок нет.\n\nПриостановить уведомления: /mute &lt;URL манги&gt; [срок]")
+		return
+	}
+
+	sendMessageToChat(bot, chatID, fmt.Sprintf("🔕 <b>Приглушённые подписки (%d):</b>\n\n%s", count, sb.String()))
+}
+
+// muteSubscription приглушает подписку и пишет событие
+func muteSubscription(st store.Store, userID int64, manga *types.Manga, until *time.Time, via string) error {
+	if err := st.MuteSubscription(userID, manga.ID, until); err != nil {
+		log.Printf("Ошибка приглушения подписки: %v", err)
+		return err
+	}
+
+	payload := map[string]any{"via": via}
+	if until != nil {
+		payload["until"] = until.UTC().Format(time.RFC3339)
+	}
+	recordEvent(st, types.Event{
+		Type: types.EventMute, ActorID: userID, UserID: userID, MangaID: manga.ID, SourceID: manga.SourceID,
+		Payload: payload,
+	})
+	return nil
+}
+
+// unmuteSubscription возобновляет уведомления и пишет событие. Возвращает пропущенные главы;
+// уведомление о них хранилище ставит в outbox.
+func unmuteSubscription(st store.Store, userID int64, manga *types.Manga, via string) ([]types.Chapter, error) {
+	missed, err := st.UnmuteSubscription(userID, manga.ID)
+	if err != nil {
+		log.Printf("Ошибка возобновления подписки: %v", err)
+		return nil, err
+	}
+
+	recordEvent(st, types.Event{
+		Type: types.EventUnmute, ActorID: userID, UserID: userID, MangaID: manga.ID, SourceID: manga.SourceID,
+		Payload: map[string]any{"via": via, "missed": len(missed)},
+	})
+	return missed, nil
+}
+
+// resumeExpiredMutes возобновляет подписки, срок приглушения которых истёк.
+// Пропущенные главы уходят через outbox, поэтому вызывается перед доставкой уведомлений.
+func resumeExpiredMutes(st store.Store) {
+	resumed, err := st.ResumeExpiredMutes(time.Now())
+	if err != nil {
+		log.Printf("Ошибка возобновления приглушённых подписок: %v", err)
+		return
+	}
+
+	for _, sub := range resumed {
+		recordEvent(st, types.Event{
+			Type: types.EventUnmute, UserID: sub.TelegramUserID, MangaID: sub.MangaID,
+			Payload: map[string]any{"via": "expired"},
+		})
+	}
+}
+
+// parseMuteDuration разбирает срок приглушения: "12h", "90m", "7d", "2w" или число дней
+func parseMuteDuration(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		// Дни и недели time.ParseDuration не понимает; число без единицы — дни
+		count, unit := value, 24*time.Hour
+		if n, ok := strings.CutSuffix(value, "d"); ok {
+			count = n
+		} else if n, ok := strings.CutSuffix(value, "w"); ok {
+			count, unit = n, 7*24*time.Hour
+		}
+
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return 0, fmt.Errorf("некорректный срок %q: используйте 12h, 7d или 2w", escapeHTML(value))
+		}
+		// Проверка до умножения: большое число дней переполнило бы time.Duration
+		if n > int(maxMuteDuration/unit) {
+			return 0, errMuteTooLong
+		}
+		duration = time.Duration(n) * unit
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("срок должен быть больше нуля")
+	}
+	if duration > maxMuteDuration {
+		return 0, errMuteTooLong
+	}
+	return duration, nil
+}
+
+// mutedUntilText описывает срок приглушения для сообщений
+func mutedUntilText(until *time.Time) string {
+	if until == nil {
+		return "до /unmute"
+	}
+	return "до " + until.Local().Format("02.01.2006 15:04")
+}
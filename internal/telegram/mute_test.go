@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseMuteDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration // 0 — ожидается ошибка
+	}{
+		{"12h", 12 * time.Hour},
+		{"90m", 90 * time.Minute},
+		{"7d", 7 * 24 * time.Hour},
+		{" 2W ", 14 * 24 * time.Hour},
+		{"3", 3 * 24 * time.Hour},
+		{"365d", maxMuteDuration},
+		{"52w", 52 * 7 * 24 * time.Hour},
+
+		{"366d", 0},
+		{"53w", 0},
+		{"8761h", 0},
+		{"0", 0},
+		{"0d", 0},
+		{"-1d", 0},
+		{"-5m", 0},
+		{"", 0},
+		{"d", 0},
+		{"неделя", 0},
+		{"1.5d", 0},
+
+		// Умножение таких чисел на единицу переполнило бы time.Duration:
+		// 7+2⁴⁸ дней и 1+2⁴⁸ недель по модулю 2⁶⁴ дают ровно неделю
+		{"106752d", 0},
+		{"15251w", 0},
+		{strconv.Itoa(7+1<<48) + "d", 0},
+		{strconv.Itoa(1+1<<48) + "w", 0},
+		{strconv.Itoa(7 + 1<<48), 0},
+	}
+
+	for _, tt := range tests {
+		got, err := parseMuteDuration(tt.value)
+		if tt.want == 0 {
+			if err == nil {
+				t.Errorf("parseMuteDuration(%q) = %v, ожидалась ошибка", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseMuteDuration(%q) = %v, %v, ожидалось %v", tt.value, got, err, tt.want)
+		}
+	}
+}
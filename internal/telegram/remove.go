@@ -0,0 +1,238 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/store"
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
+)
+
+const (
+	// removePageSize сколько подписок на одной странице выбора
+	removePageSize = 8
+	// removeTitleLimit длина названия на кнопке
+	removeTitleLimit = 40
+)
+
+// handleRemove обработка команды /remove [url]: без аргумента показывает подписки кнопками,
+// со ссылкой — сразу отписывает от манги
+func handleRemove(bot *TelegramBot, st store.Store, chatID int64, userID int64, text string) {
+	rawURL := strings.TrimSpace(strings.TrimPrefix(text, "/remove"))
+	if rawURL != "" {
+		removeByURL(bot, st, chatID, userID, rawURL)
+		return
+	}
+
+	subscriptions, err := st.GetUserSubscriptions(userID)
+	if err != nil {
+		log.Printf("Ошибка получения подписок: %v", err)
+		sendMessageToChat(bot, chatID, "❌ Ошибка получения списка манги")
+		return
+	}
+
+	if len(subscriptions) == 0 {
+		sendMessageToChat(bot, chatID, "📭 У вас нет отслеживаемых манг.")
+		return
+	}
+
+	text, keyboard := removePage(subscriptions, 0)
+	sendKeyboardToUser(bot, chatID, text, keyboard)
+}
+
+// removeByURL отписка по ссылке на мангу
+func removeByURL(bot *TelegramBot, st store.Store, chatID int64, userID int64, rawURL string) {
+	manga, err := findMangaByURL(st, rawURL)
+	if err != nil {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("❌ %v", err))
+		return
+	}
+
+	removed, err := unsubscribe(st, userID, manga, "url")
+	if err != nil {
+		sendMessageToChat(bot, chatID, "❌ Ошибка при удалении подписки")
+		return
+	}
+	if !removed {
+		sendMessageToChat(bot, chatID, fmt.Sprintf("ℹ️ Вы не подписаны на <b>%s</b>", escapeHTML(manga.Title)))
+		return
+	}
+
+	sendMessageToChat(bot, chatID, fmt.Sprintf("✅ Вы отписались от <b>%s</b>", escapeHTML(manga.Title)))
+}
+
+// unsubscribe удаляет подписку и пишет событие. Возвращает false, если подписки не было.
+// Настройки подписки сохраняются в событии — по ним кнопка «Вернуть подписку» восстановит её.
+func unsubscribe(st store.Store, userID int64, manga *types.Manga, via string) (bool, error) {
+	subscription, err := st.GetSubscription(userID, manga.ID)
+	if err != nil {
+		log.Printf("Ошибка проверки подписки: %v", err)
+		return false, err
+	}
+	if subscription == nil {
+		return false, nil
+	}
+
+	if err := st.DeleteSubscription(userID, manga.ID); err != nil {
+		log.Printf("Ошибка удаления подписки: %v", err)
+		return false, err
+	}
+
+	payload := subscriptionSettings(subscription)
+	payload["via"] = via
+	recordEvent(st, types.Event{
+		Type: types.EventUnsubscribe, ActorID: userID, UserID: userID, MangaID: manga.ID, SourceID: manga.SourceID,
+		Payload: payload,
+	})
+	return true, nil
+}
+
+// subscriptionSettings настройки подписки для события отписки: фильтр команд и приглушение
+func subscriptionSettings(subscription *types.UserSubscription) map[string]any {
+	settings := map[string]any{
+		"team_mode": string(subscription.TeamMode),
+		"muted":     !subscription.Notify,
+	}
+	if subscription.PreferredTeam != "" {
+		settings["preferred_team"] = subscription.PreferredTeam
+	}
+	if subscription.MutedUntil != nil {
+		settings["muted_until"] = subscription.MutedUntil.UTC().Format(time.RFC3339)
+	}
+	return settings
+}
+
+// removePage текст и кнопки страницы выбора подписки для отписки
+func removePage(subscriptions []types.SubscribedManga, page int) (string, *InlineKeyboardMarkup) {
+	pages := (len(subscriptions) + removePageSize - 1) / removePageSize
+	page = max(0, min(page, pages-1))
+
+	start := page * removePageSize
+	end := min(start+removePageSize, len(subscriptions))
+
+	var rows [][]InlineKeyboardButton
+	for _, manga := range subscriptions[start:end] {
+		rows = append(rows, []InlineKeyboardButton{{
+			Text:         fmt.Sprintf("%s · %s", truncateRunes(manga.Title, removeTitleLimit), manga.SourceName),
+			CallbackData: callbackData(callbackRemoveAsk, int64(manga.ID), int64(page)),
+		}})
+	}
+
+	var navigation []InlineKeyboardButton
+	if page > 0 {
+		navigation = append(navigation, InlineKeyboardButton{Text: "◀️", CallbackData: callbackData(callbackRemovePage, int64(page-1))})
+	}
+	if page < pages-1 {
+		navigation = append(navigation, InlineKeyboardButton{Text: "▶️", CallbackData: callbackData(callbackRemovePage, int64(page+1))})
+	}
+	if len(navigation) > 0 {
+		rows = append(rows, navigation)
+	}
+	rows = append(rows, []InlineKeyboardButton{{Text: "✖️ Закрыть", CallbackData: callbackData(callbackRemoveClose)}})
+
+	text := fmt.Sprintf("🗑 <b>От какой манги отписаться?</b>\n\nПодписок: %d", len(subscriptions))
+	if pages > 1 {
+		text += fmt.Sprintf(", страница %d из %d", page+1, pages)
+	}
+
+	return text, &InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// showRemovePage перерисовывает сообщение страницей выбора подписки
+func showRemovePage(bot *TelegramBot, st store.Store, query *CallbackQuery, page int) string {
+	subscriptions, err := st.GetUserSubscriptions(query.From.ID)
+	if err != nil {
+		log.Printf("Ошибка получения подписок: %v", err)
+		return "Ошибка получения списка манги"
+	}
+
+	chatID, messageID := query.Message.Chat.ID, query.Message.MessageID
+	if len(subscriptions) == 0 {
+		editMessage(bot, chatID, messageID, "📭 У вас больше нет отслеживаемых манг.", nil)
+		return ""
+	}
+
+	text, keyboard := removePage(subscriptions, page)
+	editMessage(bot, chatID, messageID, text, keyboard)
+	return ""
+}
+
+// handleRemovePageCallback листание страниц выбора
+func handleRemovePageCallback(bot *TelegramBot, st store.Store, query *CallbackQuery, args []int64) string {
+	if len(args) != 1 {
+		return "Кнопка устарела"
+	}
+	return showRemovePage(bot, st, query, int(args[0]))
+}
+
+// handleRemoveCloseCallback закрытие выбора без изменений
+func handleRemoveCloseCallback(bot *TelegramBot, st store.Store, query *CallbackQuery, args []int64) string {
+	editMessage(bot, query.Message.Chat.ID, query.Message.MessageID, "Подписки не изменены.", nil)
+	return ""
+}
+
+// handleRemoveAskCallback выбранная манга: просим подтвердить отписку
+func handleRemoveAskCallback(bot *TelegramBot, st store.Store, query *CallbackQuery, args []int64) string {
+	if len(args) != 2 {
+		return "Кнопка устарела"
+	}
+	page := int(args[1])
+
+	manga, subscription, notice := callbackManga(st, query, args)
+	if manga == nil {
+		showRemovePage(bot, st, query, page)
+		return notice
+	}
+	if subscription == nil {
+		showRemovePage(bot, st, query, page)
+		return "Вы уже отписались"
+	}
+
+	keyboard := &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{
+		{{Text: "✅ Отписаться", CallbackData: callbackData(callbackRemoveConfirm, int64(manga.ID), int64(page))}},
+		{{Text: "↩️ Назад", CallbackData: callbackData(callbackRemovePage, int64(page))}},
+	}}
+	editMessage(bot, query.Message.Chat.ID, query.Message.MessageID,
+		fmt.Sprintf("Отписаться от <b>%s</b>?\n\nУведомления о новых главах приходить перестанут.", escapeHTML(manga.Title)), keyboard)
+	return ""
+}
+
+// handleRemoveConfirmCallback подтверждённая отписка
+func handleRemoveConfirmCallback(bot *TelegramBot, st store.Store, query *CallbackQuery, args []int64) string {
+	if len(args) != 2 {
+		return "Кнопка устарела"
+	}
+	page := int(args[1])
+
+	manga, _, notice := callbackManga(st, query, args)
+	if manga == nil {
+		showRemovePage(bot, st, query, page)
+		return notice
+	}
+
+	removed, err := unsubscribe(st, query.From.ID, manga, "picker")
+	if err != nil {
+		return "Ошибка при удалении подписки"
+	}
+	if !removed {
+		showRemovePage(bot, st, query, page)
+		return "Вы уже отписались"
+	}
+
+	keyboard := &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{
+		{{Text: "↩️ К списку", CallbackData: callbackData(callbackRemovePage, int64(page))}},
+	}}
+	editMessage(bot, query.Message.Chat.ID, query.Message.MessageID, fmt.Sprintf("✅ Вы отписались от <b>%s</b>", escapeHTML(manga.Title)), keyboard)
+	return "Подписка удалена"
+}
+
+// truncateRunes обрезает строку до limit символов с многоточием
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
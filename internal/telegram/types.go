@@ -13,22 +13,74 @@ type TelegramBot struct {
 
 // Message структура для отправки сообщения
 type Message struct {
-	ChatID                string `json:"chat_id"`
-	Text                  string `json:"text"`
-	ParseMode             string `json:"parse_mode,omitempty"`
-	DisableWebPagePreview bool   `json:"disable_web_page_preview,omitempty"`
+	ChatID                string                `json:"chat_id"`
+	Text                  string                `json:"text"`
+	ParseMode             string                `json:"parse_mode,omitempty"`
+	DisableWebPagePreview bool                  `json:"disable_web_page_preview,omitempty"`
+	ReplyMarkup           *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// EditMessageText запрос на замену текста и клавиатуры отправленного сообщения
+type EditMessageText struct {
+	ChatID                string                `json:"chat_id"`
+	MessageID             int64                 `json:"message_id"`
+	Text                  string                `json:"text"`
+	ParseMode             string                `json:"parse_mode,omitempty"`
+	DisableWebPagePreview bool                  `json:"disable_web_page_preview,omitempty"`
+	ReplyMarkup           *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// EditMessageReplyMarkup запрос на замену кнопок отправленного сообщения без изменения текста
+type EditMessageReplyMarkup struct {
+	ChatID      string                `json:"chat_id"`
+	MessageID   int64                 `json:"message_id"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// AnswerCallbackQuery ответ на нажатие кнопки (убирает индикатор загрузки, может показать подсказку)
+type AnswerCallbackQuery struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+}
+
+// InlineKeyboardMarkup кнопки под сообщением
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// InlineKeyboardButton кнопка под сообщением: присылает CallbackData боту или открывает URL
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"` // До 64 байт
+	URL          string `json:"url,omitempty"`
 }
 
 // APIResponse структура ответа от Telegram API
 type APIResponse struct {
-	OK          bool   `json:"ok"`
-	Description string `json:"description,omitempty"`
+	OK          bool                `json:"ok"`
+	ErrorCode   int                 `json:"error_code,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Parameters  *ResponseParameters `json:"parameters,omitempty"`
+}
+
+// ResponseParameters дополнительные сведения об ошибке Telegram API
+type ResponseParameters struct {
+	RetryAfter int `json:"retry_after,omitempty"` // Через сколько секунд можно повторить (при 429)
 }
 
 // Update структура обновления от Telegram
 type Update struct {
-	UpdateID int64      `json:"update_id"`
-	Message  *TgMessage `json:"message,omitempty"`
+	UpdateID      int64          `json:"update_id"`
+	Message       *TgMessage     `json:"message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+// CallbackQuery нажатие кнопки под сообщением бота
+type CallbackQuery struct {
+	ID      string     `json:"id"`
+	From    *TgUser    `json:"from"`
+	Message *TgMessage `json:"message,omitempty"` // Сообщение с кнопкой
+	Data    string     `json:"data,omitempty"`    // CallbackData нажатой кнопки
 }
 
 // TgMessage структура сообщения от Telegram
@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // SourceName тип парсера (enum)
 type SourceName string
@@ -25,6 +28,15 @@ func (s SourceName) IsValid() bool {
 	return false
 }
 
+// TeamMode режим фильтрации релизов по командам перевода в подписке
+type TeamMode string
+
+const (
+	TeamModeAll   TeamMode = "all"   // Уведомлять о релизах всех команд
+	TeamModeTeam  TeamMode = "team"  // Уведомлять только о релизах выбранной команды
+	TeamModeFirst TeamMode = "first" // Уведомлять только о первом релизе каждого номера главы
+)
+
 // TelegramUser пользователь Telegram
 type TelegramUser struct {
 	ID        int64     `db:"id" json:"id"`                 // Уникальный идентификатор пользователя в Telegram (используется как ChatID)
@@ -38,12 +50,13 @@ type TelegramUser struct {
 
 // Source источник/сайт для парсинга манги (хранится в БД)
 type Source struct {
-	ID         int        `db:"id" json:"id"`                   // Уникальный идентификатор источника
-	ParserName SourceName `db:"parser_name" json:"parser_name"` // Имя парсера (readmanga, mintmanga)
-	BaseURL    string     `db:"base_url" json:"base_url"`       // Базовый URL сайта (поддомен)
-	IsActive   bool       `db:"is_active" json:"is_active"`     // Активен ли источник
-	CreatedAt  time.Time  `db:"created_at" json:"created_at"`   // Дата добавления
-	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`   // Дата последнего обновления
+	ID            int        `db:"id" json:"id"`                         // Уникальный идентификатор источника
+	ParserName    SourceName `db:"parser_name" json:"parser_name"`       // Имя парсера (readmanga, mintmanga)
+	BaseURL       string     `db:"base_url" json:"base_url"`             // Базовый URL сайта (поддомен)
+	IsActive      bool       `db:"is_active" json:"is_active"`           // Активен ли источник
+	RespectRobots bool       `db:"respect_robots" json:"respect_robots"` // Соблюдать robots.txt источника
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`         // Дата добавления
+	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`         // Дата последнего обновления
 }
 
 // Manga манга
@@ -55,6 +68,7 @@ type Manga struct {
 	LastChapterURL   string         `db:"last_chapter_url" json:"last_chapter_url"`     // URL последней известной главы
 	LastChapterTitle string         `db:"last_chapter_title" json:"last_chapter_title"` // Название последней главы
 	LastCheckAt      *time.Time     `db:"last_check_at" json:"last_check_at"`           // Время последней проверки обновлений
+	WorkID           int            `db:"work_id" json:"work_id,omitempty"`             // ID произведения (0 — не привязана)
 	CreatedAt        time.Time      `db:"created_at" json:"created_at"`                 // Дата добавления
 	UpdatedAt        time.Time      `db:"updated_at" json:"updated_at"`                 // Дата последнего обновления
 	Chapters         []Chapter      `db:"-" json:"chapters,omitempty"`                  // Главы (не из БД, заполняется отдельно)
@@ -63,20 +77,240 @@ type Manga struct {
 
 // UserSubscription подписка пользователя на мангу
 type UserSubscription struct {
-	ID             int       `db:"id" json:"id"`                 // Уникальный идентификатор подписки
-	TelegramUserID int64     `db:"user_id" json:"user_id"`       // ID пользователя (внешний ключ на telegram_users)
-	MangaID        int       `db:"manga_id" json:"manga_id"`     // ID манги (внешний ключ на manga)
-	Notify         bool      `db:"notify" json:"notify"`         // Отправлять ли уведомления о новых главах
-	CreatedAt      time.Time `db:"created_at" json:"created_at"` // Дата подписки
+	ID             int        `db:"id" json:"id"`                         // Уникальный идентификатор подписки
+	TelegramUserID int64      `db:"user_id" json:"user_id"`               // ID пользователя (внешний ключ на telegram_users)
+	MangaID        int        `db:"manga_id" json:"manga_id"`             // ID манги (внешний ключ на manga)
+	Notify         bool       `db:"notify" json:"notify"`                 // Отправлять ли уведомления о новых главах
+	TeamMode       TeamMode   `db:"team_mode" json:"team_mode"`           // Фильтр по командам перевода
+	PreferredTeam  string     `db:"preferred_team" json:"preferred_team"` // Выбранная команда (для TeamModeTeam)
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`         // Дата подписки
+	MutedAt        *time.Time `db:"muted_at" json:"muted_at"`             // С какого момента приглушена (пропущенные главы считаются от него)
+	MutedUntil     *time.Time `db:"muted_until" json:"muted_until"`       // До какого момента приглушена (nil — бессрочно)
+}
+
+// WantsChapter проверяет, нужно ли уведомлять подписчика о главе с учётом фильтра команд
+func (s UserSubscription) WantsChapter(ch Chapter) bool {
+	switch s.TeamMode {
+	case TeamModeTeam:
+		return strings.EqualFold(ch.Translator, s.PreferredTeam)
+	case TeamModeFirst:
+		return ch.IsFirstRelease
+	}
+	return true
+}
+
+// WantedChapters оставляет главы, о которых нужно уведомить подписчика
+func (s UserSubscription) WantedChapters(chapters []Chapter) []Chapter {
+	var wanted []Chapter
+	for _, ch := range chapters {
+		if s.WantsChapter(ch) {
+			wanted = append(wanted, ch)
+		}
+	}
+	return wanted
+}
+
+// MissedChapters отбирает главы, найденные, пока подписка была приглушена, с учётом фильтра команд.
+// Главы собираются со всех источников произведения, поэтому одна и та же глава (номер и команда)
+// остаётся один раз — из манги подписки, если она есть и там.
+// Признак первого релиза сохраняется только в момент анонса, поэтому в режиме TeamModeFirst
+// возвращаются все пропущенные главы.
+func (s UserSubscription) MissedChapters(chapters []Chapter) []Chapter {
+	if s.MutedAt == nil {
+		return nil
+	}
+
+	type releaseKey struct{ number, translator string }
+	seen := make(map[releaseKey]int)
+
+	var missed []Chapter
+	for _, ch := range chapters {
+		if ch.DiscoveredAt.Before(*s.MutedAt) {
+			continue
+		}
+		if s.TeamMode == TeamModeTeam && !s.WantsChapter(ch) {
+			continue
+		}
+
+		if ch.Number != "" {
+			key := releaseKey{ch.Number, ch.Translator}
+			if i, ok := seen[key]; ok {
+				if ch.MangaID == s.MangaID && missed[i].MangaID != s.MangaID {
+					missed[i] = ch
+				}
+				continue
+			}
+			seen[key] = len(missed)
+		}
+		missed = append(missed, ch)
+	}
+	return missed
+}
+
+// MangaWithSource манга с информацией об источнике
+type MangaWithSource struct {
+	Manga
+	SourceName    string `db:"source_name"`
+	SourceBaseURL string `db:"source_base_url"`
+}
+
+// SubscribedManga манга из подписок пользователя вместе с настройками подписки
+type SubscribedManga struct {
+	MangaWithSource
+	Subscription UserSubscription
+}
+
+// MangaSearchResult найденная манга: какое из названий совпало с запросом и насколько (от 0 до 1)
+type MangaSearchResult struct {
+	MangaWithSource
+	MatchedTitle string
+	Score        float64
+}
+
+// WorkSubscriber подписчик произведения вместе с настройками его подписки
+type WorkSubscriber struct {
+	TelegramUser
+	Subscription UserSubscription
 }
 
 // Chapter глава манги
 type Chapter struct {
-	ID           int       `db:"id" json:"id"`                       // Уникальный идентификатор главы
-	MangaID      int       `db:"manga_id" json:"manga_id"`           // ID манги (внешний ключ на manga)
-	URL          string    `db:"url" json:"url"`                     // URL главы
-	Title        string    `db:"title" json:"title"`                 // Название главы
-	DiscoveredAt time.Time `db:"discovered_at" json:"discovered_at"` // Дата обнаружения главы
+	ID           int       `db:"id" json:"id"`                           // Уникальный идентификатор главы
+	MangaID      int       `db:"manga_id" json:"manga_id"`               // ID манги (внешний ключ на manga)
+	URL          string    `db:"url" json:"url"`                         // URL главы
+	Title        string    `db:"title" json:"title"`                     // Название главы
+	Number       string    `db:"number" json:"number,omitempty"`         // Нормализованный номер главы (пусто, если не распознан)
+	Translator   string    `db:"translator" json:"translator,omitempty"` // Команда переводчиков (пусто, если источник не сообщает)
+	DiscoveredAt time.Time `db:"discovered_at" json:"discovered_at"`     // Дата обнаружения главы
+
+	// IsFirstRelease первый релиз этого номера главы в произведении (заполняется при анонсе, не из БД)
+	IsFirstRelease bool `db:"-" json:"-"`
+}
+
+// Work произведение — группа манги с разных источников (одно и то же тайтл на readmanga, mintmanga, зеркалах)
+type Work struct {
+	ID        int       `db:"id" json:"id"`                 // Уникальный идентификатор произведения
+	Title     string    `db:"title" json:"title"`           // Основное название
+	CreatedAt time.Time `db:"created_at" json:"created_at"` // Дата создания
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"` // Дата последнего обновления
+}
+
+// CrawlStatus результат проверки манги
+type CrawlStatus string
+
+const (
+	CrawlOK    CrawlStatus = "ok"
+	CrawlError CrawlStatus = "error"
+)
+
+// CrawlRecord запись истории проверок
+type CrawlRecord struct {
+	ID          int         `db:"id" json:"id"`                     // Уникальный идентификатор записи
+	SourceID    int         `db:"source_id" json:"source_id"`       // ID источника
+	MangaID     int         `db:"manga_id" json:"manga_id"`         // ID манги (0 — проверка источника целиком)
+	Status      CrawlStatus `db:"status" json:"status"`             // Результат проверки
+	ErrorKind   string      `db:"error_kind" json:"error_kind"`     // Тип ошибки (blocked, challenge, parse_error, store, ...)
+	Error       string      `db:"error" json:"error"`               // Текст ошибки
+	HTTPStatus  int         `db:"http_status" json:"http_status"`   // HTTP статус ответа (0 — не было)
+	NewChapters int         `db:"new_chapters" json:"new_chapters"` // Сколько новых глав найдено
+	CreatedAt   time.Time   `db:"created_at" json:"created_at"`     // Время проверки
+}
+
+// NotificationStatus состояние доставки уведомления
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending" // Ждёт отправки (в том числе повторной)
+	NotificationSent    NotificationStatus = "sent"    // Доставлено
+	NotificationFailed  NotificationStatus = "failed"  // Доставить не удалось, попытки исчерпаны
+)
+
+// Notification исходящее уведомление о новых главах (outbox)
+type Notification struct {
+	ID            int                `db:"id" json:"id"`                           // Уникальный идентификатор уведомления
+	UserID        int64              `db:"user_id" json:"user_id"`                 // Получатель (Telegram user ID)
+	MangaID       int                `db:"manga_id" json:"manga_id"`               // ID манги
+	Chapters      []Chapter          `db:"chapters" json:"chapters"`               // Главы, о которых уведомляем
+	Status        NotificationStatus `db:"status" json:"status"`                   // Состояние доставки
+	Attempts      int                `db:"attempts" json:"attempts"`               // Сколько раз пытались отправить
+	LastError     string             `db:"last_error" json:"last_error"`           // Ошибка последней попытки
+	NextAttemptAt time.Time          `db:"next_attempt_at" json:"next_attempt_at"` // Когда пробовать снова
+	CreatedAt     time.Time          `db:"created_at" json:"created_at"`           // Время создания
+	SentAt        *time.Time         `db:"sent_at" json:"sent_at"`                 // Время доставки
+}
+
+// EventType тип события журнала действий
+type EventType string
+
+const (
+	EventSubscribe    EventType = "subscribe"     // Пользователь подписался на мангу
+	EventUnsubscribe  EventType = "unsubscribe"   // Пользователь отписался от манги
+	EventMute         EventType = "mute"          // Уведомления по подписке приостановлены
+	EventUnmute       EventType = "unmute"        // Уведомления по подписке возобновлены
+	EventSettings     EventType = "settings"      // Изменена настройка подписки (например, фильтр команд)
+	EventSourceToggle EventType = "source_toggle" // Источник включён или выключен
+	EventWorkMerge    EventType = "work_merge"    // Манги объединены в одно произведение
+	EventWorkAlias    EventType = "work_alias"    // Произведению добавлено альтернативное название
+	EventUserBlocked  EventType = "user_blocked"  // Пользователь заблокировал бота
+)
+
+// Event запись журнала действий. Журнал только пополняется; старые записи удаляет обслуживание.
+// Ссылки на пользователя, мангу и источник не внешние ключи: запись переживает их удаление.
+type Event struct {
+	ID        int64          `db:"id" json:"id"`                 // Уникальный идентификатор события
+	Type      EventType      `db:"type" json:"type"`             // Тип события
+	ActorID   int64          `db:"actor_id" json:"actor_id"`     // Кто выполнил действие (Telegram user ID, 0 — система)
+	UserID    int64          `db:"user_id" json:"user_id"`       // Пользователь, которого касается событие (0 — нет)
+	MangaID   int            `db:"manga_id" json:"manga_id"`     // ID манги (0 — нет)
+	SourceID  int            `db:"source_id" json:"source_id"`   // ID источника (0 — нет)
+	Payload   map[string]any `db:"payload" json:"payload"`       // Подробности: новые и прежние значения и т.п.
+	CreatedAt time.Time      `db:"created_at" json:"created_at"` // Время события
+}
+
+// EventFilter условия выборки событий. Нулевые поля не ограничивают выборку.
+type EventFilter struct {
+	Type     EventType
+	ActorID  int64
+	UserID   int64
+	MangaID  int
+	SourceID int
+	Since    time.Time // Не раньше
+	Until    time.Time // Раньше
+	Limit    int       // Сколько последних событий вернуть
+}
+
+// OrphanAction что делать с мангой, на которую никто не подписан дольше отсрочки
+type OrphanAction string
+
+const (
+	OrphanFreeze OrphanAction = "freeze" // Перестать проверять (размораживается при новой подписке)
+	OrphanDelete OrphanAction = "delete" // Удалить вместе с главами
+)
+
+// RetentionPolicy политика хранения данных для обслуживания. Нулевая длительность или лимит — правило отключено.
+type RetentionPolicy struct {
+	ChaptersPerManga  int           // Сколько последних глав хранить на мангу
+	ArchiveChapters   bool          // Переносить вытесненные главы в chapters_archive вместо удаления
+	OrphanGrace       time.Duration // Отсрочка для манги без подписчиков
+	OrphanAction      OrphanAction  // Что делать с мангой без подписчиков после отсрочки
+	InactiveUserGrace time.Duration // Через сколько удалять неактивных пользователей (заблокировавших бота)
+	NotificationsTTL  time.Duration // Сколько хранить доставленные и недоставленные уведомления
+	CrawlHistoryTTL   time.Duration // Сколько хранить историю проверок
+	EventsTTL         time.Duration // Сколько хранить журнал действий
+}
+
+// MaintenanceReport результат обслуживания (при DryRun — что было бы сделано)
+type MaintenanceReport struct {
+	DryRun               bool
+	ChaptersArchived     int // Глав перенесено в архив
+	ChaptersDeleted      int // Глав удалено (включая перенесённые в архив)
+	MangaOrphaned        int // Манги впервые замечено без подписчиков
+	MangaFrozen          int // Манги заморожено
+	MangaDeleted         int // Манги удалено
+	UsersDeleted         int // Неактивных пользователей удалено
+	NotificationsDeleted int // Старых уведомлений удалено
+	CrawlHistoryDeleted  int // Старых записей истории проверок удалено
+	EventsDeleted        int // Старых событий журнала удалено
 }
 
 // RSS структура RSS фида
@@ -93,6 +327,8 @@ type Channel struct {
 
 // Item элемент RSS фида (глава манги)
 type Item struct {
-	Title string `xml:"title"`
-	Link  string `xml:"link"`
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	Author  string `xml:"author"`
+	Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"` // dc:creator — обычно команда переводчиков
 }
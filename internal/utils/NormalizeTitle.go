@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeTitle приводит название к виду для сопоставления между источниками:
+// нижний регистр, ё -> е, без пунктуации, одиночные пробелы.
+// Пример: "Угроза в моём сердце!" -> "угроза в моем сердце"
+func NormalizeTitle(title string) string {
+	var sb strings.Builder
+	space := false
+
+	for _, r := range strings.ToLower(title) {
+		if r == 'ё' {
+			r = 'е'
+		}
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && sb.Len() > 0 {
+				sb.WriteRune(' ')
+			}
+			sb.WriteRune(r)
+			space = false
+			continue
+		}
+
+		space = true
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Номер главы в URL readmanga-подобных сайтов: /manga_name/vol3/15
+	chapterURLPattern = regexp.MustCompile(`/vol\d+/(\d+(?:\.\d+)?)`)
+
+	// Номер главы в названии, от более точного паттерна к менее точному
+	chapterTitlePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)глава\s*(\d+(?:[.,]\d+)?)`),
+		regexp.MustCompile(`(?i)chapter\s*(\d+(?:[.,]\d+)?)`),
+		regexp.MustCompile(`(?i)(?:ch|гл)\.\s*(\d+(?:[.,]\d+)?)`),
+		// "Название 3 - 15": том - глава
+		regexp.MustCompile(`\d+\s+-\s+(\d+(?:[.,]\d+)?)`),
+	}
+)
+
+// ParseChapterNumber извлекает номер главы из URL или названия.
+// Возвращает нормализованный номер ("15", "15.5") или пустую строку, если номер не найден.
+func ParseChapterNumber(title, chapterURL string) string {
+	if matches := chapterURLPattern.FindStringSubmatch(chapterURL); len(matches) > 1 {
+		return normalizeChapterNumber(matches[1])
+	}
+
+	for _, pattern := range chapterTitlePatterns {
+		if matches := pattern.FindStringSubmatch(title); len(matches) > 1 {
+			return normalizeChapterNumber(matches[1])
+		}
+	}
+
+	return ""
+}
+
+// normalizeChapterNumber приводит номер к единому виду: "015,50" -> "15.5"
+func normalizeChapterNumber(number string) string {
+	number = strings.ReplaceAll(number, ",", ".")
+
+	intPart, fracPart, hasFrac := strings.Cut(number, ".")
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	if hasFrac {
+		fracPart = strings.TrimRight(fracPart, "0")
+	}
+
+	if fracPart == "" {
+		return intPart
+	}
+
+	return intPart + "." + fracPart
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cyrillicToLatin транслитерация для поиска. Должна совпадать с SQL-функцией search_key
+// из db/migrations/009_search.sql, иначе индекс и запрос разойдутся.
+var cyrillicToLatin = strings.NewReplacer(
+	"а", "a", "б", "b", "в", "v", "г", "g", "д", "d", "е", "e", "ё", "e",
+	"ж", "zh", "з", "z", "и", "i", "й", "i", "к", "k", "л", "l", "м", "m",
+	"н", "n", "о", "o", "п", "p", "р", "r", "с", "s", "т", "t", "у", "u",
+	"ф", "f", "х", "kh", "ц", "ts", "ч", "ch", "ш", "sh", "щ", "shch",
+	"ъ", "", "ы", "y", "ь", "", "э", "e", "ю", "yu", "я", "ya",
+)
+
+// SearchKey приводит название или запрос к ключу поиска: нижний регистр, кириллица латиницей.
+// Так "Берсерк" и "berserk" сравниваются как одинаковые строки.
+// Пример: "Ванпанчмен" -> "vanpanchmen"
+func SearchKey(text string) string {
+	return cyrillicToLatin.Replace(strings.ToLower(text))
+}
+
+// WordSimilarity упрощённый аналог word_similarity из pg_trgm: доля триграмм запроса,
+// найденных в названии (от 0 до 1). Используется там, где нет pg_trgm (SQLite, память).
+func WordSimilarity(query, title string) float64 {
+	queryTrigrams := trigrams(query)
+	if len(queryTrigrams) == 0 {
+		return 0
+	}
+
+	titleTrigrams := trigrams(title)
+
+	common := 0
+	for trigram := range queryTrigrams {
+		if titleTrigrams[trigram] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(queryTrigrams))
+}
+
+// trigrams множество триграмм строки по правилам pg_trgm:
+// слова из букв и цифр дополняются двумя пробелами в начале и одним в конце
+func trigrams(text string) map[string]bool {
+	result := make(map[string]bool)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			result[string(runes[i:i+3])] = true
+		}
+	}
+
+	return result
+}
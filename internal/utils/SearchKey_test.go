@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSearchKey(t *testing.T) {
+	tests := map[string]string{
+		"Берсерк":               "berserk",
+		"berserk":               "berserk",
+		"BERSERK":               "berserk",
+		"Ванпанчмен":            "vanpanchmen",
+		"Щит и Жезл":            "shchit i zhezl",
+		"Ёжик в тумане":         "ezhik v tumane",
+		"Подъезд Хаяо":          "podezd khayao",
+		"Семья шпиона: код 2":   "semya shpiona: kod 2",
+		"Цикада Чуя Юности Эхо": "tsikada chuya yunosti ekho",
+		"Атака титанов / AoT":   "ataka titanov / aot",
+		"":                      "",
+	}
+
+	for text, want := range tests {
+		if got := SearchKey(text); got != want {
+			t.Errorf("SearchKey(%q) = %q, ожидалось %q", text, got, want)
+		}
+	}
+}
+
+func TestWordSimilarity(t *testing.T) {
+	tests := []struct {
+		query string
+		title string
+		want  float64
+	}{
+		{"berserk", "berserk", 1},
+		{"berserk", "berserk: the golden age", 1},
+		{"BERSERK", "berserk", 1},
+		{"berserk", "vagabond", 0},
+		{"", "berserk", 0},
+		{"!!!", "berserk", 0},
+		// Опечатка: из 7 триграмм "bersek" в названии есть 5 ("  b", " be", "ber", "ers", "rse")
+		{"bersek", "berserk", 5.0 / 7.0},
+		// Порядок слов не важен; нет только "an " — "titan" в названии продолжается
+		{"titan ataka", "ataka titanov", 11.0 / 12.0},
+	}
+
+	for _, tt := range tests {
+		if got := WordSimilarity(tt.query, tt.title); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("WordSimilarity(%q, %q) = %.3f, ожидалось %.3f", tt.query, tt.title, got, tt.want)
+		}
+	}
+}
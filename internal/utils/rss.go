@@ -2,68 +2,39 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
-	"time"
 
+	"github.com/SemenovDmitry/manga-crawler-backend/internal/fetcher"
 	"github.com/SemenovDmitry/manga-crawler-backend/internal/types"
 )
 
-func FindRSSLink(baseUrl, mangaName string) (string, error) {
+// FindRSSLink ищет ссылку на RSS фид на странице манги.
+// Фетчер передаётся явно: в работе это fetcher.Default(), в тестах — фетчер httptest-сервера.
+func FindRSSLink(f *fetcher.Fetcher, source types.Source, mangaName string) (string, error) {
+	baseUrl := source.BaseURL
+
 	// Формируем URL страницы манги
 	mangaUrl := fmt.Sprintf("%s/%s", baseUrl, mangaName)
 
-	client := &http.Client{
-		Timeout: 60 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:          10,
-			IdleConnTimeout:       60 * time.Second,
-			DisableCompression:    false,
-			DisableKeepAlives:     false,
-			MaxIdleConnsPerHost:   10,
-			TLSHandshakeTimeout:   15 * time.Second,
-			ResponseHeaderTimeout: 30 * time.Second,
-			ExpectContinueTimeout: 5 * time.Second,
-		},
-	}
-
-	req, err := http.NewRequest("GET", mangaUrl, nil)
-	if err != nil {
-		return "", fmt.Errorf("ошибка создания запроса: %v", err)
-	}
-
-	req.Header = GetClientHeaders(baseUrl)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("ошибка запроса страницы: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("статус ошибки: %d %s", resp.StatusCode, resp.Status)
-	}
-
-	// Читаем и декомпрессируем тело
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("ошибка чтения ответа: %v", err)
-	}
-
-	decompressedBody, err := DecompressGzipBody(body)
+	resp, err := f.Get(context.Background(), fetcher.Request{
+		Source:        source.ParserName,
+		URL:           mangaUrl,
+		Kind:          fetcher.KindPage,
+		Referer:       baseUrl,
+		RespectRobots: source.RespectRobots,
+	})
 	if err != nil {
-		log.Printf("Ошибка декомпрессии: %v", err)
-		decompressedBody = body
+		return "", fmt.Errorf("ошибка запроса страницы: %w", err)
 	}
 
 	// Ищем RSS ссылку в HTML
-	htmlContent := string(decompressedBody)
+	htmlContent := string(resp.Body)
 
 	// Паттерн для поиска RSS ссылок
 	patterns := []*regexp.Regexp{
@@ -146,60 +117,28 @@ func FindRSSLink(baseUrl, mangaName string) (string, error) {
 	}
 
 	if rssLink == "" {
-		return "", fmt.Errorf("RSS ссылка не найдена для %s", mangaName)
+		return "", &fetcher.ParseError{URL: mangaUrl, Detail: fmt.Sprintf("RSS ссылка не найдена для %s", mangaName)}
 	}
 
 	return rssLink, nil
 }
 
-func GetRSSFeed(baseUrl string) (types.Channel, error) {
+// GetRSSFeed загружает и разбирает RSS фид манги. Неразборчивый XML возвращается как fetcher.ParseError.
+func GetRSSFeed(f *fetcher.Fetcher, source types.Source, rssUrl string) (types.Channel, error) {
 	var channel types.Channel
-	url := baseUrl
-
-	client := &http.Client{
-		Timeout: 60 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:          10,
-			IdleConnTimeout:       60 * time.Second,
-			DisableCompression:    false,
-			DisableKeepAlives:     false,
-			MaxIdleConnsPerHost:   10,
-			TLSHandshakeTimeout:   15 * time.Second,
-			ResponseHeaderTimeout: 30 * time.Second,
-			ExpectContinueTimeout: 5 * time.Second,
-		},
-	}
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return channel, fmt.Errorf("ошибка создания запроса: %v", err)
-	}
-
-	// Важные заголовки
-	req.Header = GetRSSHeaders(baseUrl)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return channel, fmt.Errorf("ошибка запроса RSS: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return channel, fmt.Errorf("статус ошибки: %d %s", resp.StatusCode, resp.Status)
-	}
 
-	// Читаем тело ответа
-	body, err := io.ReadAll(resp.Body)
+	resp, err := f.Get(context.Background(), fetcher.Request{
+		Source:        source.ParserName,
+		URL:           rssUrl,
+		Kind:          fetcher.KindFeed,
+		Referer:       source.BaseURL,
+		RespectRobots: source.RespectRobots,
+	})
 	if err != nil {
-		return channel, fmt.Errorf("ошибка чтения ответа: %v", err)
+		return channel, fmt.Errorf("ошибка запроса RSS: %w", err)
 	}
 
-	// Декомпрессируем если нужно
-	decompressedBody, err := DecompressGzipBody(body)
-	if err != nil {
-		log.Printf("Ошибка декомпрессии: %v", err)
-		decompressedBody = body
-	}
+	decompressedBody := resp.Body
 
 	// Убираем BOM (Byte Order Mark) если есть
 	decompressedBody = bytes.TrimPrefix(decompressedBody, []byte{0xEF, 0xBB, 0xBF})
@@ -217,10 +156,7 @@ func GetRSSFeed(baseUrl string) (types.Channel, error) {
 		log.Printf("Ошибка парсинга XML: %v", err)
 		log.Printf("Начало данных: %s", sample)
 
-		// Пробуем исправить XML - убираем невалидные символы
-		if err := xml.Unmarshal(decompressedBody, &rss); err != nil {
-			return channel, fmt.Errorf("ошибка парсинга XML после очистки: %v", err)
-		}
+		return channel, &fetcher.ParseError{URL: rssUrl, Detail: "ошибка парсинга XML", Err: err}
 	}
 
 	return rss.Channel, nil
@@ -241,22 +177,20 @@ func TransformRSSFeed(feed types.Channel) (*types.Manga, error) {
 
 	for i, item := range filteredItems {
 		manga.Chapters[i] = types.Chapter{
-			Title: item.Title,
-			URL:   item.Link,
+			Title:      item.Title,
+			URL:        item.Link,
+			Number:     ParseChapterNumber(item.Title, item.Link),
+			Translator: itemTranslator(item),
 		}
 	}
 
 	return manga, nil
 }
 
-// GetRSSHeaders возвращает заголовки для запросов RSS
-func GetRSSHeaders(baseUrl string) http.Header {
-	return http.Header{
-		"User-Agent":      {GetRandomUserAgent()},
-		"Accept":          {"application/xml,text/xml,application/rss+xml"},
-		"Accept-Language": {"ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7"},
-		"Accept-Encoding": {"gzip, deflate"},
-		"Referer":         {baseUrl + "/"},
-		"Connection":      {"keep-alive"},
+// itemTranslator возвращает команду переводчиков элемента фида, если источник её указывает
+func itemTranslator(item types.Item) string {
+	if creator := strings.TrimSpace(item.Creator); creator != "" {
+		return creator
 	}
+	return strings.TrimSpace(item.Author)
 }